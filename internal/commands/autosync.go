@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/git"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// anyTemplate stands for changes to any template, in place of a template
+// name, for commands that cannot tell which templates they changed.
+const anyTemplate = "*"
+
+// autoSyncTarget is a sync profile and what changed in it: a template of the
+// profile, "" for the config file, or anyTemplate.
+type autoSyncTarget struct {
+	profile  string
+	template string
+}
+
+// autoSyncTargets returns the sync profiles that hold template, a template
+// name as the commands take it, "" for the config file or anyTemplate.
+// Templates in the directory of a named profile belong to that profile.
+func autoSyncTargets(configDir, template string) ([]autoSyncTarget, error) {
+	profiles, err := sync.Profiles(configDir)
+	if err != nil {
+		return nil, err
+	}
+	switch template {
+	case "":
+		return []autoSyncTarget{{}}, nil
+	case anyTemplate:
+		targets := []autoSyncTarget{{template: anyTemplate}}
+		for _, profile := range profiles {
+			targets = append(targets, autoSyncTarget{profile: profile, template: anyTemplate})
+		}
+		return targets, nil
+	}
+	if profile, inner, ok := strings.Cut(template, "/"); ok {
+		for _, p := range profiles {
+			if p == profile {
+				return []autoSyncTarget{{profile: profile, template: inner}}, nil
+			}
+		}
+	}
+	return []autoSyncTarget{{template: template}}, nil
+}
+
+// plan computes the changes to the sync directory of manager for t.
+func (t autoSyncTarget) plan(manager *sync.Manager) (*diff.DiffResult, error) {
+	switch t.template {
+	case "":
+		return manager.PlanPushConfig()
+	case anyTemplate:
+		return manager.PlanPush()
+	}
+	return manager.PlanPushTemplate(t.template)
+}
+
+// message returns the commit message for plan: message itself, followed by
+// the changed paths when the target does not name what changed.
+func (t autoSyncTarget) message(message string, plan *diff.DiffResult) string {
+	if t.template != anyTemplate {
+		return message
+	}
+	return message + ": " + strings.Join(planPaths(plan), ", ")
+}
+
+// planPaths returns the paths changed by plan.
+func planPaths(plan *diff.DiffResult) []string {
+	var paths []string
+	for _, changes := range [][]diff.FileChange{plan.Added, plan.Modified, plan.Deleted} {
+		paths = append(paths, changePaths(changes)...)
+	}
+	return paths
+}
+
+// autoSync records the changes a command made to template (or to the config
+// file if template is "") in the sync repository when auto_sync is enabled,
+// committing them with message and pushing them in "push" mode. Templates
+// of a named sync profile are synced with that profile. Only the changes of
+// template are committed; other pending changes are left for
+// 'dotgh sync push'. Problems are reported as warnings because the command
+// itself has already succeeded.
+func autoSync(cmd *cobra.Command, configDir, template, message string) {
+	w := cmd.OutOrStdout()
+
+	cfg, err := config.Resolve(configDir, "")
+	if err != nil {
+		return
+	}
+	mode := cfg.GetAutoSync()
+	switch mode {
+	case config.AutoSyncOff:
+		return
+	case config.AutoSyncCommit, config.AutoSyncPush:
+	default:
+		_, _ = fmt.Fprintf(w, "Warning: unknown auto_sync mode %q (expected %q, %q or %q)\n", mode, config.AutoSyncOff, config.AutoSyncCommit, config.AutoSyncPush)
+		return
+	}
+
+	targets, err := autoSyncTargets(configDir, template)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: auto sync skipped: %v\n", err)
+		return
+	}
+	for _, target := range targets {
+		autoSyncProfile(cmd, configDir, target, mode, message)
+	}
+}
+
+// autoSyncProfile commits, and in "push" mode pushes, the changes of target
+// in its sync profile.
+func autoSyncProfile(cmd *cobra.Command, configDir string, target autoSyncTarget, mode, message string) {
+	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+
+	warn := func(format string, args ...any) {
+		_, _ = fmt.Fprintf(w, "Warning: auto sync skipped: "+format+"\n", args...)
+	}
+
+	manager, cfg, err := newSyncManager(configDir, target.profile)
+	if err != nil {
+		warn("%v", err)
+		return
+	}
+	if !manager.IsInitialized() {
+		warn("sync is not initialized. Run '%s' first", syncCommand(manager, "init <repository>"))
+		return
+	}
+	if manager.IsMerging() {
+		warn("the sync repository has unresolved conflicts. Run '%s' first", syncCommand(manager, "resolve"))
+		return
+	}
+
+	plan, err := target.plan(manager)
+	if err != nil {
+		warn("compute changes: %v", err)
+		return
+	}
+	if !plan.HasChanges() {
+		return
+	}
+
+	// Nobody is there to review secrets, so only secrets.mode decides
+	if _, err := checkSecrets(w, cfg, plaintextPaths(manager, plan), manager.LocalPath, secretOptions{Yes: true}); err != nil {
+		warn("%v. Run '%s' to review them", err, syncCommand(manager, "push"))
+		return
+	}
+
+	if err := manager.ApplyPush(plan); err != nil {
+		warn("copy to sync directory: %v", err)
+		return
+	}
+	message = target.message(message, plan)
+	if err := manager.CommitPaths(ctx, message, planPaths(plan)); err != nil {
+		warn("commit changes: %v", err)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Auto sync: committed %q\n", message)
+
+	if mode != config.AutoSyncPush {
+		return
+	}
+	if err := manager.Push(ctx); err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: auto sync could not push: %v. Run '%s' later\n", err, syncCommand(manager, "push"))
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Auto sync: pushed to remote")
+}
+
+// pullIfStale pulls config and templates from the sync repositories before
+// a read command when sync.pull_if_stale is set and the last pull is older
+// than it. To never lose or merge local work without review, a profile is
+// skipped while it has changes or commits not pushed yet. Notices go to
+// stderr so that the output of the command is unchanged.
+func pullIfStale(cmd *cobra.Command, configDir string) {
+	cfg, err := config.Resolve(configDir, "")
+	if err != nil || cfg.Sync.PullIfStale == "" {
+		return
+	}
+	w := cmd.ErrOrStderr()
+	maxAge, err := time.ParseDuration(cfg.Sync.PullIfStale)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: sync.pull_if_stale: %v\n", err)
+		return
+	}
+
+	profiles, err := sync.Profiles(configDir)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: %v\n", err)
+		return
+	}
+	for _, profile := range append([]string{""}, profiles...) {
+		pullProfileIfStale(cmd, configDir, profile, maxAge)
+	}
+}
+
+// pullProfileIfStale pulls a sync profile for pullIfStale.
+func pullProfileIfStale(cmd *cobra.Command, configDir, profile string, maxAge time.Duration) {
+	w := cmd.ErrOrStderr()
+	ctx := cmd.Context()
+
+	manager, _, err := newSyncManager(configDir, profile)
+	if err != nil || !manager.IsInitialized() {
+		return
+	}
+	if last := manager.LastPull(); !last.IsZero() && time.Since(last) < maxAge {
+		return
+	}
+	skip := func(reason, sub string) {
+		_, _ = fmt.Fprintf(w, "Warning: stale templates not refreshed: %s. Run '%s'\n", reason, syncCommand(manager, sub))
+	}
+	if manager.IsMerging() {
+		skip("the sync repository has unresolved conflicts", "resolve")
+		return
+	}
+	if plan, err := manager.PlanPush(); err != nil || plan.HasChanges() {
+		skip("there are local changes not pushed yet", "push")
+		return
+	}
+	if ahead, _, err := manager.AheadBehind(ctx); err == nil && ahead > 0 {
+		skip("there are local commits not pushed yet", "push")
+		return
+	}
+
+	if err := manager.Pull(ctx); err != nil && !errors.Is(err, git.ErrNoUpstream) {
+		skip(fmt.Sprintf("pull from remote: %v", err), "pull")
+		return
+	}
+	plan, err := manager.PlanPull()
+	if err == nil {
+		err = manager.ApplyPull(plan)
+	}
+	if err != nil {
+		skip(fmt.Sprintf("copy from sync directory: %v", err), "pull")
+		return
+	}
+	if n := len(planPaths(plan)); n > 0 {
+		_, _ = fmt.Fprintf(w, "Pulled %d changed file(s) from the sync repository.\n", n)
+	}
+}
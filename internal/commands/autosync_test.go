@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	run := func(c *cobra.Command, args ...string) (string, error) {
+		var buf bytes.Buffer
+		c.SetArgs(args)
+		c.SetOut(&buf)
+		c.SetErr(&bytes.Buffer{})
+		err := c.Execute()
+		return buf.String(), err
+	}
+	remoteLog := func(bareDir string) string {
+		out, err := exec.Command("git", "-C", bareDir, "log", "--format=%s", "main").Output()
+		require.NoError(t, err)
+		return string(out)
+	}
+	setup := func(mode string) (configDir, bareDir string) {
+		bareDir = t.TempDir()
+		require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", bareDir).Run())
+
+		configDir = t.TempDir()
+		configYAML := "editor: \"true\"\nincludes:\n  - AGENTS.md\nauto_sync: " + mode + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configYAML), 0644))
+		_, err := run(NewSyncInitCmd(configDir), bareDir, "-b", "main")
+		require.NoError(t, err)
+		_, err = run(NewSyncPushCmd(configDir), "-m", "initial", "--yes")
+		require.NoError(t, err)
+		return configDir, bareDir
+	}
+
+	t.Run("push mode commits and pushes template changes", func(t *testing.T) {
+		configDir, bareDir := setup("push")
+		createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work")
+
+		output, err := run(NewEditCmd(filepath.Join(configDir, "templates"), configDir), "work")
+		require.NoError(t, err)
+		assert.Contains(t, output, `Auto sync: committed "Edit template 'work'"`)
+		assert.Contains(t, output, "Auto sync: pushed to remote")
+		assert.Contains(t, remoteLog(bareDir), "Edit template 'work'")
+
+		data, err := os.ReadFile(filepath.Join(configDir, ".sync", "templates", "work", "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Work", string(data))
+	})
+
+	t.Run("commit mode does not push", func(t *testing.T) {
+		configDir, bareDir := setup("commit")
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("editor: \"true\"\nincludes:\n  - CLAUDE.md\nauto_sync: commit\n"), 0644))
+
+		output, err := run(NewConfigEditCmd(configDir))
+		require.NoError(t, err)
+		assert.Contains(t, output, `Auto sync: committed "Edit config"`)
+		assert.NotContains(t, output, "pushed")
+		assert.NotContains(t, remoteLog(bareDir), "Edit config")
+
+		output, err = run(NewSyncStatusCmd(configDir))
+		require.NoError(t, err)
+		assert.Contains(t, output, "1 ahead")
+	})
+
+	t.Run("nothing happens without changes or when off", func(t *testing.T) {
+		configDir, bareDir := setup("push")
+		output, err := run(NewConfigEditCmd(configDir))
+		require.NoError(t, err)
+		assert.NotContains(t, output, "Auto sync")
+		assert.Equal(t, "initial\nInitial commit\n", remoteLog(bareDir))
+
+		configDir, bareDir = setup("off")
+		createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work")
+		output, err = run(NewEditCmd(filepath.Join(configDir, "templates"), configDir), "work")
+		require.NoError(t, err)
+		assert.NotContains(t, output, "Auto sync")
+		assert.Equal(t, "initial\nInitial commit\n", remoteLog(bareDir))
+	})
+
+	t.Run("commits only the changed template", func(t *testing.T) {
+		configDir, bareDir := setup("push")
+		createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work")
+		createTestFile(t, filepath.Join(configDir, "templates", "home"), "AGENTS.md", "# Home")
+
+		output, err := run(NewEditCmd(filepath.Join(configDir, "templates"), configDir), "work")
+		require.NoError(t, err)
+		assert.Contains(t, output, `Auto sync: committed "Edit template 'work'"`)
+		files, err := exec.Command("git", "-C", bareDir, "show", "--name-only", "--format=", "main").Output()
+		require.NoError(t, err)
+		assert.Equal(t, "templates/work/AGENTS.md\n", string(files))
+
+		output, err = run(NewSyncStatusCmd(configDir))
+		require.NoError(t, err)
+		assert.Contains(t, output, "templates/home", "other changes are left for sync push")
+	})
+
+	t.Run("syncs templates of a named profile with the profile", func(t *testing.T) {
+		configDir, bareDir := setup("push")
+		workBare := t.TempDir()
+		require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", workBare).Run())
+		_, err := run(NewSyncInitCmd(configDir), workBare, "-b", "main", "--profile", "work")
+		require.NoError(t, err)
+		createTestFile(t, filepath.Join(configDir, "templates", "work", "api"), "AGENTS.md", "# API")
+
+		output, err := run(NewEditCmd(filepath.Join(configDir, "templates"), configDir), "work/api")
+		require.NoError(t, err)
+		assert.Contains(t, output, `Auto sync: committed "Edit template 'work/api'"`)
+		assert.Contains(t, remoteLog(workBare), "Edit template 'work/api'")
+		assert.NotContains(t, remoteLog(bareDir), "Edit template")
+
+		data, err := os.ReadFile(filepath.Join(configDir, ".sync-profiles", "work", "templates", "api", "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# API", string(data))
+	})
+
+	t.Run("lists the changed paths when the template is not known", func(t *testing.T) {
+		configDir, bareDir := setup("push")
+		createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work")
+
+		output, err := run(NewEditCmd(filepath.Join(configDir, "templates"), configDir))
+		require.NoError(t, err)
+		assert.Contains(t, output, `Auto sync: committed "Edit templates: templates/work/AGENTS.md"`)
+		assert.Contains(t, remoteLog(bareDir), "Edit templates: templates/work/AGENTS.md")
+	})
+
+	t.Run("warns when sync is not initialized", func(t *testing.T) {
+		configDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("editor: \"true\"\nauto_sync: commit\n"), 0644))
+		output, err := run(NewConfigEditCmd(configDir))
+		require.NoError(t, err)
+		assert.Contains(t, output, "Warning: auto sync skipped: sync is not initialized")
+	})
+}
+
+func TestSyncPullIfStale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", bareDir).Run())
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("includes:\n  - AGENTS.md\n"), 0644))
+
+	initCmd := NewSyncInitCmd(configDir)
+	initCmd.SetArgs([]string{bareDir, "-b", "main"})
+	initCmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, initCmd.Execute())
+	pushCmd := NewSyncPushCmd(configDir)
+	pushCmd.SetArgs([]string{"--yes"})
+	pushCmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, pushCmd.Execute())
+
+	pull := func() string {
+		cmd := NewSyncPullCmd(configDir)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"--if-stale", "1h", "--yes"})
+		require.NoError(t, cmd.Execute())
+		return buf.String()
+	}
+
+	// The first pull records the time
+	output := pull()
+	assert.NotContains(t, output, "last pulled")
+
+	output = pull()
+	assert.Contains(t, output, "last pulled")
+
+	stamp := filepath.Join(configDir, ".sync", ".git", "dotgh-last-pull")
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(stamp, old, old))
+	output = pull()
+	assert.NotContains(t, output, "last pulled")
+}
+
+func TestPullIfStale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	run := func(c *cobra.Command, args ...string) {
+		t.Helper()
+		c.SetArgs(args)
+		c.SetOut(&bytes.Buffer{})
+		c.SetErr(&bytes.Buffer{})
+		require.NoError(t, c.Execute())
+	}
+	refresh := func(configDir string) string {
+		var stderr bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetContext(t.Context())
+		cmd.SetErr(&stderr)
+		pullIfStale(cmd, configDir)
+		return stderr.String()
+	}
+	age := func(configDir string) {
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(configDir, ".sync", ".git", "dotgh-last-pull"), old, old))
+	}
+
+	bareDir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", bareDir).Run())
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("includes:\n  - AGENTS.md\nsync:\n  pull_if_stale: 1h\n"), 0644))
+	createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work")
+	run(NewSyncInitCmd(configDir), bareDir, "-b", "main")
+	run(NewSyncPushCmd(configDir), "--yes")
+
+	otherDir := t.TempDir()
+	run(NewSyncInitCmd(otherDir), bareDir, "-b", "main")
+	run(NewSyncPullCmd(otherDir), "--yes")
+	otherFile := filepath.Join(otherDir, "templates", "work", "AGENTS.md")
+
+	createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work v2")
+	run(NewSyncPushCmd(configDir), "--yes")
+
+	t.Run("skips a recent pull", func(t *testing.T) {
+		assert.Empty(t, refresh(otherDir))
+		data, err := os.ReadFile(otherFile)
+		require.NoError(t, err)
+		assert.Equal(t, "# Work", string(data))
+	})
+
+	t.Run("pulls when stale", func(t *testing.T) {
+		age(otherDir)
+		assert.Contains(t, refresh(otherDir), "Pulled 1 changed file(s) from the sync repository.")
+		data, err := os.ReadFile(otherFile)
+		require.NoError(t, err)
+		assert.Equal(t, "# Work v2", string(data))
+	})
+
+	t.Run("keeps local changes", func(t *testing.T) {
+		createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work v3")
+		run(NewSyncPushCmd(configDir), "--yes")
+		createTestFile(t, filepath.Join(otherDir, "templates", "work"), "AGENTS.md", "# Local")
+		age(otherDir)
+
+		output := refresh(otherDir)
+		assert.Contains(t, output, "Warning: stale templates not refreshed: there are local changes not pushed yet. Run 'dotgh sync push'")
+		data, err := os.ReadFile(otherFile)
+		require.NoError(t, err)
+		assert.Equal(t, "# Local", string(data))
+	})
+}
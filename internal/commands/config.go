@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/openjny/dotgh/internal/config"
 	"github.com/openjny/dotgh/internal/editor"
@@ -18,13 +21,176 @@ var configCmd = &cobra.Command{
 	Long:  `View and edit the dotgh configuration file.`,
 }
 
+// configShowCmdLong is the long description for the config show command.
+const configShowCmdLong = `Display the current configuration settings from the config file or defaults if no file exists.
+
+The selected profile and the project config file (.dotgh.yaml in the current
+directory or its parents) are applied. Use --origin to show where each value
+came from.`
+
 var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Display current configuration in YAML format",
-	Long:  `Display the current configuration settings from the config file or defaults if no file exists.`,
+	Long:  configShowCmdLong,
 	RunE:  runConfigShow,
 }
 
+var configShowOrigin bool
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the config profiles",
+	Long:  configProfilesCmdLong,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigProfiles,
+}
+
+// configProfilesCmdLong is the long description for the config profiles command.
+const configProfilesCmdLong = `List the profiles defined in the configuration file.
+
+A profile overrides editor, templates_dir, presets, includes and excludes of
+the base configuration. Select one for a single command with --profile
+<name>, or set DOTGH_PROFILE=<name> to apply it to every command. The active
+profile is marked with '*'.
+
+Example config:
+  profiles:
+    claude:
+      includes:
+        - "CLAUDE.md"
+        - ".claude/commands/*.md"`
+
+var configPresetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "List the built-in presets",
+	Long:  configPresetsCmdLong,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigPresets,
+}
+
+// configPresetsCmdLong is the long description for the config presets command.
+const configPresetsCmdLong = `List the built-in presets and the includes and excludes each adds.
+
+A preset bundles the files of an AI coding tool. Name presets in the config
+file (or in a profile or .dotgh.yaml) to add their includes and excludes to
+your own. The presets in use are marked with '*'.
+
+Example config:
+  presets: [copilot, claude]
+
+Examples:
+  dotgh config presets
+  dotgh config add presets cursor`
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration files for errors",
+	Long:  configValidateCmdLong,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigValidate,
+}
+
+// configValidateCmdLong is the long description for the config validate command.
+const configValidateCmdLong = `Check the config file and the project config file (.dotgh.yaml in the
+current directory or its parents) for errors.
+
+Reports unknown keys, values of the wrong type, invalid glob patterns, empty
+includes, unknown modes, invalid durations and templates directories that
+cannot be written, each with its line and column. Exits with an error if any
+problem is found.
+
+Editors with YAML language support can check the config file as you type
+using the JSON Schema at:
+  ` + config.SchemaURL
+
+// configKeysHelp explains the keys taken by get, set, add and remove.
+const configKeysHelp = `Keys are dotted paths such as "editor", "sync.timeout" or
+"profiles.work.includes".`
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a configuration key",
+	Long:  configGetCmdLong,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+// configGetCmdLong is the long description for the config get command.
+const configGetCmdLong = `Print the effective value of a configuration key, after the profile, the
+project config file and the environment variables are applied. Lists are
+printed one item per line, sections as YAML. Nothing is printed if the key
+is not set.
+
+` + configKeysHelp + `
+
+Examples:
+  dotgh config get editor
+  dotgh config get includes`
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set a configuration key",
+	Long:  configSetCmdLong,
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runConfigSet,
+}
+
+// configSetCmdLong is the long description for the config set command.
+const configSetCmdLong = `Set a key in the config file. List keys such as includes take one or more
+values, which replace the whole list.
+
+The file is edited in place: comments and the order of keys are kept. It is
+created with the defaults if it does not exist. Invalid values are rejected.
+
+` + configKeysHelp + `
+
+Examples:
+  dotgh config set editor "code --wait"
+  dotgh config set sync.timeout 30s
+  dotgh config set excludes ".github/prompts/local.prompt.md"`
+
+var configAddCmd = &cobra.Command{
+	Use:   "add <key> <value>...",
+	Short: "Add values to a configuration list",
+	Long:  configAddCmdLong,
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runConfigAdd,
+}
+
+// configAddCmdLong is the long description for the config add command.
+const configAddCmdLong = `Append values to a list key in the config file, such as includes. Values
+that are already in the list are skipped, so running the command again
+changes nothing.
+
+The file is edited in place: comments and the order of keys are kept. It is
+created with the defaults if it does not exist. Invalid values are rejected.
+
+` + configKeysHelp + `
+
+Examples:
+  dotgh config add includes "CLAUDE.md" ".claude/commands/*.md"
+  dotgh config add profiles.work.excludes "AGENTS.md"`
+
+var configRemoveCmd = &cobra.Command{
+	Use:   "remove <key> [value...]",
+	Short: "Remove values from a configuration list, or a key",
+	Long:  configRemoveCmdLong,
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runConfigRemove,
+}
+
+// configRemoveCmdLong is the long description for the config remove command.
+const configRemoveCmdLong = `Remove values from a list key in the config file, or remove the key itself
+if no values are given. Values that are not in the list are ignored.
+
+The file is edited in place: comments and the order of keys are kept.
+
+` + configKeysHelp + `
+
+Examples:
+  dotgh config remove includes ".vscode/mcp.json"
+  dotgh config remove editor`
+
 var configEditCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Open configuration file in the user's preferred editor",
@@ -34,8 +200,17 @@ If the config file doesn't exist, it will be created with default values first.`
 }
 
 func init() {
+	configShowCmd.Flags().BoolVar(&configShowOrigin, "origin", false, "Show where each value came from")
+
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configProfilesCmd)
+	configCmd.AddCommand(configPresetsCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configAddCmd)
+	configCmd.AddCommand(configRemoveCmd)
 }
 
 // NewConfigCmd creates a new config command for testing.
@@ -54,21 +229,66 @@ func NewConfigCmd() *cobra.Command {
 		Use:   "edit",
 		Short: "Open configuration file in the user's preferred editor",
 	}
+	profilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the config profiles",
+	}
+
+	presetsCmd := &cobra.Command{
+		Use:   "presets",
+		Short: "List the built-in presets",
+	}
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration files for errors",
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a configuration key",
+	}
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>...",
+		Short: "Set a configuration key",
+	}
+	addCmd := &cobra.Command{
+		Use:   "add <key> <value>...",
+		Short: "Add values to a configuration list",
+	}
+	removeCmd := &cobra.Command{
+		Use:   "remove <key> [value...]",
+		Short: "Remove values from a configuration list, or a key",
+	}
 
 	cmd.AddCommand(showCmd)
 	cmd.AddCommand(editCmd)
+	cmd.AddCommand(profilesCmd)
+	cmd.AddCommand(presetsCmd)
+	cmd.AddCommand(validateCmd)
+	cmd.AddCommand(getCmd)
+	cmd.AddCommand(setCmd)
+	cmd.AddCommand(addCmd)
+	cmd.AddCommand(removeCmd)
 	return cmd
 }
 
 // NewConfigShowCmd creates a new config show command with a custom config directory.
 func NewConfigShowCmd(configDir string) *cobra.Command {
+	var origin bool
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Display current configuration in YAML format",
+		Long:  configShowCmdLong,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Temporarily set the global variable
+			oldOrigin := configShowOrigin
+			configShowOrigin = origin
+			defer func() { configShowOrigin = oldOrigin }()
+
 			return runConfigShowWithDir(cmd, configDir)
 		},
 	}
+	cmd.Flags().BoolVar(&origin, "origin", false, "Show where each value came from")
 	return cmd
 }
 
@@ -84,25 +304,151 @@ func NewConfigEditCmd(configDir string) *cobra.Command {
 	return cmd
 }
 
+// NewConfigProfilesCmd creates a new config profiles command with a custom config directory.
+func NewConfigProfilesCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the config profiles",
+		Long:  configProfilesCmdLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigProfilesWithDir(cmd, configDir)
+		},
+	}
+	return cmd
+}
+
+// NewConfigValidateCmd creates a new config validate command with a custom config directory.
+func NewConfigValidateCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration files for errors",
+		Long:  configValidateCmdLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigValidateWithDir(cmd, configDir)
+		},
+	}
+	return cmd
+}
+
+// NewConfigPresetsCmd creates a new config presets command with a custom config directory.
+func NewConfigPresetsCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "presets",
+		Short: "List the built-in presets",
+		Long:  configPresetsCmdLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigPresetsWithDir(cmd, configDir)
+		},
+	}
+	return cmd
+}
+
+// NewConfigGetCmd creates a new config get command with a custom config directory.
+func NewConfigGetCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a configuration key",
+		Long:  configGetCmdLong,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigGetWithDir(cmd, configDir, args[0])
+		},
+	}
+	return cmd
+}
+
+// NewConfigSetCmd creates a new config set command with a custom config directory.
+func NewConfigSetCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>...",
+		Short: "Set a configuration key",
+		Long:  configSetCmdLong,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigSetWithDir(cmd, configDir, args)
+		},
+	}
+	return cmd
+}
+
+// NewConfigAddCmd creates a new config add command with a custom config directory.
+func NewConfigAddCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <key> <value>...",
+		Short: "Add values to a configuration list",
+		Long:  configAddCmdLong,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigAddWithDir(cmd, configDir, args)
+		},
+	}
+	return cmd
+}
+
+// NewConfigRemoveCmd creates a new config remove command with a custom config directory.
+func NewConfigRemoveCmd(configDir string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <key> [value...]",
+		Short: "Remove values from a configuration list, or a key",
+		Long:  configRemoveCmdLong,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigRemoveWithDir(cmd, configDir, args)
+		},
+	}
+	return cmd
+}
+
 func runConfigShow(cmd *cobra.Command, args []string) error {
 	return runConfigShowWithDir(cmd, config.GetConfigDir())
 }
 
 func runConfigShowWithDir(cmd *cobra.Command, configDir string) error {
-	configPath := filepath.Join(configDir, "config.yaml")
+	configPath := config.ConfigFilePath(configDir)
 	w := cmd.OutOrStdout()
 
 	// Print config file path as comment
 	_, _ = fmt.Fprintf(w, "# Config file: %s\n", configPath)
 
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+
 	// Load config (defaults if file doesn't exist)
-	cfg, err := config.LoadFromDir(configDir)
+	cfg, err := config.Resolve(configDir, cwd)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	if cfg.ActiveProfile != "" {
+		_, _ = fmt.Fprintf(w, "# Profile: %s\n", cfg.ActiveProfile)
+	}
+	if cfg.ProjectFile != "" {
+		_, _ = fmt.Fprintf(w, "# Project config file: %s\n", cfg.ProjectFile)
+	}
 
 	// Marshal to YAML
-	data, err := yaml.Marshal(cfg)
+	var node yaml.Node
+	if err := node.Encode(cfg); err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if configShowOrigin {
+		// Note the origin next to each top-level key
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			comment := "from " + cfg.Origin(key.Value)
+			if value.Kind == yaml.ScalarNode || len(value.Content) == 0 {
+				// Single-line values carry the comment themselves
+				value.LineComment = comment
+			} else {
+				key.LineComment = comment
+			}
+		}
+	}
+	data, err := yaml.Marshal(&node)
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
@@ -116,7 +462,7 @@ func runConfigEdit(cmd *cobra.Command, args []string) error {
 }
 
 func runConfigEditWithDir(cmd *cobra.Command, configDir string) error {
-	configPath := filepath.Join(configDir, "config.yaml")
+	configPath := config.ConfigFilePath(configDir)
 
 	// Ensure config file exists
 	if err := ensureConfigExists(configDir); err != nil {
@@ -124,7 +470,7 @@ func runConfigEditWithDir(cmd *cobra.Command, configDir string) error {
 	}
 
 	// Load config to get editor setting
-	cfg, err := config.LoadFromDir(configDir)
+	cfg, err := config.Resolve(configDir, "")
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -136,12 +482,283 @@ func runConfigEditWithDir(cmd *cobra.Command, configDir string) error {
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 
-	return execCmd.Run()
+	if err := execCmd.Run(); err != nil {
+		return err
+	}
+	autoSync(cmd, configDir, "", "Edit config")
+	return nil
+}
+
+func runConfigProfiles(cmd *cobra.Command, args []string) error {
+	return runConfigProfilesWithDir(cmd, config.GetConfigDir())
+}
+
+func runConfigProfilesWithDir(cmd *cobra.Command, configDir string) error {
+	w := cmd.OutOrStdout()
+
+	// The base config lists every profile, even if the selected one is unknown
+	cfg, err := config.LoadBaseFromDir(configDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	names := cfg.ProfileNames()
+	if len(names) == 0 {
+		_, _ = fmt.Fprintln(w, "No profiles defined.")
+		_, _ = fmt.Fprintln(w, "Add them under 'profiles' with 'dotgh config edit'.")
+		return nil
+	}
+
+	selected := config.SelectedProfile()
+	_, _ = fmt.Fprintln(w, "Profiles:")
+	for _, name := range names {
+		marker := " "
+		if name == selected {
+			marker = "*"
+		}
+		_, _ = fmt.Fprintf(w, "%s %s%s\n", marker, name, describeProfile(cfg.Profiles[name]))
+	}
+	if selected != "" && !slices.Contains(names, selected) {
+		_, _ = fmt.Fprintf(w, "\nWarning: the selected profile %q is not defined.\n", selected)
+	}
+	return nil
+}
+
+func runConfigPresets(cmd *cobra.Command, args []string) error {
+	return runConfigPresetsWithDir(cmd, config.GetConfigDir())
+}
+
+func runConfigPresetsWithDir(cmd *cobra.Command, configDir string) error {
+	w := cmd.OutOrStdout()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	// The presets are listed even if the config names an unknown one
+	var active []string
+	cfg, loadErr := config.Resolve(configDir, cwd)
+	if loadErr == nil {
+		active = cfg.Presets
+	}
+
+	_, _ = fmt.Fprintln(w, "Presets:")
+	for _, preset := range config.Presets {
+		marker := " "
+		if slices.Contains(active, preset.Name) {
+			marker = "*"
+		}
+		_, _ = fmt.Fprintf(w, "%s %-10s %s\n", marker, preset.Name, preset.Description)
+		_, _ = fmt.Fprintf(w, "    includes: %s\n", strings.Join(preset.Includes, ", "))
+		if len(preset.Excludes) > 0 {
+			_, _ = fmt.Fprintf(w, "    excludes: %s\n", strings.Join(preset.Excludes, ", "))
+		}
+	}
+	if loadErr != nil {
+		_, _ = fmt.Fprintf(w, "\nWarning: load config: %v\n", loadErr)
+	}
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	return runConfigValidateWithDir(cmd, config.GetConfigDir())
+}
+
+func runConfigValidateWithDir(cmd *cobra.Command, configDir string) error {
+	w := cmd.OutOrStdout()
+	count := 0
+
+	configPath := config.ConfigFilePath(configDir)
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && rootConfigFile == "" {
+		_, _ = fmt.Fprintf(w, "%s: not found, the defaults are used\n", configPath)
+	} else {
+		problems, err := config.ValidateFile(configPath)
+		if err != nil {
+			return err
+		}
+		count += printProblems(w, configPath, problems)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	if projectPath := config.FindProjectFile(cwd); projectPath != "" {
+		problems, err := config.ValidateProjectFile(projectPath)
+		if err != nil {
+			return err
+		}
+		count += printProblems(w, projectPath, problems)
+	}
+
+	if count > 0 {
+		// The problems are the result, not a usage error
+		cmd.SilenceUsage = true
+		return fmt.Errorf("found %d problem(s)", count)
+	}
+	return nil
+}
+
+// printProblems prints the problems found in the file at path, one per line
+// as "path:line:column: key: message", and returns how many there are.
+func printProblems(w io.Writer, path string, problems []config.Problem) int {
+	if len(problems) == 0 {
+		_, _ = fmt.Fprintf(w, "%s: ok\n", path)
+		return 0
+	}
+	for _, p := range problems {
+		if p.Line > 0 {
+			_, _ = fmt.Fprintf(w, "%s:%s\n", path, p)
+		} else {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", path, p)
+		}
+	}
+	return len(problems)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	return runConfigGetWithDir(cmd, config.GetConfigDir(), args[0])
+}
+
+func runConfigGetWithDir(cmd *cobra.Command, configDir, key string) error {
+	w := cmd.OutOrStdout()
+
+	if err := config.CheckKey(key); err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	cfg, err := config.Resolve(configDir, cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	value, err := cfg.Value(key)
+	if err != nil || value == nil {
+		return err
+	}
+
+	switch value.Kind {
+	case yaml.ScalarNode:
+		_, _ = fmt.Fprintln(w, value.Value)
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			_, _ = fmt.Fprintln(w, item.Value)
+		}
+	default:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal config: %w", err)
+		}
+		_, _ = fmt.Fprint(w, string(data))
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	return runConfigSetWithDir(cmd, config.GetConfigDir(), args)
+}
+
+func runConfigSetWithDir(cmd *cobra.Command, configDir string, args []string) error {
+	key, values := args[0], args[1:]
+	return updateConfigKey(cmd, configDir, key, true, func(data []byte) ([]byte, error) {
+		return config.SetValues(data, key, values)
+	})
+}
+
+func runConfigAdd(cmd *cobra.Command, args []string) error {
+	return runConfigAddWithDir(cmd, config.GetConfigDir(), args)
+}
+
+func runConfigAddWithDir(cmd *cobra.Command, configDir string, args []string) error {
+	key, values := args[0], args[1:]
+	return updateConfigKey(cmd, configDir, key, true, func(data []byte) ([]byte, error) {
+		return config.AddValues(data, key, values)
+	})
+}
+
+func runConfigRemove(cmd *cobra.Command, args []string) error {
+	return runConfigRemoveWithDir(cmd, config.GetConfigDir(), args)
+}
+
+func runConfigRemoveWithDir(cmd *cobra.Command, configDir string, args []string) error {
+	key, values := args[0], args[1:]
+	return updateConfigKey(cmd, configDir, key, false, func(data []byte) ([]byte, error) {
+		return config.RemoveValues(data, key, values)
+	})
+}
+
+// updateConfigKey applies edit to the content of the config file, creating
+// the file with the defaults first. Problems the edit leaves at key are
+// errors if strict is set, and warnings otherwise.
+func updateConfigKey(cmd *cobra.Command, configDir, key string, strict bool, edit func([]byte) ([]byte, error)) error {
+	w := cmd.OutOrStdout()
+	configPath := config.ConfigFilePath(configDir)
+
+	if err := ensureConfigExists(configDir); err != nil {
+		return fmt.Errorf("ensure config exists: %w", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	updated, err := edit(data)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(updated, data) {
+		_, _ = fmt.Fprintf(w, "No changes to %s\n", key)
+		return nil
+	}
+
+	problems := config.KeyProblems(updated, key)
+	if strict && len(problems) > 0 {
+		msgs := make([]string, len(problems))
+		for i, p := range problems {
+			msgs[i] = p.Message
+		}
+		return fmt.Errorf("invalid value for %s: %s", key, strings.Join(msgs, "; "))
+	}
+
+	if err := os.WriteFile(configPath, updated, 0644); err != nil {
+		return fmt.Errorf("write config file: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "Updated %s in %s\n", key, configPath)
+	for _, p := range problems {
+		_, _ = fmt.Fprintf(w, "Warning: %s: %s\n", p.Key, p.Message)
+	}
+	autoSync(cmd, configDir, "", "Update config "+key)
+	return nil
+}
+
+// describeProfile lists the settings a profile overrides.
+func describeProfile(p config.Profile) string {
+	var keys []string
+	if p.Editor != "" {
+		keys = append(keys, "editor")
+	}
+	if p.TemplatesDir != "" {
+		keys = append(keys, "templates_dir")
+	}
+	if p.Presets != nil {
+		keys = append(keys, "presets")
+	}
+	if p.Includes != nil {
+		keys = append(keys, "includes")
+	}
+	if p.Excludes != nil {
+		keys = append(keys, "excludes")
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(keys, ", ") + ")"
 }
 
 // ensureConfigExists creates the config file with defaults if it doesn't exist.
 func ensureConfigExists(configDir string) error {
-	configPath := filepath.Join(configDir, "config.yaml")
+	configPath := config.ConfigFilePath(configDir)
 
 	// Check if config file already exists
 	if _, err := os.Stat(configPath); err == nil {
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestConfigShowWithNoConfigFile(t *testing.T) {
@@ -247,3 +249,204 @@ func TestBuildEditorCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigProfiles(t *testing.T) {
+	configDir := t.TempDir()
+	executeProfiles := func() string {
+		t.Helper()
+		cmd := NewConfigProfilesCmd(configDir)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("config profiles command failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	t.Setenv("DOTGH_PROFILE", "")
+	if output := executeProfiles(); !strings.Contains(output, "No profiles defined.") {
+		t.Errorf("output should say no profiles are defined, got:\n%s", output)
+	}
+
+	configYAML := "includes:\n  - AGENTS.md\nprofiles:\n  copilot:\n    excludes: []\n  claude:\n    editor: code\n    includes:\n      - CLAUDE.md\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	t.Setenv("DOTGH_PROFILE", "claude")
+	output := executeProfiles()
+	if !strings.Contains(output, "* claude (editor, includes)\n  copilot (excludes)\n") {
+		t.Errorf("output should list the profiles with the active one marked, got:\n%s", output)
+	}
+
+	cmd := NewConfigShowCmd(configDir)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config show command failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "# Profile: claude") || !strings.Contains(buf.String(), "editor: code") {
+		t.Errorf("config show should print the effective config, got:\n%s", buf.String())
+	}
+
+	t.Setenv("DOTGH_PROFILE", "missing")
+	if output := executeProfiles(); !strings.Contains(output, `the selected profile "missing" is not defined`) {
+		t.Errorf("output should warn about the unknown profile, got:\n%s", output)
+	}
+}
+
+func TestConfigShowOrigin(t *testing.T) {
+	t.Setenv("DOTGH_PROFILE", "")
+	configDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("editor: vim\nincludes:\n  - AGENTS.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".dotgh.yaml"), []byte("template: web\nexcludes:\n  - AGENTS.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+	t.Chdir(projectDir)
+
+	cmd := NewConfigShowCmd(configDir)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--origin"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config show command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"# Project config file: " + filepath.Join(projectDir, ".dotgh.yaml"),
+		"editor: vim # from " + filepath.Join(configDir, "config.yaml"),
+		"excludes: # from " + filepath.Join(projectDir, ".dotgh.yaml"),
+		"template: web # from " + filepath.Join(projectDir, ".dotgh.yaml"),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+	projectDir := t.TempDir()
+	projectPath := filepath.Join(projectDir, ".dotgh.yaml")
+	t.Chdir(projectDir)
+
+	executeValidate := func() (string, error) {
+		t.Helper()
+		cmd := NewConfigValidateCmd(configDir)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	if output, err := executeValidate(); err != nil || !strings.Contains(output, "not found, the defaults are used") {
+		t.Errorf("missing config file should use the defaults, got error %v and:\n%s", err, output)
+	}
+
+	if err := os.WriteFile(configPath, []byte("includes:\n  - AGENTS.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if output, err := executeValidate(); err != nil || output != configPath+": ok\n" {
+		t.Errorf("valid config file should pass, got error %v and:\n%s", err, output)
+	}
+
+	if err := os.WriteFile(projectPath, []byte("template: web\nexclude:\n  - \"[\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+	output, err := executeValidate()
+	if err == nil || err.Error() != "found 1 problem(s)" {
+		t.Errorf("error = %v, want found 1 problem(s)", err)
+	}
+	if want := projectPath + `:2:1: exclude: unknown key (did you mean "excludes"?)`; !strings.Contains(output, want) {
+		t.Errorf("output should contain %q, got:\n%s", want, output)
+	}
+}
+
+func TestConfigSetAddRemoveGet(t *testing.T) {
+	t.Setenv("DOTGH_PROFILE", "")
+	t.Setenv("DOTGH_EDITOR", "")
+	t.Chdir(t.TempDir())
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+
+	execute := func(cmd *cobra.Command, args ...string) (string, error) {
+		t.Helper()
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	if _, err := execute(NewConfigSetCmd(configDir), "editor", "code --wait"); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+	if _, err := execute(NewConfigAddCmd(configDir), "includes", "CLAUDE.md"); err != nil {
+		t.Fatalf("config add failed: %v", err)
+	}
+	if output, err := execute(NewConfigAddCmd(configDir), "includes", "CLAUDE.md"); err != nil || !strings.Contains(output, "No changes to includes") {
+		t.Errorf("adding a present value should change nothing, got error %v and:\n%s", err, output)
+	}
+	if _, err := execute(NewConfigRemoveCmd(configDir), "includes", ".vscode/mcp.json"); err != nil {
+		t.Fatalf("config remove failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"# includes: Specify file patterns", "editor: code --wait", "  - \".github/prompts/*.prompt.md\"\n  - \"CLAUDE.md\"\n"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("config file should contain %q, got:\n%s", want, content)
+		}
+	}
+
+	if output, err := execute(NewConfigGetCmd(configDir), "editor"); err != nil || output != "code --wait\n" {
+		t.Errorf("config get editor = %q, %v, want %q", output, err, "code --wait\n")
+	}
+	if output, err := execute(NewConfigGetCmd(configDir), "includes"); err != nil || !strings.HasSuffix(output, "\nCLAUDE.md\n") {
+		t.Errorf("config get includes = %q, %v, want the list ending in CLAUDE.md", output, err)
+	}
+
+	if _, err := execute(NewConfigSetCmd(configDir), "secrets.mode", "strict"); err == nil || !strings.Contains(err.Error(), "must be one of block, warn, off") {
+		t.Errorf("config set of an invalid value error = %v, want it rejected", err)
+	}
+	if _, err := execute(NewConfigGetCmd(configDir), "include"); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Errorf("config get of an unknown key error = %v, want unknown key", err)
+	}
+}
+
+func TestConfigPresets(t *testing.T) {
+	t.Setenv("DOTGH_PROFILE", "")
+	t.Setenv("DOTGH_INCLUDES", "")
+	t.Chdir(t.TempDir())
+	configDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("presets: [claude]\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cmd := NewConfigPresetsCmd(configDir)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config presets command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"  copilot    GitHub Copilot\n",
+		"* claude     Claude Code\n    includes: AGENTS.md, CLAUDE.md, .claude/settings.json, .mcp.json\n    excludes: CLAUDE.local.md, .claude/settings.local.json\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/convert"
+	"github.com/openjny/dotgh/internal/glob"
+	"github.com/openjny/dotgh/internal/history"
+	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+// Command metadata constants for convert
+const (
+	convertCmdUse   = "convert [template]"
+	convertCmdShort = "Convert instructions between AI coding assistant formats"
+	convertCmdLong  = `Convert the instruction files of a template from one AI coding assistant
+format to others, and write the results into the template.
+
+Formats:
+  rules    .dotgh/rules/*.md, the rules source of a template
+  copilot  .github/copilot-instructions.md, .github/instructions/*.instructions.md
+  cursor   .cursor/rules/*.mdc, .cursorrules
+  claude   CLAUDE.md
+  gemini   GEMINI.md
+  agents   AGENTS.md
+
+Frontmatter is mapped between formats: Copilot's applyTo becomes Cursor's
+globs, and applyTo "**" becomes alwaysApply. CLAUDE.md, GEMINI.md and
+AGENTS.md are single files that apply to every request, so the other rules
+are appended as sections, and rules that apply only to some files are
+reported as lossy.
+
+Converting to rules creates the rules source of a template, from which pull
+generates the files of every tool the project includes (see 'dotgh pull').
+
+The source format is the first one found in the template, unless --from is
+given. Use --into to write into another template, or --project to write into
+the current directory. Existing files are overwritten after confirmation.
+
+The template can be omitted if a .dotgh.yaml project config file sets one.
+
+Examples:
+  dotgh convert my-template --to cursor,claude
+  dotgh convert my-template --from cursor --to copilot
+  dotgh convert my-template --to claude --project --yes
+  dotgh convert my-template --from copilot --to rules`
+)
+
+var convertCmd = &cobra.Command{
+	Use:   convertCmdUse,
+	Short: convertCmdShort,
+	Long:  convertCmdLong,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConvert,
+}
+
+var (
+	convertToFlag      []string
+	convertFromFlag    string
+	convertIntoFlag    string
+	convertProjectFlag bool
+	convertYesFlag     bool
+)
+
+func init() {
+	convertCmd.Flags().StringSliceVarP(&convertToFlag, "to", "t", nil, "Formats to convert to ("+strings.Join(convert.Names(), ", ")+")")
+	convertCmd.Flags().StringVarP(&convertFromFlag, "from", "f", "", "Format to convert from (default: the first one found)")
+	convertCmd.Flags().StringVar(&convertIntoFlag, "into", "", "Template to write into (default: the source template)")
+	convertCmd.Flags().BoolVar(&convertProjectFlag, "project", false, "Write into the current directory instead of a template")
+	convertCmd.Flags().BoolVarP(&convertYesFlag, "yes", "y", false, "Skip confirmation prompt")
+	_ = convertCmd.MarkFlagRequired("to")
+	convertCmd.MarkFlagsMutuallyExclusive("into", "project")
+}
+
+// ConvertOptions contains options for the convert command.
+type ConvertOptions struct {
+	To      []string
+	From    string
+	Into    string
+	Project bool
+	Yes     bool
+	Stdin   io.Reader
+}
+
+// NewConvertCmd creates a new convert command with custom directories and
+// config. workDir is the directory written with --project.
+// This is primarily used for testing.
+func NewConvertCmd(customTemplatesDir, workDir string, cfg *config.Config) *cobra.Command {
+	var opts ConvertOptions
+	cmd := &cobra.Command{
+		Use:   convertCmdUse,
+		Short: convertCmdShort,
+		Long:  convertCmdLong,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			templateName, err := templateArg(args, cfg)
+			if err != nil {
+				return err
+			}
+			opts.Stdin = cmd.InOrStdin()
+			_, err = convertTemplate(cmd, templateName, customTemplatesDir, workDir, opts, cfg)
+			return err
+		},
+	}
+	cmd.Flags().StringSliceVarP(&opts.To, "to", "t", nil, "Formats to convert to ("+strings.Join(convert.Names(), ", ")+")")
+	cmd.Flags().StringVarP(&opts.From, "from", "f", "", "Format to convert from (default: the first one found)")
+	cmd.Flags().StringVar(&opts.Into, "into", "", "Template to write into (default: the source template)")
+	cmd.Flags().BoolVar(&opts.Project, "project", false, "Write into the current directory instead of a template")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Skip confirmation prompt")
+	_ = cmd.MarkFlagRequired("to")
+	cmd.MarkFlagsMutuallyExclusive("into", "project")
+	return cmd
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	cfg, err := config.Resolve("", cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	opts := ConvertOptions{
+		To:      convertToFlag,
+		From:    convertFromFlag,
+		Into:    convertIntoFlag,
+		Project: convertProjectFlag,
+		Yes:     convertYesFlag,
+		Stdin:   cmd.InOrStdin(),
+	}
+
+	templateName, err := templateArg(args, cfg)
+	if err != nil {
+		return err
+	}
+	written, err := convertTemplate(cmd, templateName, cfg.GetTemplatesDir(), cwd, opts, cfg)
+	if err != nil {
+		return err
+	}
+	if written != "" {
+		autoSync(cmd, config.GetConfigDir(), written, fmt.Sprintf("Convert template '%s'", written))
+	}
+	return nil
+}
+
+// convertTemplate converts the instructions of a template and writes the
+// results. It returns the name of the template written, if any.
+func convertTemplate(cmd *cobra.Command, templateName, templatesDir, workDir string, opts ConvertOptions, cfg *config.Config) (string, error) {
+	w := cmd.OutOrStdout()
+	name, sourceDir, cleanup, err := resolveTemplateRef(templatesDir, templateName)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("template '%s' not found", templateName)
+	}
+
+	// Work out where to write
+	targetTemplate, targetDir := "", workDir
+	if !opts.Project {
+		targetTemplate = opts.Into
+		if targetTemplate == "" {
+			if name != templateName {
+				return "", fmt.Errorf("cannot write to a template revision %q (use --into or --project)", templateName)
+			}
+			targetTemplate = name
+		}
+		if strings.Contains(targetTemplate, "@") {
+			return "", fmt.Errorf("cannot write to a template revision %q", targetTemplate)
+		}
+		targetDir = filepath.Join(templatesDir, targetTemplate)
+	}
+
+	from, err := sourceFormat(w, sourceDir, opts.From)
+	if err != nil {
+		return "", err
+	}
+	var files []convert.File
+	var losses []convert.Loss
+	for _, name := range opts.To {
+		to, err := convert.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if to.Name() == from.Name() {
+			return "", fmt.Errorf("cannot convert %s to itself", from.Name())
+		}
+		if opts.Project && to.Name() == convert.SourceFormatName {
+			return "", fmt.Errorf("the rules source is only used in templates (use --into instead of --project)")
+		}
+		converted, lost, err := convert.Convert(sourceDir, from, to)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, converted...)
+		losses = append(losses, lost...)
+	}
+
+	// Leave out files that would not change
+	var added, modified []convert.File
+	for _, f := range files {
+		existing, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(f.Path)))
+		switch {
+		case os.IsNotExist(err):
+			added = append(added, f)
+		case err != nil:
+			return "", fmt.Errorf("read %s: %w", f.Path, err)
+		case !bytes.Equal(existing, f.Content):
+			modified = append(modified, f)
+		}
+	}
+
+	if len(losses) > 0 {
+		_, _ = fmt.Fprintln(w, "Lossy conversions:")
+		for _, loss := range losses {
+			_, _ = fmt.Fprintf(w, "  %s: %s\n", loss.Source, loss.Message)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	target := "the current directory"
+	if targetTemplate != "" {
+		target = fmt.Sprintf("template '%s'", targetTemplate)
+	}
+	if len(added) == 0 && len(modified) == 0 {
+		_, _ = fmt.Fprintf(w, "The converted files in %s are up to date.\n", target)
+		return "", nil
+	}
+	_, _ = fmt.Fprintf(w, "Converting %s to %s in %s:\n", from.Name(), strings.Join(opts.To, ", "), target)
+	for _, f := range added {
+		_, _ = fmt.Fprintf(w, "  + %s\n", f.Path)
+	}
+	for _, f := range modified {
+		_, _ = fmt.Fprintf(w, "  M %s\n", f.Path)
+	}
+	_, _ = fmt.Fprintln(w)
+
+	if !opts.Yes {
+		confirmed, err := prompt.Confirm("Write these files?", true, w, opts.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("confirmation: %w", err)
+		}
+		if !confirmed {
+			_, _ = fmt.Fprintln(w, "Aborted.")
+			return "", nil
+		}
+	}
+
+	if targetTemplate != "" {
+		recordHistory(w, templatesDir, targetTemplate, history.SourceConvert)
+	}
+	for _, f := range append(added, modified...) {
+		path := filepath.Join(targetDir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("create directory: %w", err)
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return "", fmt.Errorf("write %s: %w", f.Path, err)
+		}
+	}
+	var snap *history.Snapshot
+	if targetTemplate != "" {
+		snap = recordHistory(w, templatesDir, targetTemplate, history.SourceConvert)
+	}
+
+	_, _ = fmt.Fprintf(w, "Done: %d added, %d modified\n", len(added), len(modified))
+	if snap != nil {
+		_, _ = fmt.Fprintf(w, "Snapshot: %s@%d\n", targetTemplate, snap.Rev)
+	}
+	if cfg != nil {
+		warnNotIncluded(w, files, opts.To, cfg.Includes)
+	}
+	return targetTemplate, nil
+}
+
+// sourceFormat returns the named format, or the first format found in dir.
+func sourceFormat(w io.Writer, dir, name string) (convert.Format, error) {
+	if name != "" {
+		return convert.Lookup(name)
+	}
+	found, err := convert.Detect(dir)
+	if err != nil {
+		return nil, fmt.Errorf("detect formats: %w", err)
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no instruction files found (formats: %s)", strings.Join(convert.Names(), ", "))
+	}
+	if len(found) > 1 {
+		_, _ = fmt.Fprintf(w, "Converting from %s, the first format found (use --from to choose).\n\n", found[0].Name())
+	}
+	return found[0], nil
+}
+
+// warnNotIncluded warns about converted files that the includes do not
+// cover, so pull and push would leave them out. The rules source is only
+// used in templates, so it is not checked. Most format names match preset
+// names, so the hint names the presets to add.
+func warnNotIncluded(w io.Writer, files []convert.File, formats, includes []string) {
+	var missing []string
+	for _, f := range files {
+		if strings.HasPrefix(f.Path, convert.SourceDir+"/") {
+			continue
+		}
+		covered := slices.ContainsFunc(includes, func(pattern string) bool {
+			ok, _ := glob.MatchPattern(pattern, f.Path)
+			return ok
+		})
+		if !covered {
+			missing = append(missing, f.Path)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	var presets []string
+	for _, name := range formats {
+		if _, ok := config.LookupPreset(name); ok {
+			presets = append(presets, name)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Warning: %d converted file(s) are not covered by includes: %s\n", len(missing), strings.Join(missing, ", "))
+	if len(presets) > 0 {
+		_, _ = fmt.Fprintf(w, "Run 'dotgh config add presets %s' to include them.\n", strings.Join(presets, " "))
+	} else {
+		_, _ = fmt.Fprintln(w, "Add them to includes with 'dotgh config add includes'.")
+	}
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// executeConvertCmd runs the convert command and returns the output.
+func executeConvertCmd(t *testing.T, templatesDir, workDir, stdin string, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewConvertCmd(templatesDir, workDir, testConfig())
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestConvert(t *testing.T) {
+	templatesDir := t.TempDir()
+	createTestFiles(t, filepath.Join(templatesDir, "my-template"), map[string]string{
+		".github/copilot-instructions.md":         "Use tabs.\n",
+		".github/instructions/go.instructions.md": "---\napplyTo: \"**/*.go\"\n---\nWrap errors.\n",
+	})
+
+	output, err := executeConvertCmd(t, templatesDir, t.TempDir(), "y\n", "my-template", "--to", "cursor,claude")
+	if err != nil {
+		t.Fatalf("convert failed: %v\n%s", err, output)
+	}
+	for _, want := range []string{
+		"Lossy conversions:",
+		".github/instructions/go.instructions.md: applies to **/*.go only, but CLAUDE.md applies it to every request",
+		"Converting copilot to cursor, claude in template 'my-template':",
+		"  + .cursor/rules/main.mdc",
+		"  + .cursor/rules/go.mdc",
+		"  + CLAUDE.md",
+		"Done: 3 added, 0 modified",
+		"Snapshot: my-template@",
+		"Warning: 3 converted file(s) are not covered by includes",
+		"Run 'dotgh config add presets cursor claude'",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(templatesDir, "my-template", ".cursor", "rules", "go.mdc"))
+	if err != nil {
+		t.Fatalf("go.mdc not written: %v", err)
+	}
+	if want := "---\nglobs: **/*.go\nalwaysApply: false\n---\n\nWrap errors.\n"; string(data) != want {
+		t.Errorf("go.mdc = %q, want %q", data, want)
+	}
+
+	// Converting again changes nothing
+	output, err = executeConvertCmd(t, templatesDir, t.TempDir(), "", "my-template", "--to", "cursor")
+	if err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	if !strings.Contains(output, "The converted files in template 'my-template' are up to date.") {
+		t.Errorf("expected up to date message, got:\n%s", output)
+	}
+}
+
+func TestConvertToProject(t *testing.T) {
+	templatesDir := t.TempDir()
+	createTestFiles(t, filepath.Join(templatesDir, "my-template"), map[string]string{
+		".cursor/rules/go.mdc": "---\nglobs: *.go\n---\nWrap errors.\n",
+		"CLAUDE.md":            "Use tabs.\n",
+	})
+	workDir := setupTestSourceDir(t, map[string]string{
+		".github/instructions/go.instructions.md": "old",
+	})
+
+	output, err := executeConvertCmd(t, templatesDir, workDir, "", "my-template", "--to", "copilot", "--project", "--yes")
+	if err != nil {
+		t.Fatalf("convert failed: %v\n%s", err, output)
+	}
+	for _, want := range []string{
+		"Converting from cursor, the first format found (use --from to choose).",
+		"in the current directory:",
+		"  M .github/instructions/go.instructions.md",
+		"Done: 0 added, 1 modified",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Warning:") {
+		t.Errorf("unexpected includes warning:\n%s", output)
+	}
+	data, _ := os.ReadFile(filepath.Join(workDir, ".github", "instructions", "go.instructions.md"))
+	if want := "---\napplyTo: \"*.go\"\n---\n\nWrap errors.\n"; string(data) != want {
+		t.Errorf("go.instructions.md = %q, want %q", data, want)
+	}
+	if _, err := os.Stat(filepath.Join(templatesDir, "my-template", ".github")); !os.IsNotExist(err) {
+		t.Error("expected the template to be left alone")
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	templatesDir := t.TempDir()
+	createTestFile(t, filepath.Join(templatesDir, "my-template"), "CLAUDE.md", "Use tabs.\n")
+	createTestFile(t, filepath.Join(templatesDir, "empty"), "notes.txt", "")
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"missing template", []string{"nope", "--to", "cursor"}, "template 'nope' not found"},
+		{"unknown format", []string{"my-template", "--to", "vim"}, `unknown format "vim"`},
+		{"same format", []string{"my-template", "--to", "claude"}, "cannot convert claude to itself"},
+		{"no files", []string{"empty", "--to", "cursor"}, "no instruction files found"},
+		{"from without files", []string{"my-template", "--from", "cursor", "--to", "claude"}, "no cursor files found"},
+		{"into and project", []string{"my-template", "--to", "cursor", "--into", "x", "--project"}, "none of the others can be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeConvertCmd(t, templatesDir, t.TempDir(), "", tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q\n%s", err, tt.wantErr, output)
+			}
+		})
+	}
+}
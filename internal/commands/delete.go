@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/diff"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +17,10 @@ import (
 const (
 	deleteCmdUse   = "delete <template>"
 	deleteCmdShort = "Delete a template"
-	deleteCmdLong  = "Delete a template from the templates directory. Shows a confirmation prompt unless --force is specified."
+	deleteCmdLong  = `Delete a template from the templates directory. Shows a confirmation prompt unless --force is specified.
+
+Use --dry-run to print the files that would be deleted, including those of
+auto sync, without deleting them (--dry-run=json for JSON).`
 )
 
 var deleteCmd = &cobra.Command{
@@ -27,26 +31,36 @@ var deleteCmd = &cobra.Command{
 	RunE:  runDelete,
 }
 
-var deleteForceFlag bool
+var (
+	deleteForceFlag  bool
+	deleteDryRunFlag string
+)
 
 func init() {
 	deleteCmd.Flags().BoolVarP(&deleteForceFlag, "force", "f", false, "Skip confirmation prompt")
+	addDryRunFlag(deleteCmd.Flags(), &deleteDryRunFlag)
 }
 
 // NewDeleteCmd creates a new delete command with custom templates directory and stdin.
 // This is primarily used for testing.
 func NewDeleteCmd(customTemplatesDir string, stdin io.Reader) *cobra.Command {
 	var force bool
+	var dryRun string
 	cmd := &cobra.Command{
 		Use:   deleteCmdUse,
 		Short: deleteCmdShort,
 		Long:  deleteCmdLong,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return deleteTemplate(cmd, args[0], customTemplatesDir, stdin, force)
+			plan, err := deleteTemplate(cmd, args[0], customTemplatesDir, stdin, force, dryRun)
+			if err != nil || plan == nil {
+				return err
+			}
+			return printDryRun(cmd.OutOrStdout(), dryRun, plan)
 		},
 	}
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+	addDryRunFlag(cmd.Flags(), &dryRun)
 	return cmd
 }
 
@@ -56,34 +70,65 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
-	return deleteTemplate(cmd, args[0], cfg.GetTemplatesDir(), os.Stdin, deleteForceFlag)
+	plan, err := deleteTemplate(cmd, args[0], cfg.GetTemplatesDir(), os.Stdin, deleteForceFlag, deleteDryRunFlag)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Delete template '%s'", args[0])
+	if plan != nil {
+		if err := planAutoSync(cmd.Context(), plan, config.GetConfigDir(), message); err != nil {
+			return err
+		}
+		return printDryRun(cmd.OutOrStdout(), deleteDryRunFlag, plan)
+	}
+	autoSync(cmd, config.GetConfigDir(), args[0], message)
+	return nil
 }
 
-// deleteTemplate deletes the specified template.
-func deleteTemplate(cmd *cobra.Command, templateName, templatesDir string, stdin io.Reader, force bool) error {
+// deleteTemplate deletes the specified template. With a dryRun format, it
+// deletes nothing and returns the plan instead.
+func deleteTemplate(cmd *cobra.Command, templateName, templatesDir string, stdin io.Reader, force bool, dryRun string) (*dryRunPlan, error) {
 	w := cmd.OutOrStdout()
+	if err := checkDryRun(dryRun); err != nil {
+		return nil, err
+	}
 	templatePath := filepath.Join(templatesDir, templateName)
 
 	// Check if template exists
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-		return fmt.Errorf("template '%s' not found", templateName)
+		return nil, fmt.Errorf("template '%s' not found", templateName)
+	}
+
+	if dryRun != "" {
+		files, err := diff.ListFiles(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		deleted, err := diff.CompareFiles(nil, files, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		p := &dryRunPlan{Command: "delete " + templateName, template: templateName}
+		p.addChanges(fmt.Sprintf("template '%s'", templateName), templatePath, deleted)
+		p.stage = os.RemoveAll
+		return p, nil
 	}
 
 	// Confirm deletion unless force flag is set
 	if !force {
 		if !confirmDelete(stdin, w, templateName) {
 			_, _ = fmt.Fprintln(w, "Deletion cancelled.")
-			return nil
+			return nil, nil
 		}
 	}
 
 	// Delete the template directory
 	if err := os.RemoveAll(templatePath); err != nil {
-		return fmt.Errorf("delete template: %w", err)
+		return nil, fmt.Errorf("delete template: %w", err)
 	}
 
 	_, _ = fmt.Fprintf(w, "Template '%s' deleted.\n", templateName)
-	return nil
+	return nil, nil
 }
 
 // confirmDelete prompts the user for confirmation and returns true if confirmed.
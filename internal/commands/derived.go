@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/convert"
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/glob"
+)
+
+// derivedFiles returns the instruction files generated from the rules source
+// of the template in templateDir, or none if it has no rules source. Only
+// the files of the tools that the includes cover are generated, so the
+// project's presets decide which tools get them.
+func derivedFiles(templateDir string, cfg *config.Config) ([]convert.File, error) {
+	generated, err := convert.Generate(templateDir)
+	if err != nil {
+		return nil, fmt.Errorf("generate from %s: %w", convert.SourceDir, err)
+	}
+	var paths []string
+	for _, f := range generated {
+		for _, pattern := range cfg.Includes {
+			if ok, _ := glob.MatchPattern(pattern, f.Path); ok {
+				paths = append(paths, f.Path)
+				break
+			}
+		}
+	}
+	paths, err = glob.FilterExcludes(paths, cfg.Excludes)
+	if err != nil {
+		return nil, err
+	}
+	var files []convert.File
+	for _, f := range generated {
+		if slices.Contains(paths, f.Path) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// derivedPaths returns the paths of derived files.
+func derivedPaths(derived []convert.File) []string {
+	paths := make([]string, len(derived))
+	for i, f := range derived {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+// stageTemplate returns the directory to pull the template in templateDir
+// from. For a template with a rules source, this is a temporary copy of the
+// included files with the derived files generated into it, which cleanup
+// removes; derived files kept in the template itself are replaced.
+func stageTemplate(templateDir string, cfg *config.Config) (dir string, derived []convert.File, cleanup func(), err error) {
+	derived, err = derivedFiles(templateDir, cfg)
+	if err != nil || len(derived) == 0 {
+		return templateDir, nil, func() {}, err
+	}
+
+	dir, err = os.MkdirTemp("", "dotgh-template-*")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	copied, err := diff.ComputeDiff(templateDir, dir, cfg.Includes, cfg.Excludes, true)
+	if err == nil {
+		err = diff.ApplyChanges(templateDir, dir, copied)
+	}
+	for _, f := range derived {
+		if err != nil {
+			break
+		}
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, f.Content, 0644)
+		}
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, nil, fmt.Errorf("stage template: %w", err)
+	}
+	return dir, derived, cleanup, nil
+}
+
+// editedDerived returns the derived files whose copy in dir differs from the
+// generated content, i.e. that were edited directly.
+func editedDerived(dir string, derived []convert.File) ([]string, error) {
+	var edited []string
+	for _, f := range derived {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f.Path, err)
+		}
+		if !bytes.Equal(content, f.Content) {
+			edited = append(edited, f.Path)
+		}
+	}
+	return edited, nil
+}
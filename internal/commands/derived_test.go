@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rulesSourceFiles is a template with a rules source.
+var rulesSourceFiles = map[string]string{
+	".dotgh/rules/main.md": "Use tabs.\n",
+	".dotgh/rules/go.md":   "---\ndescription: Go style\nglobs: [\"**/*.go\"]\n---\nWrap errors.\n",
+	".vscode/mcp.json":     `{"servers": {}}`,
+}
+
+func TestPullGeneratesDerivedFiles(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "rules", rulesSourceFiles)
+	targetDir := t.TempDir()
+
+	output, err := executePullCmd(t, templatesDir, targetDir, "rules", false, true, nil, "")
+	if err != nil {
+		t.Fatalf("pull failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Generated from the template's rules source: .github/copilot-instructions.md, .github/instructions/go.instructions.md, AGENTS.md") {
+		t.Errorf("expected generated files to be listed, got:\n%s", output)
+	}
+
+	want := map[string]string{
+		".github/copilot-instructions.md":         "Use tabs.\n",
+		".github/instructions/go.instructions.md": "---\ndescription: \"Go style\"\napplyTo: \"**/*.go\"\n---\n\nWrap errors.\n",
+		"AGENTS.md":        "Use tabs.\n\n## Go style\n\nApplies to: `**/*.go`\n\nWrap errors.\n",
+		".vscode/mcp.json": `{"servers": {}}`,
+	}
+	for path, content := range want {
+		data, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Errorf("%s not pulled: %v", path, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", path, data, content)
+		}
+	}
+	// Only the tools that the includes cover get files, and the source stays
+	// in the template
+	for _, path := range []string{"CLAUDE.md", ".cursor/rules/main.mdc", ".dotgh/rules/main.md"} {
+		if _, err := os.Stat(filepath.Join(targetDir, filepath.FromSlash(path))); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be pulled", path)
+		}
+	}
+
+	// The generated files are compared, not the template's files
+	output, err = executeDiffCmd(t, templatesDir, targetDir, "rules", false, false, nil)
+	if err != nil {
+		t.Fatalf("diff failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "(no changes)") {
+		t.Errorf("expected no changes after pull, got:\n%s", output)
+	}
+}
+
+func TestDiffMarksDerivedFiles(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "rules", rulesSourceFiles)
+	targetDir := setupTestSourceDir(t, map[string]string{"AGENTS.md": "Old rules.\n"})
+
+	output, err := executeDiffCmd(t, templatesDir, targetDir, "rules", false, false, nil)
+	if !errors.Is(err, ErrDiffFound) {
+		t.Fatalf("expected ErrDiffFound, got %v\n%s", err, output)
+	}
+	for _, want := range []string{
+		"  + .github/copilot-instructions.md (generated)",
+		"  + .vscode/mcp.json\n",
+		"  M AGENTS.md (generated)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	output, err = executeDiffCmd(t, templatesDir, targetDir, "rules", true, false, nil)
+	if !errors.Is(err, ErrDiffFound) {
+		t.Fatalf("expected ErrDiffFound, got %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "  ! AGENTS.md (generated, edited here)") {
+		t.Errorf("expected edited derived file, got:\n%s", output)
+	}
+	if strings.Contains(output, "  + AGENTS.md") {
+		t.Errorf("expected derived file not to be pushed, got:\n%s", output)
+	}
+}
+
+func TestPushRefusesEditedDerivedFiles(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "rules", rulesSourceFiles)
+	targetDir := t.TempDir()
+	if _, err := executePullCmd(t, templatesDir, targetDir, "rules", false, true, nil, ""); err != nil {
+		t.Fatalf("pull failed: %v", err)
+	}
+
+	// Unedited derived files are left out of the push
+	createTestFile(t, targetDir, ".github/prompts/new.prompt.md", "# New")
+	output, err := executePushCmd(t, templatesDir, targetDir, "rules", false, true, nil, "")
+	if err != nil {
+		t.Fatalf("push failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "  + .github/prompts/new.prompt.md") || strings.Contains(output, "AGENTS.md") {
+		t.Errorf("expected only the new prompt to be pushed, got:\n%s", output)
+	}
+	if _, err := os.Stat(filepath.Join(templatesDir, "rules", "AGENTS.md")); !os.IsNotExist(err) {
+		t.Error("expected AGENTS.md not to be pushed")
+	}
+
+	// Edited derived files are refused
+	createTestFile(t, targetDir, "AGENTS.md", "Edited.\n")
+	output, err = executePushCmd(t, templatesDir, targetDir, "rules", false, true, nil, "")
+	if err == nil || !strings.Contains(err.Error(), "AGENTS.md generated from the rules source of template 'rules' but edited here") {
+		t.Errorf("expected push to refuse, got %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/detect"
+	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+// Command metadata constants for detect
+const (
+	detectCmdUse   = "detect"
+	detectCmdShort = "Find AI tool files that the includes do not cover"
+	detectCmdLong  = `Scan the current directory for the config files of known AI coding tools
+(the files of the built-in presets, see 'dotgh config presets') and report
+those that the includes do not cover, so push would leave them out.
+
+Offers to add the matching patterns to the includes: in the config file, the
+active profile or the project config file, wherever the includes are set.
+Use --yes to add them without asking.
+
+Examples:
+  dotgh detect
+  dotgh detect --yes`
+)
+
+var detectCmd = &cobra.Command{
+	Use:   detectCmdUse,
+	Short: detectCmdShort,
+	Long:  detectCmdLong,
+	Args:  cobra.NoArgs,
+	RunE:  runDetect,
+}
+
+var detectYesFlag bool
+
+func init() {
+	detectCmd.Flags().BoolVarP(&detectYesFlag, "yes", "y", false, "Add the patterns without asking")
+}
+
+// NewDetectCmd creates a new detect command that scans workDir with the
+// config in configDir, reading answers from stdin.
+// This is primarily used for testing.
+func NewDetectCmd(configDir, workDir string, stdin io.Reader) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   detectCmdUse,
+		Short: detectCmdShort,
+		Long:  detectCmdLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return detectFiles(cmd, configDir, workDir, yes, stdin)
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Add the patterns without asking")
+	return cmd
+}
+
+func runDetect(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	return detectFiles(cmd, config.GetConfigDir(), cwd, detectYesFlag, os.Stdin)
+}
+
+// detectFiles reports the AI tool files in workDir and offers to add the
+// patterns of those not covered to the includes.
+func detectFiles(cmd *cobra.Command, configDir, workDir string, yes bool, stdin io.Reader) error {
+	w := cmd.OutOrStdout()
+
+	cfg, err := config.Resolve(configDir, workDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	result, err := detect.Scan(workDir, cfg.Includes, cfg.Excludes)
+	if err != nil {
+		return fmt.Errorf("scan files: %w", err)
+	}
+
+	if len(result.Tools) == 0 && len(result.Uncovered) == 0 {
+		_, _ = fmt.Fprintln(w, "No AI tool files found.")
+		return nil
+	}
+	if len(result.Tools) > 0 {
+		_, _ = fmt.Fprintln(w, "AI tool files:")
+		for _, tool := range result.Tools {
+			_, _ = fmt.Fprintf(w, "  %-14s %s\n", tool.Preset.Description, strings.Join(tool.Files, ", "))
+		}
+	}
+	if len(result.Uncovered) == 0 {
+		_, _ = fmt.Fprintln(w, "\nAll of them are covered by includes.")
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(w, "\nNot covered by includes:")
+	for _, u := range result.Uncovered {
+		tools := strings.Join(u.Tools, ", ")
+		if u.Shared {
+			tools = "all tools"
+		}
+		_, _ = fmt.Fprintf(w, "  %-28s %s (%d file(s))\n", u.Pattern, tools, len(u.Files))
+	}
+	_, _ = fmt.Fprintln(w)
+
+	origin := cfg.Origin("includes")
+	if env, ok := strings.CutPrefix(origin, "env "); ok {
+		_, _ = fmt.Fprintf(w, "The includes are set by %s; add the patterns there.\n", env)
+		return nil
+	}
+	path, key := config.ConfigFilePath(configDir), "includes"
+	switch {
+	case cfg.ProjectFile != "" && origin == cfg.ProjectFile:
+		path = cfg.ProjectFile
+	case cfg.ActiveProfile != "" && origin == "profile "+cfg.ActiveProfile:
+		key = "profiles." + cfg.ActiveProfile + ".includes"
+	}
+
+	if !yes {
+		confirmed, err := prompt.Confirm(fmt.Sprintf("Add these patterns to %s in %s?", key, path), true, w, stdin)
+		if err != nil {
+			return fmt.Errorf("confirmation: %w", err)
+		}
+		if !confirmed {
+			_, _ = fmt.Fprintln(w, "No changes made.")
+			return nil
+		}
+	}
+
+	isConfigFile := path == config.ConfigFilePath(configDir)
+	if isConfigFile {
+		if err := ensureConfigExists(configDir); err != nil {
+			return fmt.Errorf("ensure config exists: %w", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	updated, err := config.AddValues(data, key, result.Patterns())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return fmt.Errorf("write config file: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "Added %d pattern(s) to %s in %s\n", len(result.Uncovered), key, path)
+	if isConfigFile {
+		autoSync(cmd, configDir, "", "Add detected includes")
+	}
+	return nil
+}
+
+// warnUncovered warns about AI tool files in dir that the includes of cfg do
+// not cover.
+func warnUncovered(w io.Writer, dir string, cfg *config.Config) {
+	if msg := uncoveredWarning(dir, cfg); msg != "" {
+		_, _ = fmt.Fprintf(w, "Warning: %s\n", msg)
+		_, _ = fmt.Fprintln(w, "Run 'dotgh detect' to add their patterns.")
+	}
+}
+
+// uncoveredWarning describes the AI tool files in dir that the includes of
+// cfg do not cover, or returns "" if there are none. Scan errors are
+// ignored, as the warning is only a hint.
+func uncoveredWarning(dir string, cfg *config.Config) string {
+	result, err := detect.Scan(dir, cfg.Includes, cfg.Excludes)
+	if err != nil || len(result.Uncovered) == 0 {
+		return ""
+	}
+	files := result.UncoveredFiles()
+	list := files
+	if len(list) > 3 {
+		list = append(list[:3:3], "...")
+	}
+	return fmt.Sprintf("%d AI tool file(s) are not covered by includes: %s", len(files), strings.Join(list, ", "))
+}
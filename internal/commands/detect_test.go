@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	t.Setenv("DOTGH_PROFILE", "")
+	t.Setenv("DOTGH_INCLUDES", "")
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("# Shared files\nincludes:\n  - AGENTS.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	workDir := setupTestSourceDir(t, map[string]string{
+		"AGENTS.md":            "# Agents",
+		"CLAUDE.md":            "# Claude",
+		".cursor/rules/go.mdc": "go rules",
+	})
+
+	execute := func(stdin string, args ...string) string {
+		t.Helper()
+		cmd := NewDetectCmd(configDir, workDir, strings.NewReader(stdin))
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("detect command failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	output := execute("n\n")
+	for _, want := range []string{
+		"  Claude Code    CLAUDE.md\n",
+		"  Cursor         .cursor/rules/go.mdc\n",
+		"  CLAUDE.md                    Claude Code (1 file(s))\n",
+		"  .cursor/rules/*.mdc          Cursor (1 file(s))\n",
+		"Add these patterns to includes in " + configPath + "?",
+		"No changes made.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+
+	output = execute("", "--yes")
+	if !strings.Contains(output, "Added 2 pattern(s) to includes") {
+		t.Errorf("output should report the added patterns, got:\n%s", output)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	if want := "# Shared files\nincludes:\n  - AGENTS.md\n  - CLAUDE.md\n  - .cursor/rules/*.mdc\n"; string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+
+	if output := execute(""); !strings.Contains(output, "All of them are covered by includes.") {
+		t.Errorf("output should say all files are covered, got:\n%s", output)
+	}
+}
+
+func TestPushWarnsAboutUncoveredToolFiles(t *testing.T) {
+	sourceDir := setupTestSourceDir(t, map[string]string{
+		"AGENTS.md":            "# Agents",
+		".cursor/rules/go.mdc": "go rules",
+	})
+	templatesDir := t.TempDir()
+
+	output, err := executePushCmd(t, templatesDir, sourceDir, "my-template", false, true, nil, "")
+	if err != nil {
+		t.Fatalf("push command failed: %v", err)
+	}
+	if want := "Warning: 1 AI tool file(s) are not covered by includes: .cursor/rules/go.mdc\nRun 'dotgh detect'"; !strings.Contains(output, want) {
+		t.Errorf("output should contain %q, got:\n%s", want, output)
+	}
+}
@@ -4,16 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/convert"
 	"github.com/openjny/dotgh/internal/diff"
 	"github.com/spf13/cobra"
 )
 
 // Command metadata constants for diff
 const (
-	diffCmdUse   = "diff <template>"
+	diffCmdUse   = "diff [template][@rev]"
 	diffCmdShort = "Show differences between a template and the current directory"
 	diffCmdLong  = `Show differences between a template and the current directory.
 
@@ -21,6 +22,14 @@ Displays files that would be added, modified, or deleted when pulling a template
 By default, shows what a full sync (pull) would do. Use --reverse to show what
 a push would do.
 
+Files generated from the rules source of a template (.dotgh/rules) are
+regenerated and compared, and marked as generated. With --reverse, generated
+files edited in the current directory are marked with '!', as push refuses
+to run while they differ.
+
+Use <template>@<rev> to compare against a revision from 'dotgh history'.
+The template can be omitted if a .dotgh.yaml project config file sets one.
+
 Exit codes:
   0 - No differences found
   1 - Differences found or error occurred`
@@ -30,7 +39,7 @@ var diffCmd = &cobra.Command{
 	Use:   diffCmdUse,
 	Short: diffCmdShort,
 	Long:  diffCmdLong,
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runDiff,
 }
 
@@ -58,9 +67,13 @@ func NewDiffCmdWithConfig(customTemplatesDir, customTargetDir string, cfg *confi
 		Use:   diffCmdUse,
 		Short: diffCmdShort,
 		Long:  diffCmdLong,
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDiffWithOptions(cmd, args[0], customTemplatesDir, customTargetDir, reverse, merge, cfg)
+			templateName, err := templateArg(args, cfg)
+			if err != nil {
+				return err
+			}
+			return runDiffWithOptions(cmd, templateName, customTemplatesDir, customTargetDir, reverse, merge, cfg)
 		},
 	}
 	cmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "Show differences for push (current → template)")
@@ -73,19 +86,28 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("get current directory: %w", err)
 	}
+	pullIfStale(cmd, config.GetConfigDir())
 
-	cfg, err := config.Load()
+	cfg, err := config.Resolve("", cwd)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
-	return runDiffWithOptions(cmd, args[0], cfg.GetTemplatesDir(), cwd, diffReverseFlag, diffMergeFlag, cfg)
+	templateName, err := templateArg(args, cfg)
+	if err != nil {
+		return err
+	}
+	return runDiffWithOptions(cmd, templateName, cfg.GetTemplatesDir(), cwd, diffReverseFlag, diffMergeFlag, cfg)
 }
 
 // runDiffWithOptions runs the diff command with the specified options.
 func runDiffWithOptions(cmd *cobra.Command, templateName, templatesDir, targetDir string, reverse, mergeMode bool, cfg *config.Config) error {
 	w := cmd.OutOrStdout()
-	templatePath := filepath.Join(templatesDir, templateName)
+	_, templatePath, cleanup, err := resolveTemplateRef(templatesDir, templateName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
 	// Check if template exists
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
@@ -94,8 +116,7 @@ func runDiffWithOptions(cmd *cobra.Command, templateName, templatesDir, targetDi
 
 	// Load config if not provided
 	if cfg == nil {
-		var err error
-		cfg, err = config.Load()
+		cfg, err = config.Resolve("", targetDir)
 		if err != nil {
 			return fmt.Errorf("load config: %w", err)
 		}
@@ -103,22 +124,48 @@ func runDiffWithOptions(cmd *cobra.Command, templateName, templatesDir, targetDi
 
 	var srcDir, dstDir string
 	var direction string
+	var derived []convert.File
+	var edited []string
+	excludes := cfg.Excludes
 	if reverse {
-		// Push direction: current -> template
+		// Push direction: current -> template. Derived files are not
+		// pushed, but edits to them are reported.
 		srcDir = targetDir
 		dstDir = templatePath
 		direction = fmt.Sprintf("current directory → template '%s'", templateName)
+		if derived, err = derivedFiles(templatePath, cfg); err != nil {
+			return err
+		}
+		if edited, err = editedDerived(targetDir, derived); err != nil {
+			return err
+		}
+		excludes = append(slices.Clone(excludes), derivedPaths(derived)...)
 	} else {
-		// Pull direction: template -> current
-		srcDir = templatePath
+		// Pull direction: template -> current, with derived files
+		// regenerated to compare against
+		stageDir, staged, cleanupStage, err := stageTemplate(templatePath, cfg)
+		if err != nil {
+			return err
+		}
+		defer cleanupStage()
+		srcDir = stageDir
 		dstDir = targetDir
+		derived = staged
 		direction = fmt.Sprintf("template '%s' → current directory", templateName)
 	}
 
-	diffResult, err := diff.ComputeDiff(srcDir, dstDir, cfg.Includes, cfg.Excludes, mergeMode)
+	diffResult, err := diff.ComputeDiff(srcDir, dstDir, cfg.Includes, excludes, mergeMode)
 	if err != nil {
 		return fmt.Errorf("compute diff: %w", err)
 	}
+	var locked []string
+	if !reverse {
+		if locked, err = skipLocked(srcDir, diffResult); err != nil {
+			return err
+		}
+	}
+
+	printSkippedLocked(w, locked)
 
 	// Print header
 	if mergeMode {
@@ -127,25 +174,38 @@ func runDiffWithOptions(cmd *cobra.Command, templateName, templatesDir, targetDi
 		_, _ = fmt.Fprintf(w, "Diff (%s):\n", direction)
 	}
 
-	if !diffResult.HasChanges() {
+	if !diffResult.HasChanges() && len(edited) == 0 {
 		_, _ = fmt.Fprintln(w, "  (no changes)")
 		return nil
 	}
 
-	// Print changes
+	// Print changes, marking the files generated from a rules source
+	generated := derivedPaths(derived)
+	mark := func(path string) string {
+		if slices.Contains(generated, path) {
+			return path + " (generated)"
+		}
+		return path
+	}
 	for _, change := range diffResult.Added {
-		_, _ = fmt.Fprintf(w, "  + %s\n", change.Path)
+		_, _ = fmt.Fprintf(w, "  + %s\n", mark(change.Path))
 	}
 	for _, change := range diffResult.Modified {
-		_, _ = fmt.Fprintf(w, "  M %s\n", change.Path)
+		_, _ = fmt.Fprintf(w, "  M %s\n", mark(change.Path))
 	}
 	for _, change := range diffResult.Deleted {
 		_, _ = fmt.Fprintf(w, "  - %s\n", change.Path)
 	}
+	for _, path := range edited {
+		_, _ = fmt.Fprintf(w, "  ! %s (generated, edited here)\n", path)
+	}
 
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintf(w, "Summary: %d addition(s), %d modification(s), %d deletion(s)\n",
 		len(diffResult.Added), len(diffResult.Modified), len(diffResult.Deleted))
+	if len(edited) > 0 {
+		_, _ = fmt.Fprintf(w, "Push refuses to run until the edits to generated files are moved to %s in the template.\n", convert.SourceDir)
+	}
 
 	// Return error to indicate differences found (exit code 1)
 	return ErrDiffFound
@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/spf13/pflag"
+)
+
+// Output formats of --dry-run
+const (
+	dryRunText = "text"
+	dryRunJSON = "json"
+)
+
+// addDryRunFlag adds the --dry-run flag of the commands that change files.
+// --dry-run prints the plan as text, --dry-run=json as JSON.
+func addDryRunFlag(flags *pflag.FlagSet, p *string) {
+	flags.StringVar(p, "dry-run", "", "Print what would be done without changing anything (text or json)")
+	flags.Lookup("dry-run").NoOptDefVal = dryRunText
+}
+
+// checkDryRun returns an error for an unknown --dry-run format.
+func checkDryRun(format string) error {
+	switch format {
+	case "", dryRunText, dryRunJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid --dry-run format %q (expected %q or %q)", format, dryRunText, dryRunJSON)
+}
+
+// dryRunPlan is what a command would do, as computed by --dry-run.
+type dryRunPlan struct {
+	Command string `json:"command"`
+	// Changes lists the file changes, in the order they are made.
+	Changes []dryRunChanges `json:"changes"`
+	// Snapshots lists the templates recorded in the local history.
+	Snapshots []string `json:"snapshots,omitempty"`
+	// Secrets lists potential secrets in the files to copy.
+	Secrets []string `json:"secrets,omitempty"`
+	// Commits lists the messages of the commits made in the sync repository.
+	Commits []string `json:"commits,omitempty"`
+	// Push names the remote branch that the sync repository is pushed to.
+	Push string `json:"push,omitempty"`
+	// Notes describe what the command would print, such as warnings, and
+	// what the plan cannot tell.
+	Notes []string `json:"notes,omitempty"`
+
+	// template is the template the command changes, and stage applies the
+	// changes to a copy of it, for planning the auto sync.
+	template string
+	stage    func(dir string) error
+}
+
+// dryRunChanges are the file changes in one directory.
+type dryRunChanges struct {
+	// Target describes the directory, e.g. "template 'work'".
+	Target   string   `json:"target"`
+	Dir      string   `json:"dir"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Deleted  []string `json:"deleted"`
+}
+
+// addChanges adds the changes of d to target in dir, if there are any.
+func (p *dryRunPlan) addChanges(target, dir string, d *diff.DiffResult) {
+	if !d.HasChanges() {
+		return
+	}
+	p.Changes = append(p.Changes, dryRunChanges{
+		Target:   target,
+		Dir:      dir,
+		Added:    changePaths(d.Added),
+		Modified: changePaths(d.Modified),
+		Deleted:  changePaths(d.Deleted),
+	})
+}
+
+// note adds a note to the plan.
+func (p *dryRunPlan) note(format string, args ...any) {
+	p.Notes = append(p.Notes, fmt.Sprintf(format, args...))
+}
+
+// addSecrets scans the given files for secrets like checkSecrets, and adds
+// the findings and what the command would do about them to the plan.
+func (p *dryRunPlan) addSecrets(cfg *config.Config, paths []string, resolve func(string) string, opts secretOptions) error {
+	findings, err := scanSecrets(cfg, paths, resolve, opts.Allow)
+	if err != nil || len(findings) == 0 {
+		return err
+	}
+	for _, f := range findings {
+		p.Secrets = append(p.Secrets, f.String())
+	}
+	switch {
+	case opts.Redact:
+		p.note("the secrets would be replaced with placeholders")
+	case cfg.Secrets.GetMode() == config.SecretsModeWarn:
+		p.note("the secrets would be copied, since secrets.mode is 'warn'")
+	case opts.Yes:
+		p.note("the command would stop because of the secrets; use --redact-secrets to replace them or --allow-secrets to ignore them")
+	default:
+		p.note("the command would ask whether to replace the secrets with placeholders")
+	}
+	return nil
+}
+
+// printDryRun prints the plan in the given format.
+func printDryRun(w io.Writer, format string, p *dryRunPlan) error {
+	if p.Changes == nil {
+		p.Changes = []dryRunChanges{}
+	}
+	if format == dryRunJSON {
+		data, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode plan: %w", err)
+		}
+		_, _ = fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(w, "Dry run of 'dotgh %s', nothing was changed.\n\n", p.Command)
+	if len(p.Changes) == 0 && len(p.Commits) == 0 && p.Push == "" {
+		_, _ = fmt.Fprintln(w, "No changes.")
+	}
+	for _, c := range p.Changes {
+		_, _ = fmt.Fprintf(w, "Changes to %s (%s):\n", c.Target, c.Dir)
+		for _, path := range c.Added {
+			_, _ = fmt.Fprintf(w, "  + %s\n", path)
+		}
+		for _, path := range c.Modified {
+			_, _ = fmt.Fprintf(w, "  M %s\n", path)
+		}
+		for _, path := range c.Deleted {
+			_, _ = fmt.Fprintf(w, "  - %s\n", path)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	for _, name := range p.Snapshots {
+		_, _ = fmt.Fprintf(w, "Snapshot of template '%s' in the local history\n", name)
+	}
+	if len(p.Secrets) > 0 {
+		_, _ = fmt.Fprintln(w, "Potential secrets:")
+		for _, s := range p.Secrets {
+			_, _ = fmt.Fprintf(w, "  %s\n", s)
+		}
+	}
+	for _, message := range p.Commits {
+		_, _ = fmt.Fprintf(w, "Commit in the sync repository: %q\n", message)
+	}
+	if p.Push != "" {
+		_, _ = fmt.Fprintf(w, "Push to %s\n", p.Push)
+	}
+	for _, n := range p.Notes {
+		_, _ = fmt.Fprintf(w, "Note: %s\n", n)
+	}
+	return nil
+}
+
+// planAutoSync adds what autoSync would do after the command of p to the
+// plan, following the same checks as autoSync.
+func planAutoSync(ctx context.Context, p *dryRunPlan, configDir, message string) error {
+	cfg, err := config.Resolve(configDir, "")
+	if err != nil {
+		return nil
+	}
+	mode := cfg.GetAutoSync()
+	switch mode {
+	case config.AutoSyncOff:
+		return nil
+	case config.AutoSyncCommit, config.AutoSyncPush:
+	default:
+		p.note("unknown auto_sync mode %q, so auto sync would be skipped", mode)
+		return nil
+	}
+
+	targets, err := autoSyncTargets(configDir, p.template)
+	if err != nil {
+		p.note("auto sync would be skipped: %v", err)
+		return nil
+	}
+	for _, target := range targets {
+		if err := planAutoSyncProfile(ctx, p, configDir, target, mode, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planAutoSyncProfile adds what autoSyncProfile would do for target.
+func planAutoSyncProfile(ctx context.Context, p *dryRunPlan, configDir string, target autoSyncTarget, mode, message string) error {
+	manager, _, err := newSyncManager(configDir, target.profile)
+	if err != nil {
+		p.note("auto sync would be skipped: %v", err)
+		return nil
+	}
+	if !manager.IsInitialized() {
+		p.note("auto sync would be skipped: sync is not initialized")
+		return nil
+	}
+	if manager.IsMerging() {
+		p.note("auto sync would be skipped: the sync repository has unresolved conflicts")
+		return nil
+	}
+
+	plan, err := planSyncCopy(manager, target, p)
+	if err != nil {
+		return fmt.Errorf("plan auto sync: %w", err)
+	}
+	if !plan.HasChanges() {
+		return nil
+	}
+	p.addChanges("sync repository", manager.SyncDirPath(), plan)
+	p.Commits = append(p.Commits, target.message(message, plan))
+	if mode == config.AutoSyncPush {
+		p.Push = pushTarget(ctx, manager)
+	}
+	return nil
+}
+
+// planSyncCopy computes the copy of target to the sync directory once the
+// command of p has changed its template, by applying the changes to a copy of the
+// template.
+func planSyncCopy(manager *sync.Manager, target autoSyncTarget, p *dryRunPlan) (*diff.DiffResult, error) {
+	if p.stage == nil {
+		return target.plan(manager)
+	}
+	dir, err := os.MkdirTemp("", "dotgh-plan-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	templateDir := manager.LocalPath("templates/" + target.template)
+	files, err := diff.ListFiles(templateDir)
+	if err != nil {
+		return nil, err
+	}
+	all, err := diff.CompareFiles(files, nil, true, nil)
+	if err == nil {
+		err = diff.ApplyChanges(templateDir, dir, all)
+	}
+	if err == nil {
+		err = p.stage(dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("copy template: %w", err)
+	}
+	return manager.PlanPushAs(target.template, dir)
+}
+
+// pushTarget names the remote branch that the sync repository is pushed to.
+func pushTarget(ctx context.Context, manager *sync.Manager) string {
+	client := manager.GetGitClient()
+	if upstream, err := client.Upstream(ctx); err == nil {
+		return upstream
+	}
+	if branch, err := client.GetCurrentBranch(ctx); err == nil {
+		return "origin/" + branch
+	}
+	return "origin"
+}
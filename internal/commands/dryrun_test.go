@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runDryRun runs c and returns its output.
+func runDryRun(t *testing.T, c *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	c.SetArgs(args)
+	c.SetOut(&buf)
+	c.SetErr(&bytes.Buffer{})
+	err := c.Execute()
+	return buf.String(), err
+}
+
+// decodePlan decodes the output of --dry-run=json.
+func decodePlan(t *testing.T, output string) dryRunPlan {
+	t.Helper()
+	var p dryRunPlan
+	require.NoError(t, json.Unmarshal([]byte(output), &p), output)
+	return p
+}
+
+func TestDryRun(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "go", map[string]string{
+		"AGENTS.md": "# Agents\n",
+		"old.md":    "old\n",
+	})
+	workDir := setupTestSourceDir(t, map[string]string{
+		"AGENTS.md":        "# Local\n",
+		".vscode/mcp.json": mcpConfigWithToken,
+	})
+	cfg := testConfig()
+	cfg.Includes = append(cfg.Includes, "*.md")
+
+	t.Run("pull", func(t *testing.T) {
+		output, err := runDryRun(t, NewPullCmdWithConfig(templatesDir, workDir, cfg), "go", "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Dry run of 'dotgh pull go', nothing was changed.")
+		assert.Contains(t, output, "Changes to directory ("+workDir+"):\n  + old.md\n  M AGENTS.md\n  - .vscode/mcp.json\n")
+		assert.NotContains(t, output, "Apply these changes?")
+
+		data, err := os.ReadFile(filepath.Join(workDir, "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Local\n", string(data))
+	})
+
+	t.Run("push", func(t *testing.T) {
+		output, err := runDryRun(t, NewPushCmdWithConfig(templatesDir, workDir, cfg), "go", "--dry-run=json")
+		require.NoError(t, err)
+		p := decodePlan(t, output)
+		require.Len(t, p.Changes, 1)
+		assert.Equal(t, dryRunChanges{
+			Target:   "template 'go'",
+			Dir:      filepath.Join(templatesDir, "go"),
+			Added:    []string{".vscode/mcp.json"},
+			Modified: []string{"AGENTS.md"},
+			Deleted:  []string{"old.md"},
+		}, p.Changes[0])
+		assert.Equal(t, []string{"go"}, p.Snapshots)
+		require.Len(t, p.Secrets, 1)
+		assert.Contains(t, p.Secrets[0], ".vscode/mcp.json:6")
+		assert.Contains(t, p.Notes, "the command would ask whether to replace the secrets with placeholders")
+
+		_, err = os.Stat(filepath.Join(templatesDir, "go", "old.md"))
+		assert.NoError(t, err, "template should be unchanged")
+		_, err = os.Stat(filepath.Join(templatesDir, ".history"))
+		assert.True(t, os.IsNotExist(err), "no snapshot should be recorded")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		output, err := runDryRun(t, NewDeleteCmd(templatesDir, nil), "go", "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Changes to template 'go' ("+filepath.Join(templatesDir, "go")+"):\n  - AGENTS.md\n  - old.md\n")
+		assert.DirExists(t, filepath.Join(templatesDir, "go"))
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		output, err := runDryRun(t, NewPullCmdWithConfig(templatesDir, t.TempDir(), cfg), "go", "--merge", "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, output, "+ AGENTS.md")
+
+		output, err = runDryRun(t, NewPushCmdWithConfig(templatesDir, filepath.Join(templatesDir, "go"), cfg), "go", "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, output, "No changes.")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := runDryRun(t, NewPullCmdWithConfig(templatesDir, workDir, cfg), "go", "--dry-run=yaml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid --dry-run format "yaml"`)
+	})
+}
+
+func TestDryRunSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", bareDir).Run())
+
+	configDir := t.TempDir()
+	configYAML := "includes:\n  - AGENTS.md\nauto_sync: push\n"
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configYAML), 0644))
+	createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work")
+	_, err := runDryRun(t, NewSyncInitCmd(configDir), bareDir, "-b", "main")
+	require.NoError(t, err)
+	_, err = runDryRun(t, NewSyncPushCmd(configDir), "-m", "initial", "--yes")
+	require.NoError(t, err)
+
+	otherDir := t.TempDir()
+	_, err = runDryRun(t, NewSyncInitCmd(otherDir), bareDir, "-b", "main")
+	require.NoError(t, err)
+	_, err = runDryRun(t, NewSyncPullCmd(otherDir), "--yes")
+	require.NoError(t, err)
+
+	syncedFile := filepath.Join(configDir, ".sync", "templates", "work", "AGENTS.md")
+	createTestFile(t, filepath.Join(configDir, "templates", "work"), "AGENTS.md", "# Work v2")
+
+	t.Run("sync push", func(t *testing.T) {
+		output, err := runDryRun(t, NewSyncPushCmd(configDir), "-m", "update", "--dry-run=json")
+		require.NoError(t, err)
+		p := decodePlan(t, output)
+		require.Len(t, p.Changes, 1)
+		assert.Equal(t, []string{"templates/work/AGENTS.md"}, p.Changes[0].Modified)
+		assert.Equal(t, []string{"update"}, p.Commits)
+		assert.Equal(t, "origin/main", p.Push)
+
+		data, err := os.ReadFile(syncedFile)
+		require.NoError(t, err)
+		assert.Equal(t, "# Work", string(data))
+	})
+
+	t.Run("auto sync of delete", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		cmd.SetOut(io.Discard)
+		p, err := deleteTemplate(cmd, "work", filepath.Join(configDir, "templates"), nil, true, dryRunText)
+		require.NoError(t, err)
+		require.NoError(t, planAutoSync(t.Context(), p, configDir, "Delete template 'work'"))
+
+		require.Len(t, p.Changes, 2)
+		assert.Equal(t, "sync repository", p.Changes[1].Target)
+		assert.Equal(t, []string{"templates/work/AGENTS.md"}, p.Changes[1].Deleted)
+		assert.Equal(t, []string{"Delete template 'work'"}, p.Commits)
+		assert.Equal(t, "origin/main", p.Push)
+		assert.FileExists(t, syncedFile)
+	})
+
+	t.Run("sync pull", func(t *testing.T) {
+		_, err := runDryRun(t, NewSyncPushCmd(configDir), "-m", "update", "--yes")
+		require.NoError(t, err)
+
+		// Without --fetch, the sync repository is left alone
+		gitDir := filepath.Join(otherDir, ".sync", ".git")
+		refs := func() string {
+			out, err := exec.Command("git", "-C", gitDir, "for-each-ref").Output()
+			require.NoError(t, err)
+			return string(out)
+		}
+		before, err := os.Stat(gitDir)
+		require.NoError(t, err)
+		beforeRefs := refs()
+		output, err := runDryRun(t, NewSyncPullCmd(otherDir), "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, output, "No changes.")
+		assert.Contains(t, output, "use --fetch to fetch from the remote first")
+		after, err := os.Stat(gitDir)
+		require.NoError(t, err)
+		assert.Equal(t, before.ModTime(), after.ModTime())
+		assert.Equal(t, beforeRefs, refs())
+
+		output, err = runDryRun(t, NewSyncPullCmd(otherDir), "--dry-run", "--fetch")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Changes to local config directory ("+otherDir+"):\n  M templates/work/AGENTS.md\n")
+		assert.Contains(t, output, "Note: 1 commit(s) would be pulled from origin/main")
+
+		data, err := os.ReadFile(filepath.Join(otherDir, "templates", "work", "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Work", string(data))
+
+		_, err = runDryRun(t, NewSyncPullCmd(otherDir), "--fetch")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--fetch can only be used with --dry-run")
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/openjny/dotgh/internal/config"
 	"github.com/openjny/dotgh/internal/editor"
+	"github.com/openjny/dotgh/internal/history"
 	"github.com/openjny/dotgh/internal/prompt"
 	"github.com/spf13/cobra"
 )
@@ -68,7 +69,7 @@ func runEdit(cmd *cobra.Command, args []string) error {
 
 func runEditWithConfig(cmd *cobra.Command, args []string, templatesDir, configDir string, cfg *config.Config, opts EditOptions) error {
 	w := cmd.OutOrStdout()
-	var targetPath string
+	var targetPath, templateName string
 
 	if len(args) == 0 {
 		// No argument: open templates directory itself
@@ -85,7 +86,7 @@ func runEditWithConfig(cmd *cobra.Command, args []string, templatesDir, configDi
 		targetPath = templatesDir
 	} else {
 		// Argument provided: open specific template
-		templateName := args[0]
+		templateName = args[0]
 		path, err := getTemplatePath(templatesDir, templateName)
 		if err != nil {
 			// Template doesn't exist - check if we should create it
@@ -129,7 +130,7 @@ func runEditWithConfig(cmd *cobra.Command, args []string, templatesDir, configDi
 	// Load config if not provided
 	if cfg == nil {
 		var err error
-		cfg, err = config.LoadFromDir(configDir)
+		cfg, err = config.Resolve(configDir, "")
 		if err != nil {
 			return fmt.Errorf("load config: %w", err)
 		}
@@ -142,13 +143,31 @@ func runEditWithConfig(cmd *cobra.Command, args []string, templatesDir, configDi
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 
-	return execCmd.Run()
+	// Snapshot the template around the edit session. GUI editors return
+	// immediately for directories, so the snapshot taken before the next
+	// session also captures changes made after this one.
+	if templateName != "" {
+		recordHistory(w, templatesDir, templateName, history.SourceEdit)
+	}
+	if err := execCmd.Run(); err != nil {
+		return err
+	}
+	if templateName != "" {
+		recordHistory(w, templatesDir, templateName, history.SourceEdit)
+		autoSync(cmd, configDir, templateName, fmt.Sprintf("Edit template '%s'", templateName))
+	} else {
+		autoSync(cmd, configDir, anyTemplate, "Edit templates")
+	}
+	return nil
 }
 
 // getTemplatePath returns the path to the template directory.
 // It returns an error if the template doesn't exist or is not a directory.
 func getTemplatePath(templatesDir, templateName string) (string, error) {
-	templatePath := filepath.Join(templatesDir, templateName)
+	templatePath, err := resolveTemplateDir(templatesDir, templateName)
+	if err != nil {
+		return "", err
+	}
 
 	info, err := os.Stat(templatePath)
 	if err != nil {
@@ -149,6 +149,13 @@ func TestGetTemplatePath(t *testing.T) {
 			wantError:     true,
 			errorContains: "not found",
 		},
+		{
+			name:          "name outside the templates directory",
+			templateName:  "../" + templateName,
+			wantPath:      "",
+			wantError:     true,
+			errorContains: "invalid template name",
+		},
 	}
 
 	for _, tt := range tests {
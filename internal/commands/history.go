@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/history"
+	"github.com/spf13/cobra"
+)
+
+// Command metadata constants for history
+const (
+	historyCmdUse   = "history <template>"
+	historyCmdShort = "Show the local version history of a template"
+	historyCmdLong  = `Show the local version history of a template.
+
+A snapshot of a template is recorded every time it is pushed or edited with
+'dotgh edit'. Identical versions are recorded only once, and file contents are
+shared between snapshots.
+
+Refer to a snapshot as <template>@<rev> with diff and pull:
+
+Examples:
+  dotgh history my-template           # List snapshots
+  dotgh diff my-template@3            # Compare revision 3 with the current directory
+  dotgh pull my-template@3            # Restore revision 3 into the current directory`
+)
+
+var historyCmd = &cobra.Command{
+	Use:   historyCmdUse,
+	Short: historyCmdShort,
+	Long:  historyCmdLong,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHistory,
+}
+
+// NewHistoryCmd creates a new history command with a custom templates directory.
+// This is primarily used for testing.
+func NewHistoryCmd(customTemplatesDir string) *cobra.Command {
+	return &cobra.Command{
+		Use:   historyCmdUse,
+		Short: historyCmdShort,
+		Long:  historyCmdLong,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistoryWithDir(cmd, args[0], customTemplatesDir)
+		},
+	}
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	return runHistoryWithDir(cmd, args[0], cfg.GetTemplatesDir())
+}
+
+// runHistoryWithDir lists the snapshots of a template, newest first.
+func runHistoryWithDir(cmd *cobra.Command, templateName, templatesDir string) error {
+	w := cmd.OutOrStdout()
+
+	snapshots, err := history.ForTemplatesDir(templatesDir).List(templateName)
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+	if len(snapshots) == 0 {
+		_, _ = fmt.Fprintf(w, "No history for template '%s'.\n", templateName)
+		return nil
+	}
+
+	width := 0
+	for _, snap := range snapshots {
+		width = max(width, len(snap.Source))
+	}
+	_, _ = fmt.Fprintf(w, "History of template '%s':\n", templateName)
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snap := snapshots[i]
+		_, _ = fmt.Fprintf(w, "  %s@%d  %s  %-*s  %d file(s)\n",
+			templateName, snap.Rev, snap.Time.Local().Format("2006-01-02 15:04"), width, snap.Source, len(snap.Files))
+	}
+
+	return nil
+}
+
+// resolveTemplateRef returns the directory holding the files of a template
+// reference. A plain name resolves to the template directory; "<name>@<rev>"
+// is extracted from the history into a temporary directory that the returned
+// cleanup function removes.
+func resolveTemplateRef(templatesDir, ref string) (name, dir string, cleanup func(), err error) {
+	name, rev, err := history.ParseRef(ref)
+	if err != nil {
+		return "", "", nil, err
+	}
+	templateDir, err := resolveTemplateDir(templatesDir, name)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if rev == 0 {
+		return name, templateDir, func() {}, nil
+	}
+
+	store := history.ForTemplatesDir(templatesDir)
+	snap, err := store.Get(name, rev)
+	if err != nil {
+		if errors.Is(err, history.ErrRevisionNotFound) {
+			return "", "", nil, fmt.Errorf("template '%s' has no revision %d (see 'dotgh history %s')", name, rev, name)
+		}
+		return "", "", nil, fmt.Errorf("read history: %w", err)
+	}
+
+	dir, err = os.MkdirTemp("", "dotgh-history-*")
+	if err != nil {
+		return "", "", nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	if err := store.Extract(snap, dir); err != nil {
+		cleanup()
+		return "", "", nil, fmt.Errorf("extract %s: %w", ref, err)
+	}
+	return name, dir, cleanup, nil
+}
+
+// resolveTemplateDir returns the directory of the template name, which is a plain
+// name or "<profile>/<template>". Names can come from agents through
+// 'dotgh mcp serve', so names that leave templatesDir, including through
+// symlinks, are rejected.
+func resolveTemplateDir(templatesDir, name string) (string, error) {
+	segments := strings.Split(name, "/")
+	invalid := name == "" || filepath.IsAbs(name) || len(segments) > 2 || strings.ContainsRune(name, '\\')
+	for _, segment := range segments {
+		invalid = invalid || segment == "" || segment == "." || segment == ".."
+	}
+	if invalid {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+
+	dir := filepath.Join(templatesDir, filepath.FromSlash(name))
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		// A missing template is reported by the caller
+		return dir, nil
+	}
+	root, err := filepath.EvalSymlinks(templatesDir)
+	if err != nil {
+		return "", err
+	}
+	if !within(root, resolved) {
+		return "", fmt.Errorf("template %q is outside the templates directory %s", name, templatesDir)
+	}
+	return dir, nil
+}
+
+// recordHistory snapshots a template into the local history. History is a
+// convenience, so failures are reported as warnings.
+func recordHistory(w io.Writer, templatesDir, templateName, source string) *history.Snapshot {
+	snap, _, err := history.ForTemplatesDir(templatesDir).Record(templateName, filepath.Join(templatesDir, templateName), source)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: could not record history: %v\n", err)
+		return nil
+	}
+	return snap
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openjny/dotgh/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateHistory(t *testing.T) {
+	templatesDir := t.TempDir()
+	sourceDir := setupTestSourceDir(t, map[string]string{"AGENTS.md": "# v1"})
+
+	output, err := executePushCmd(t, templatesDir, sourceDir, "my-template", false, true, nil, "")
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "Snapshot: my-template@1")
+
+	createTestFile(t, sourceDir, "AGENTS.md", "# v2")
+	createTestFile(t, sourceDir, ".github/copilot-instructions.md", "instructions")
+	output, err = executePushCmd(t, templatesDir, sourceDir, "my-template", false, true, nil, "")
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "Snapshot: my-template@2")
+
+	t.Run("history lists snapshots newest first", func(t *testing.T) {
+		cmd := NewHistoryCmd(templatesDir)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"my-template"})
+		require.NoError(t, cmd.Execute())
+
+		out := buf.String()
+		assert.Contains(t, out, "my-template@2")
+		assert.Contains(t, out, "2 file(s)")
+		assert.Less(t, bytes.Index(buf.Bytes(), []byte("@2")), bytes.Index(buf.Bytes(), []byte("@1")))
+	})
+
+	t.Run("list skips the history store", func(t *testing.T) {
+		out, err := executeListCmd(t, templatesDir)
+		require.NoError(t, err)
+		assert.NotContains(t, out, ".history")
+		assert.Contains(t, out, "1 template(s) found")
+	})
+
+	t.Run("diff compares a revision", func(t *testing.T) {
+		out, err := executeDiffCmd(t, templatesDir, sourceDir, "my-template@1", false, false, nil)
+		assert.ErrorIs(t, err, ErrDiffFound)
+		assert.Contains(t, out, "M AGENTS.md")
+		assert.Contains(t, out, "- .github/copilot-instructions.md")
+	})
+
+	t.Run("pull restores a revision", func(t *testing.T) {
+		targetDir := t.TempDir()
+		out, err := executePullCmd(t, templatesDir, targetDir, "my-template@1", false, true, nil, "")
+		require.NoError(t, err, out)
+
+		data, err := os.ReadFile(filepath.Join(targetDir, "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# v1", string(data))
+		assert.NoFileExists(t, filepath.Join(targetDir, ".github", "copilot-instructions.md"))
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		_, err := executePullCmd(t, templatesDir, t.TempDir(), "my-template@9", false, true, nil, "")
+		assert.ErrorContains(t, err, "has no revision 9")
+	})
+
+	t.Run("push rejects revisions", func(t *testing.T) {
+		_, err := executePushCmd(t, templatesDir, sourceDir, "my-template@1", false, true, nil, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("push records the version it overwrites as pre-push", func(t *testing.T) {
+		createTestFile(t, filepath.Join(templatesDir, "my-template"), "AGENTS.md", "# edited by hand")
+		createTestFile(t, sourceDir, "AGENTS.md", "# v3")
+		out, err := executePushCmd(t, templatesDir, sourceDir, "my-template", false, true, nil, "")
+		require.NoError(t, err, out)
+		assert.Contains(t, out, "Snapshot: my-template@4")
+
+		snapshots, err := history.ForTemplatesDir(templatesDir).List("my-template")
+		require.NoError(t, err)
+		require.Len(t, snapshots, 4)
+		assert.Equal(t, history.SourcePrePush, snapshots[2].Source)
+		assert.Equal(t, history.SourcePush, snapshots[3].Source)
+
+		cmd := NewHistoryCmd(templatesDir)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"my-template"})
+		require.NoError(t, cmd.Execute())
+		assert.Contains(t, buf.String(), "  push      1 file(s)")
+		assert.Contains(t, buf.String(), "  pre-push  2 file(s)")
+	})
+}
@@ -3,8 +3,12 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/sync"
 	"github.com/spf13/cobra"
 )
 
@@ -18,32 +22,53 @@ var listCmd = &cobra.Command{
 // NewListCmd creates a new list command with a custom templates directory.
 // This is primarily used for testing.
 func NewListCmd(customTemplatesDir string) *cobra.Command {
+	return NewListCmdWithConfigDir(customTemplatesDir, "")
+}
+
+// NewListCmdWithConfigDir creates a new list command with a custom templates
+// directory, looking up sync profiles in configDir, if set.
+// This is primarily used for testing.
+func NewListCmdWithConfigDir(customTemplatesDir, configDir string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "Display a list of available templates",
 		Long:  `Display a list of available templates stored in the configuration directory.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return listTemplates(cmd, customTemplatesDir)
+			var profiles []string
+			if configDir != "" {
+				var err error
+				if profiles, err = sync.Profiles(configDir); err != nil {
+					return err
+				}
+			}
+			return listTemplates(cmd, customTemplatesDir, profiles)
 		},
 	}
 	return cmd
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	pullIfStale(cmd, config.GetConfigDir())
+
 	// Load config to get templates directory
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
-	return listTemplates(cmd, cfg.GetTemplatesDir())
+	profiles, err := sync.Profiles(config.GetConfigDir())
+	if err != nil {
+		return err
+	}
+	return listTemplates(cmd, cfg.GetTemplatesDir(), profiles)
 }
 
 // listTemplates scans the templates directory and displays available templates.
-func listTemplates(cmd *cobra.Command, dir string) error {
+// Templates of the given sync profiles are listed as "<profile>/<template>".
+func listTemplates(cmd *cobra.Command, dir string, profiles []string) error {
 	w := cmd.OutOrStdout()
 	_, _ = fmt.Fprintln(w, "Available templates:")
 
-	templates, err := scanTemplates(dir)
+	templates, err := scanTemplates(dir, profiles)
 	if err != nil {
 		// Directory doesn't exist or can't be read - show no templates
 		_, _ = fmt.Fprintln(w, "  (no templates found)")
@@ -69,8 +94,11 @@ func listTemplates(cmd *cobra.Command, dir string) error {
 }
 
 // scanTemplates reads the templates directory and returns a list of template names.
-// Only directories are considered as templates (files are ignored).
-func scanTemplates(dir string) ([]string, error) {
+// Only directories are considered as templates (files are ignored). Hidden
+// directories, such as the local history store, are skipped. The directories
+// named after sync profiles hold the templates of those profiles, which are
+// returned as "<profile>/<template>".
+func scanTemplates(dir string, profiles []string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
@@ -78,8 +106,19 @@ func scanTemplates(dir string) ([]string, error) {
 
 	var templates []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !slices.Contains(profiles, entry.Name()) {
 			templates = append(templates, entry.Name())
+			continue
+		}
+		names, err := scanTemplates(filepath.Join(dir, entry.Name()), nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			templates = append(templates, entry.Name()+"/"+name)
 		}
 	}
 
@@ -121,3 +121,28 @@ func TestRunListIgnoresFiles(t *testing.T) {
 		t.Errorf("output should show '1 template(s) found', got:\n%s", output)
 	}
 }
+
+func TestRunListNamespacesProfileTemplates(t *testing.T) {
+	templatesDir := setupTestTemplatesDir(t, []string{"home", "work/api", "work/web"})
+	configDir := filepath.Dir(templatesDir)
+	if err := os.MkdirAll(filepath.Join(configDir, ".sync-profiles", "work", ".git"), 0755); err != nil {
+		t.Fatalf("failed to create profile: %v", err)
+	}
+
+	cmd := NewListCmdWithConfigDir(templatesDir, configDir)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("runList() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"  home\n", "  work/api\n", "  work/web\n", "3 template(s) found"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "  work\n") {
+		t.Errorf("profile namespace should not be listed as a template, got:\n%s", output)
+	}
+}
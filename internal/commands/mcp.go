@@ -0,0 +1,523 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/glob"
+	"github.com/openjny/dotgh/internal/history"
+	"github.com/openjny/dotgh/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP servers across AI tool config files",
+	Long: `Manage the MCP servers of a template or the current directory.
+
+Each AI coding tool declares MCP servers in its own file:
+  copilot  .vscode/mcp.json ("servers")
+  cursor   .cursor/mcp.json ("mcpServers")
+  claude   .mcp.json, .claude/settings.json ("mcpServers")
+  gemini   .gemini/settings.json ("mcpServers")
+
+'dotgh mcp add' writes one server definition to the file of every tool, so
+the tools stay in sync.`,
+}
+
+// Command metadata constants for mcp list
+const (
+	mcpListCmdUse   = "list"
+	mcpListCmdShort = "List the MCP servers of a template or the current directory"
+	mcpListCmdLong  = `List the MCP servers declared in the MCP config files of a template, or of
+the current directory if no template is given.
+
+Servers that some of the files lack, or that the files define differently,
+are pointed out; run 'dotgh mcp add' to write the same definition to all.
+
+Examples:
+  dotgh mcp list
+  dotgh mcp list --template my-template`
+)
+
+// Command metadata constants for mcp add
+const (
+	mcpAddCmdUse   = "add <name> [command] [args...]"
+	mcpAddCmdShort = "Add an MCP server to every MCP config file"
+	mcpAddCmdLong  = `Add an MCP server to the MCP config files of a template, or of the current
+directory if no template is given. A server of the same name is replaced.
+
+The server is written to every MCP config file that exists, and created for
+the tools whose files the includes cover (see 'dotgh config presets'). Use
+--tool to choose the tools instead. Fields of the existing definition that
+dotgh does not manage, such as Gemini's trust, are kept.
+
+Give a command for a local (stdio) server, or --url for a remote one. Put
+the command after -- if its arguments start with a dash.
+
+Refer to environment variables as ${NAME} in env and header values. Each
+file gets the form its tool resolves: an input for VS Code (declared in the
+file's inputs), ${env:NAME} for Cursor and ${NAME} for the others.
+
+Examples:
+  dotgh mcp add github -e GITHUB_TOKEN='${GITHUB_TOKEN}' -- npx -y @modelcontextprotocol/server-github
+  dotgh mcp add docs --url https://example.com/mcp -H 'Authorization=Bearer ${TOKEN}'
+  dotgh mcp add events --url https://example.com/sse --type sse --tool claude,cursor
+  dotgh mcp add github --template my-template -- npx -y @modelcontextprotocol/server-github`
+)
+
+// Command metadata constants for mcp remove
+const (
+	mcpRemoveCmdUse   = "remove <name>"
+	mcpRemoveCmdShort = "Remove an MCP server from every MCP config file"
+	mcpRemoveCmdLong  = `Remove an MCP server from the MCP config files of a template, or of the
+current directory if no template is given. Use --tool to remove it from
+some tools only.
+
+Examples:
+  dotgh mcp remove github
+  dotgh mcp remove github --template my-template`
+)
+
+var mcpListCmd = &cobra.Command{
+	Use:   mcpListCmdUse,
+	Short: mcpListCmdShort,
+	Long:  mcpListCmdLong,
+	Args:  cobra.NoArgs,
+	RunE:  runMCPList,
+}
+
+var mcpAddCmd = &cobra.Command{
+	Use:   mcpAddCmdUse,
+	Short: mcpAddCmdShort,
+	Long:  mcpAddCmdLong,
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runMCPAdd,
+}
+
+var mcpRemoveCmd = &cobra.Command{
+	Use:   mcpRemoveCmdUse,
+	Short: mcpRemoveCmdShort,
+	Long:  mcpRemoveCmdLong,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMCPRemove,
+}
+
+var (
+	mcpTemplateFlag string
+	mcpToolFlag     []string
+	mcpURLFlag      string
+	mcpTypeFlag     string
+	mcpEnvFlag      []string
+	mcpHeaderFlag   []string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{mcpListCmd, mcpAddCmd, mcpRemoveCmd} {
+		cmd.Flags().StringVarP(&mcpTemplateFlag, "template", "t", "", "Template to use instead of the current directory")
+	}
+	for _, cmd := range []*cobra.Command{mcpAddCmd, mcpRemoveCmd} {
+		cmd.Flags().StringSliceVar(&mcpToolFlag, "tool", nil, "Tools to write ("+strings.Join(mcp.Tools(), ", ")+")")
+	}
+	mcpAddCmd.Flags().StringVar(&mcpURLFlag, "url", "", "URL of a remote server")
+	mcpAddCmd.Flags().StringVar(&mcpTypeFlag, "type", "", "Transport: stdio, http or sse (default: stdio with a command, http with --url)")
+	mcpAddCmd.Flags().StringArrayVarP(&mcpEnvFlag, "env", "e", nil, "Environment variable for a stdio server (KEY=VALUE)")
+	mcpAddCmd.Flags().StringArrayVarP(&mcpHeaderFlag, "header", "H", nil, "HTTP header for a remote server (KEY=VALUE)")
+
+	mcpCmd.AddCommand(mcpListCmd)
+	mcpCmd.AddCommand(mcpAddCmd)
+	mcpCmd.AddCommand(mcpRemoveCmd)
+	mcpCmd.AddCommand(mcpServeCmd)
+}
+
+// MCPOptions contains options for the mcp add and remove commands.
+type MCPOptions struct {
+	Template string
+	Tools    []string
+	URL      string
+	Type     string
+	Env      []string
+	Headers  []string
+}
+
+// NewMCPListCmd creates a new mcp list command with custom directories.
+// This is primarily used for testing.
+func NewMCPListCmd(customTemplatesDir, workDir string) *cobra.Command {
+	var template string
+	cmd := &cobra.Command{
+		Use:   mcpListCmdUse,
+		Short: mcpListCmdShort,
+		Long:  mcpListCmdLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listMCPServers(cmd.OutOrStdout(), customTemplatesDir, workDir, template)
+		},
+	}
+	cmd.Flags().StringVarP(&template, "template", "t", "", "Template to use instead of the current directory")
+	return cmd
+}
+
+// NewMCPAddCmd creates a new mcp add command with custom directories and
+// config. This is primarily used for testing.
+func NewMCPAddCmd(customTemplatesDir, workDir string, cfg *config.Config) *cobra.Command {
+	var opts MCPOptions
+	cmd := &cobra.Command{
+		Use:   mcpAddCmdUse,
+		Short: mcpAddCmdShort,
+		Long:  mcpAddCmdLong,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := addMCPServer(cmd.OutOrStdout(), customTemplatesDir, workDir, args, opts, cfg)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Template to use instead of the current directory")
+	cmd.Flags().StringSliceVar(&opts.Tools, "tool", nil, "Tools to write ("+strings.Join(mcp.Tools(), ", ")+")")
+	cmd.Flags().StringVar(&opts.URL, "url", "", "URL of a remote server")
+	cmd.Flags().StringVar(&opts.Type, "type", "", "Transport: stdio, http or sse (default: stdio with a command, http with --url)")
+	cmd.Flags().StringArrayVarP(&opts.Env, "env", "e", nil, "Environment variable for a stdio server (KEY=VALUE)")
+	cmd.Flags().StringArrayVarP(&opts.Headers, "header", "H", nil, "HTTP header for a remote server (KEY=VALUE)")
+	return cmd
+}
+
+// NewMCPRemoveCmd creates a new mcp remove command with custom directories.
+// This is primarily used for testing.
+func NewMCPRemoveCmd(customTemplatesDir, workDir string) *cobra.Command {
+	var opts MCPOptions
+	cmd := &cobra.Command{
+		Use:   mcpRemoveCmdUse,
+		Short: mcpRemoveCmdShort,
+		Long:  mcpRemoveCmdLong,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := removeMCPServer(cmd.OutOrStdout(), customTemplatesDir, workDir, args[0], opts)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Template to use instead of the current directory")
+	cmd.Flags().StringSliceVar(&opts.Tools, "tool", nil, "Tools to write ("+strings.Join(mcp.Tools(), ", ")+")")
+	return cmd
+}
+
+func runMCPList(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	cfg, err := config.Resolve("", cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	return listMCPServers(cmd.OutOrStdout(), cfg.GetTemplatesDir(), cwd, mcpTemplateFlag)
+}
+
+func runMCPAdd(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	cfg, err := config.Resolve("", cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	opts := MCPOptions{
+		Template: mcpTemplateFlag,
+		Tools:    mcpToolFlag,
+		URL:      mcpURLFlag,
+		Type:     mcpTypeFlag,
+		Env:      mcpEnvFlag,
+		Headers:  mcpHeaderFlag,
+	}
+	changed, err := addMCPServer(cmd.OutOrStdout(), cfg.GetTemplatesDir(), cwd, args, opts, cfg)
+	if err != nil {
+		return err
+	}
+	if changed && opts.Template != "" {
+		autoSync(cmd, config.GetConfigDir(), opts.Template, fmt.Sprintf("Add MCP server '%s' to template '%s'", args[0], opts.Template))
+	}
+	return nil
+}
+
+func runMCPRemove(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	cfg, err := config.Resolve("", cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	opts := MCPOptions{Template: mcpTemplateFlag, Tools: mcpToolFlag}
+	changed, err := removeMCPServer(cmd.OutOrStdout(), cfg.GetTemplatesDir(), cwd, args[0], opts)
+	if err != nil {
+		return err
+	}
+	if changed && opts.Template != "" {
+		autoSync(cmd, config.GetConfigDir(), opts.Template, fmt.Sprintf("Remove MCP server '%s' from template '%s'", args[0], opts.Template))
+	}
+	return nil
+}
+
+// mcpDir returns the directory that the mcp commands work on, a template
+// or workDir, and how to call it in messages.
+func mcpDir(templatesDir, workDir, template string) (string, string, error) {
+	if template == "" {
+		return workDir, "the current directory", nil
+	}
+	if strings.Contains(template, "@") {
+		return "", "", fmt.Errorf("cannot edit a template revision %q", template)
+	}
+	dir, err := getTemplatePath(templatesDir, template)
+	if err != nil {
+		return "", "", err
+	}
+	return dir, fmt.Sprintf("template '%s'", template), nil
+}
+
+// listMCPServers prints the servers of the MCP config files in a template or
+// workDir.
+func listMCPServers(w io.Writer, templatesDir, workDir, template string) error {
+	dir, where, err := mcpDir(templatesDir, workDir, template)
+	if err != nil {
+		return err
+	}
+	files, err := mcp.ReadFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		_, _ = fmt.Fprintf(w, "No MCP config files in %s.\n", where)
+		return nil
+	}
+
+	// Collect the definitions of each server across the files
+	type entry struct {
+		server mcp.Server
+		paths  []string
+		differ []string
+	}
+	var entries []*entry
+	byName := make(map[string]*entry)
+	var withServers []string
+	for _, f := range files {
+		if f.Dialect.Secondary && !f.HasServers() {
+			continue
+		}
+		withServers = append(withServers, f.Dialect.Path)
+		servers, err := f.Servers()
+		if err != nil {
+			return err
+		}
+		for _, s := range servers {
+			e, ok := byName[s.Name]
+			switch {
+			case !ok:
+				e = &entry{server: s}
+				byName[s.Name] = e
+				entries = append(entries, e)
+			case !e.server.Equal(s):
+				e.differ = append(e.differ, f.Dialect.Path)
+			}
+			e.paths = append(e.paths, f.Dialect.Path)
+		}
+	}
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintf(w, "No MCP servers in %s.\n", where)
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(w, "MCP servers in %s:\n", where)
+	for _, e := range entries {
+		target := e.server.URL
+		if e.server.Type == mcp.TypeStdio {
+			target = strings.Join(append([]string{e.server.Command}, e.server.Args...), " ")
+		}
+		_, _ = fmt.Fprintf(w, "  %-16s %-5s %s\n", e.server.Name, e.server.Type, target)
+		_, _ = fmt.Fprintf(w, "      in: %s\n", strings.Join(e.paths, ", "))
+		var missing []string
+		for _, path := range withServers {
+			if !slices.Contains(e.paths, path) {
+				missing = append(missing, path)
+			}
+		}
+		if len(missing) > 0 {
+			_, _ = fmt.Fprintf(w, "      missing in: %s\n", strings.Join(missing, ", "))
+		}
+		if len(e.differ) > 0 {
+			_, _ = fmt.Fprintf(w, "      defined differently in: %s\n", strings.Join(e.differ, ", "))
+		}
+	}
+	return nil
+}
+
+// mcpTargetFiles returns the MCP config files in dir to write: those of the
+// given tools, or else the files that exist and those that the includes
+// cover. Secondary files are only written if they declare servers already.
+func mcpTargetFiles(dir string, tools []string, includes []string) ([]*mcp.File, error) {
+	if err := checkMCPTools(tools); err != nil {
+		return nil, err
+	}
+	var files []*mcp.File
+	for _, d := range mcp.Dialects {
+		if len(tools) > 0 && !slices.Contains(tools, d.Tool) {
+			continue
+		}
+		f, err := mcp.ReadFile(dir, d)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case d.Secondary:
+			if f == nil || !f.HasServers() {
+				continue
+			}
+		case f == nil:
+			included := slices.ContainsFunc(includes, func(pattern string) bool {
+				ok, _ := glob.MatchPattern(pattern, d.Path)
+				return ok
+			})
+			if len(tools) == 0 && !included {
+				continue
+			}
+			f = mcp.NewFile(d)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// checkMCPTools reports tools that have no MCP config file.
+func checkMCPTools(tools []string) error {
+	for _, tool := range tools {
+		if !slices.Contains(mcp.Tools(), tool) {
+			return fmt.Errorf("unknown tool %q (one of %s)", tool, strings.Join(mcp.Tools(), ", "))
+		}
+	}
+	return nil
+}
+
+// writeMCPFiles writes the changed files into dir, recording the history of
+// template around the change.
+func writeMCPFiles(w io.Writer, templatesDir, template, dir string, files []*mcp.File) error {
+	if template != "" {
+		recordHistory(w, templatesDir, template, history.SourceMCP)
+	}
+	for _, f := range files {
+		if err := f.Write(dir); err != nil {
+			return fmt.Errorf("write %s: %w", f.Dialect.Path, err)
+		}
+	}
+	if template != "" {
+		recordHistory(w, templatesDir, template, history.SourceMCP)
+	}
+	return nil
+}
+
+// addMCPServer writes the server given by args and opts to the MCP config
+// files. It reports whether any file changed.
+func addMCPServer(w io.Writer, templatesDir, workDir string, args []string, opts MCPOptions, cfg *config.Config) (bool, error) {
+	dir, where, err := mcpDir(templatesDir, workDir, opts.Template)
+	if err != nil {
+		return false, err
+	}
+
+	server := mcp.Server{Name: args[0], Type: opts.Type, URL: opts.URL}
+	if len(args) > 1 {
+		server.Command, server.Args = args[1], args[2:]
+	}
+	if server.Type == "" {
+		server.Type = mcp.TypeStdio
+		if server.URL != "" {
+			server.Type = mcp.TypeHTTP
+		}
+	}
+	if server.Env, err = mcp.ParseAssignments(opts.Env); err != nil {
+		return false, fmt.Errorf("--env: %w", err)
+	}
+	if server.Headers, err = mcp.ParseAssignments(opts.Headers); err != nil {
+		return false, fmt.Errorf("--header: %w", err)
+	}
+	if err := server.Validate(); err != nil {
+		return false, err
+	}
+
+	var includes []string
+	if cfg != nil {
+		includes = cfg.Includes
+	}
+	files, err := mcpTargetFiles(dir, opts.Tools, includes)
+	if err != nil {
+		return false, err
+	}
+	if len(files) == 0 {
+		return false, fmt.Errorf("no MCP config files in %s and the includes cover none; add a preset ('dotgh config add presets claude') or choose tools with --tool", where)
+	}
+
+	var changed []*mcp.File
+	for _, f := range files {
+		ok, err := f.Set(server)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		_, _ = fmt.Fprintf(w, "Server '%s' is up to date in %s.\n", server.Name, where)
+		return false, nil
+	}
+	if err := writeMCPFiles(w, templatesDir, opts.Template, dir, changed); err != nil {
+		return false, err
+	}
+	_, _ = fmt.Fprintf(w, "Added server '%s' in %s:\n", server.Name, where)
+	for _, f := range changed {
+		_, _ = fmt.Fprintf(w, "  %s (%s)\n", f.Dialect.Path, f.Dialect.Tool)
+	}
+	return true, nil
+}
+
+// removeMCPServer removes a server from the MCP config files. It reports
+// whether any file changed.
+func removeMCPServer(w io.Writer, templatesDir, workDir, name string, opts MCPOptions) (bool, error) {
+	dir, where, err := mcpDir(templatesDir, workDir, opts.Template)
+	if err != nil {
+		return false, err
+	}
+	if err := checkMCPTools(opts.Tools); err != nil {
+		return false, err
+	}
+	files, err := mcp.ReadFiles(dir)
+	if err != nil {
+		return false, err
+	}
+
+	var changed []*mcp.File
+	for _, f := range files {
+		if len(opts.Tools) > 0 && !slices.Contains(opts.Tools, f.Dialect.Tool) {
+			continue
+		}
+		ok, err := f.Remove(name)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		return false, fmt.Errorf("server '%s' not found in %s", name, where)
+	}
+	if err := writeMCPFiles(w, templatesDir, opts.Template, dir, changed); err != nil {
+		return false, err
+	}
+	_, _ = fmt.Fprintf(w, "Removed server '%s' in %s:\n", name, where)
+	for _, f := range changed {
+		_, _ = fmt.Fprintf(w, "  %s (%s)\n", f.Dialect.Path, f.Dialect.Tool)
+	}
+	return true, nil
+}
@@ -0,0 +1,438 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/convert"
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/glob"
+	"github.com/openjny/dotgh/internal/mcpserver"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/openjny/dotgh/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// Command metadata constants for mcp serve
+const (
+	mcpServeCmdUse   = "serve"
+	mcpServeCmdShort = "Run dotgh as an MCP server for AI agents"
+	mcpServeCmdLong  = `Run dotgh as an MCP server over stdio, so that AI agents can find templates
+and apply them to a project themselves.
+
+The server has these tools:
+  list_templates  List the templates
+  show_template   Show the files a template would pull, with their content
+  diff_template   Show what pulling a template into a directory would change
+  pull_template   Pull a template into a directory (a dry run unless the
+                  agent sets dry_run to false)
+
+Paths are relative to the directory the server runs in. The project config
+file (.dotgh.yaml) of each directory applies, as it does for 'dotgh pull'.
+
+Register the server with your AI tools, for example:
+  dotgh mcp add dotgh -- dotgh mcp serve`
+)
+
+var mcpServeCmd = &cobra.Command{
+	Use:   mcpServeCmdUse,
+	Short: mcpServeCmdShort,
+	Long:  mcpServeCmdLong,
+	Args:  cobra.NoArgs,
+	RunE:  runMCPServe,
+}
+
+// mcpServerInstructions tells agents what the server is for.
+const mcpServerInstructions = `dotgh manages templates of AI coding assistant files, such as instructions, prompts and MCP configs. Use list_templates to find a template, show_template to read it, and diff_template to see what pulling it into a project would change. pull_template is a dry run unless dry_run is false; confirm with the user before pulling, as a full sync deletes files that the template lacks.`
+
+// Input schemas of the tools
+const (
+	listTemplatesSchema = `{"type": "object", "properties": {}}`
+	showTemplateSchema  = `{
+  "type": "object",
+  "properties": {
+    "template": {"type": "string", "description": "Template name, or <name>@<rev> for a revision from the history (default: the template of the project config)"}
+  }
+}`
+	diffTemplateSchema = `{
+  "type": "object",
+  "properties": {
+    "template": {"type": "string", "description": "Template name, or <name>@<rev> for a revision from the history (default: the template of the project config)"},
+    "path": {"type": "string", "description": "Directory to compare with, inside the current directory (default: the current directory)"},
+    "merge": {"type": "boolean", "description": "Merge mode: only add and update files, no deletions"}
+  }
+}`
+	pullTemplateSchema = `{
+  "type": "object",
+  "properties": {
+    "template": {"type": "string", "description": "Template name, or <name>@<rev> for a revision from the history (default: the template of the project config)"},
+    "path": {"type": "string", "description": "Directory to pull into, inside the current directory (default: the current directory)"},
+    "merge": {"type": "boolean", "description": "Merge mode: only add and update files, no deletions"},
+    "dry_run": {"type": "boolean", "description": "Only report the changes (default: true)"}
+  }
+}`
+)
+
+// maxShownFileSize is the size above which show_template leaves out the
+// content of a file.
+const maxShownFileSize = 64 * 1024
+
+// NewMCPServeCmd creates a new mcp serve command with custom directories and
+// config. This is primarily used for testing.
+func NewMCPServeCmd(customTemplatesDir, workDir string, cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   mcpServeCmdUse,
+		Short: mcpServeCmdShort,
+		Long:  mcpServeCmdLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := newTemplateServer(customTemplatesDir, workDir, nil, cfg)
+			return s.Serve(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+}
+
+func runMCPServe(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current directory: %w", err)
+	}
+	cfg, err := config.Resolve("", cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	profiles, err := sync.Profiles(config.GetConfigDir())
+	if err != nil {
+		return err
+	}
+	// Resolve the config of each directory the agent names
+	s := newTemplateServer(cfg.GetTemplatesDir(), cwd, profiles, nil)
+	return s.Serve(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+}
+
+// templateServer serves the templates in templatesDir to agents. Paths are
+// relative to workDir. If cfg is nil, the config is resolved for each
+// directory, so that project config files apply.
+type templateServer struct {
+	templatesDir string
+	workDir      string
+	profiles     []string
+	cfg          *config.Config
+}
+
+// newTemplateServer returns the MCP server of 'dotgh mcp serve'.
+func newTemplateServer(templatesDir, workDir string, profiles []string, cfg *config.Config) *mcpserver.Server {
+	ts := &templateServer{templatesDir: templatesDir, workDir: workDir, profiles: profiles, cfg: cfg}
+	s := mcpserver.New("dotgh", version.Version, mcpServerInstructions)
+	s.AddTool(mcpserver.Tool{
+		Name:        "list_templates",
+		Description: "List the dotgh templates, and the default template of the current directory if its project config sets one.",
+		InputSchema: json.RawMessage(listTemplatesSchema),
+		Handler:     ts.listTemplates,
+	})
+	s.AddTool(mcpserver.Tool{
+		Name:        "show_template",
+		Description: "Show the files of a template that a pull would write, with their content. Files generated from the template's rules source are marked.",
+		InputSchema: json.RawMessage(showTemplateSchema),
+		Handler:     ts.showTemplate,
+	})
+	s.AddTool(mcpserver.Tool{
+		Name:        "diff_template",
+		Description: "Show the files that pulling a template into a directory would add, modify and delete.",
+		InputSchema: json.RawMessage(diffTemplateSchema),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in templateArgs
+			if err := mcpserver.DecodeArgs(args, &in); err != nil {
+				return nil, err
+			}
+			return ts.pull(in, false)
+		},
+	})
+	s.AddTool(mcpserver.Tool{
+		Name:        "pull_template",
+		Description: "Pull a template into a directory. Only reports the changes unless dry_run is false. A full sync deletes the files that the template lacks; use merge to keep them.",
+		InputSchema: json.RawMessage(pullTemplateSchema),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in struct {
+				templateArgs
+				DryRun *bool `json:"dry_run"`
+			}
+			if err := mcpserver.DecodeArgs(args, &in); err != nil {
+				return nil, err
+			}
+			return ts.pull(in.templateArgs, in.DryRun != nil && !*in.DryRun)
+		},
+	})
+	return s
+}
+
+// templateArgs are the arguments of the tools that work on a template.
+type templateArgs struct {
+	Template string `json:"template"`
+	Path     string `json:"path"`
+	Merge    bool   `json:"merge"`
+}
+
+// config returns the config for dir.
+func (ts *templateServer) config(dir string) (*config.Config, error) {
+	if ts.cfg != nil {
+		return ts.cfg, nil
+	}
+	cfg, err := config.Resolve("", dir)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// dir returns the directory at path, relative to the work directory.
+// Agents only get to change the work directory, so paths outside it,
+// including through symlinks, are rejected.
+func (ts *templateServer) dir(path string) (string, error) {
+	dir := ts.workDir
+	if path != "" {
+		dir = path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(ts.workDir, dir)
+		}
+		dir = filepath.Clean(dir)
+	}
+	if !within(ts.workDir, dir) {
+		return "", fmt.Errorf("%s is outside the current directory %s", path, ts.workDir)
+	}
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("directory %s not found", dir)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	// Symlinks are resolved only now that dir is known to exist
+	root, err := filepath.EvalSymlinks(ts.workDir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	if !within(root, resolved) {
+		return "", fmt.Errorf("%s is outside the current directory %s", path, ts.workDir)
+	}
+	return dir, nil
+}
+
+// within reports whether path is root or inside it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// listTemplatesResult is the result of list_templates.
+type listTemplatesResult struct {
+	Templates []string `json:"templates"`
+	// Default is the template of the project config of the work directory.
+	Default string `json:"default,omitempty"`
+}
+
+func (ts *templateServer) listTemplates(ctx context.Context, args json.RawMessage) (any, error) {
+	if err := mcpserver.DecodeArgs(args, &struct{}{}); err != nil {
+		return nil, err
+	}
+	templates, err := scanTemplates(ts.templatesDir, ts.profiles)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read templates: %w", err)
+	}
+	result := listTemplatesResult{Templates: templates}
+	if result.Templates == nil {
+		result.Templates = []string{}
+	}
+	if cfg, err := ts.config(ts.workDir); err == nil {
+		result.Default = cfg.Template
+	}
+	return result, nil
+}
+
+// templateFile is a file of a template shown by show_template.
+type templateFile struct {
+	Path string `json:"path"`
+	// Generated is set for files generated from the rules source.
+	Generated bool   `json:"generated,omitempty"`
+	Content   string `json:"content,omitempty"`
+	// Omitted says why the content is left out.
+	Omitted string `json:"omitted,omitempty"`
+}
+
+// showTemplateResult is the result of show_template.
+type showTemplateResult struct {
+	Template string         `json:"template"`
+	Files    []templateFile `json:"files"`
+}
+
+func (ts *templateServer) showTemplate(ctx context.Context, args json.RawMessage) (any, error) {
+	var in struct {
+		Template string `json:"template"`
+	}
+	if err := mcpserver.DecodeArgs(args, &in); err != nil {
+		return nil, err
+	}
+	cfg, err := ts.config(ts.workDir)
+	if err != nil {
+		return nil, err
+	}
+	name, err := templateArg(nonEmpty(in.Template), cfg)
+	if err != nil {
+		return nil, err
+	}
+	templatePath, derived, cleanup, err := ts.stage(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	paths, err := glob.ExpandPatterns(templatePath, cfg.Includes)
+	if err != nil {
+		return nil, err
+	}
+	if paths, err = glob.FilterExcludes(paths, cfg.Excludes); err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	result := showTemplateResult{Template: name, Files: []templateFile{}}
+	generated := derivedPaths(derived)
+	for _, path := range paths {
+		full := filepath.Join(templatePath, filepath.FromSlash(path))
+		info, err := os.Stat(full)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		f := templateFile{Path: path, Generated: slices.Contains(generated, path)}
+		if info.Size() > maxShownFileSize {
+			f.Omitted = fmt.Sprintf("%d bytes", info.Size())
+		} else if content, err := os.ReadFile(full); err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		} else if !utf8.Valid(content) {
+			f.Omitted = "binary"
+		} else {
+			f.Content = string(content)
+		}
+		result.Files = append(result.Files, f)
+	}
+	return result, nil
+}
+
+// pullPlan is the result of diff_template and pull_template.
+type pullPlan struct {
+	Template  string   `json:"template"`
+	Path      string   `json:"path"`
+	Mode      string   `json:"mode"`
+	Added     []string `json:"added"`
+	Modified  []string `json:"modified"`
+	Deleted   []string `json:"deleted"`
+	Unchanged int      `json:"unchanged"`
+	// Generated lists the files generated from the rules source.
+	Generated []string `json:"generated,omitempty"`
+	// Skipped lists the files that are encrypted in the sync repository
+	// and cannot be decrypted without a sync key on this machine.
+	Skipped []string `json:"skipped,omitempty"`
+	// Applied is set once the changes are written.
+	Applied bool `json:"applied"`
+}
+
+// pull computes the changes of pulling a template, and applies them if
+// apply is set.
+func (ts *templateServer) pull(in templateArgs, apply bool) (any, error) {
+	dir, err := ts.dir(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ts.config(dir)
+	if err != nil {
+		return nil, err
+	}
+	name, err := templateArg(nonEmpty(in.Template), cfg)
+	if err != nil {
+		return nil, err
+	}
+	templatePath, derived, cleanup, err := ts.stage(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	diffResult, err := diff.ComputeDiff(templatePath, dir, cfg.Includes, cfg.Excludes, in.Merge)
+	if err != nil {
+		return nil, fmt.Errorf("compute diff: %w", err)
+	}
+	locked, err := skipLocked(templatePath, diffResult)
+	if err != nil {
+		return nil, err
+	}
+	plan := pullPlan{
+		Template:  name,
+		Path:      dir,
+		Mode:      "full sync",
+		Added:     changePaths(diffResult.Added),
+		Modified:  changePaths(diffResult.Modified),
+		Deleted:   changePaths(diffResult.Deleted),
+		Unchanged: len(diffResult.Unchanged),
+		Generated: derivedPaths(derived),
+		Skipped:   locked,
+	}
+	if in.Merge {
+		plan.Mode = "merge"
+	}
+	if apply && diffResult.HasChanges() {
+		if err := diff.ApplyChanges(templatePath, dir, diffResult); err != nil {
+			return nil, fmt.Errorf("apply changes: %w", err)
+		}
+		plan.Applied = true
+	}
+	return plan, nil
+}
+
+// stage returns the directory to pull a template reference from, as pull
+// does, with the files generated from its rules source.
+func (ts *templateServer) stage(ref string, cfg *config.Config) (string, []convert.File, func(), error) {
+	_, templatePath, cleanupRef, err := resolveTemplateRef(ts.templatesDir, ref)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
+		cleanupRef()
+		return "", nil, nil, fmt.Errorf("template '%s' not found", ref)
+	}
+	dir, derived, cleanupStage, err := stageTemplate(templatePath, cfg)
+	if err != nil {
+		cleanupRef()
+		return "", nil, nil, err
+	}
+	return dir, derived, func() { cleanupStage(); cleanupRef() }, nil
+}
+
+// changePaths returns the paths of changes, never nil.
+func changePaths(changes []diff.FileChange) []string {
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.Path
+	}
+	return paths
+}
+
+// nonEmpty returns the arguments for templateArg: s, unless it is empty.
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
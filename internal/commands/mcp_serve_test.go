@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openjny/dotgh/internal/mcpserver"
+)
+
+// connectTemplateServer returns a client connected to the MCP server of
+// 'dotgh mcp serve'.
+func connectTemplateServer(t *testing.T, templatesDir, workDir string) *mcpserver.Client {
+	t.Helper()
+	c := mcpserver.Connect(context.Background(), newTemplateServer(templatesDir, workDir, nil, testConfig()))
+	t.Cleanup(func() { _ = c.Close() })
+	if _, _, err := c.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	return c
+}
+
+// callTool calls a tool and decodes its result into v.
+func callTool(t *testing.T, c *mcpserver.Client, name string, args any, v any) {
+	t.Helper()
+	result, err := c.CallTool(name, args)
+	if err != nil {
+		t.Fatalf("CallTool(%s) error = %v", name, err)
+	}
+	if result.IsError {
+		t.Fatalf("CallTool(%s) failed: %s", name, result.Content[0].Text)
+	}
+	if err := json.Unmarshal(result.StructuredContent, v); err != nil {
+		t.Fatalf("decode %s result: %v", name, err)
+	}
+}
+
+func TestMCPServeTools(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "go", map[string]string{
+		"AGENTS.md":                       "# Agents\n",
+		".github/copilot-instructions.md": "Use Go.\n",
+		"notes.txt":                       "not included\n",
+	})
+	workDir := setupTestSourceDir(t, map[string]string{"AGENTS.md": "# Old\n"})
+	c := connectTemplateServer(t, templatesDir, workDir)
+
+	tools, err := c.ListTools()
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"list_templates", "show_template", "diff_template", "pull_template"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+
+	var list listTemplatesResult
+	callTool(t, c, "list_templates", nil, &list)
+	if !reflect.DeepEqual(list.Templates, []string{"go"}) {
+		t.Errorf("list_templates = %+v", list)
+	}
+
+	var show showTemplateResult
+	callTool(t, c, "show_template", map[string]string{"template": "go"}, &show)
+	wantFiles := []templateFile{
+		{Path: ".github/copilot-instructions.md", Content: "Use Go.\n"},
+		{Path: "AGENTS.md", Content: "# Agents\n"},
+	}
+	if !reflect.DeepEqual(show.Files, wantFiles) {
+		t.Errorf("show_template files = %+v, want %+v", show.Files, wantFiles)
+	}
+
+	// diff_template and pull_template without dry_run do not write
+	for _, tool := range []string{"diff_template", "pull_template"} {
+		var plan pullPlan
+		callTool(t, c, tool, map[string]string{"template": "go"}, &plan)
+		if plan.Applied || !reflect.DeepEqual(plan.Added, []string{".github/copilot-instructions.md"}) ||
+			!reflect.DeepEqual(plan.Modified, []string{"AGENTS.md"}) {
+			t.Errorf("%s = %+v", tool, plan)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "AGENTS.md")); string(data) != "# Old\n" {
+		t.Errorf("AGENTS.md = %q after a dry run, want it unchanged", data)
+	}
+
+	var plan pullPlan
+	callTool(t, c, "pull_template", map[string]any{"template": "go", "dry_run": false}, &plan)
+	if !plan.Applied {
+		t.Errorf("pull_template = %+v, want applied", plan)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "AGENTS.md")); string(data) != "# Agents\n" {
+		t.Errorf("AGENTS.md = %q after pull, want the template's", data)
+	}
+	callTool(t, c, "diff_template", map[string]string{"template": "go"}, &plan)
+	if len(plan.Added)+len(plan.Modified)+len(plan.Deleted) != 0 || plan.Unchanged != 2 {
+		t.Errorf("diff_template after pull = %+v, want no changes", plan)
+	}
+}
+
+func TestMCPServePullPath(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "go", map[string]string{
+		"AGENTS.md": "# Agents\n",
+	})
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c := connectTemplateServer(t, templatesDir, workDir)
+
+	var plan pullPlan
+	callTool(t, c, "pull_template", map[string]any{"template": "go", "path": "sub", "merge": true, "dry_run": false}, &plan)
+	if plan.Path != filepath.Join(workDir, "sub") || plan.Mode != "merge" || !plan.Applied {
+		t.Errorf("pull_template = %+v", plan)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "sub", "AGENTS.md")); err != nil {
+		t.Errorf("expected AGENTS.md in sub: %v", err)
+	}
+}
+
+func TestMCPServeErrors(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "go", map[string]string{"AGENTS.md": "x"})
+	workDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(workDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	// A template-like directory next to the templates directory
+	escaped := t.TempDir()
+	createTestFile(t, escaped, "AGENTS.md", "not a template")
+	if err := os.Symlink(escaped, filepath.Join(templatesDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	c := connectTemplateServer(t, templatesDir, workDir)
+
+	tests := []struct {
+		tool    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"show_template", map[string]any{"template": "missing"}, "template 'missing' not found"},
+		{"diff_template", map[string]any{}, "no template given"},
+		{"diff_template", map[string]any{"template": "go", "path": "missing"}, "missing not found"},
+		{"pull_template", map[string]any{"template": "go", "path": "../" + filepath.Base(outside), "dry_run": false}, "is outside the current directory"},
+		{"pull_template", map[string]any{"template": "go", "path": "sub/../.."}, "is outside the current directory"},
+		{"pull_template", map[string]any{"template": "go", "path": outside, "dry_run": false}, "is outside the current directory"},
+		{"pull_template", map[string]any{"template": "go", "path": "link", "dry_run": false}, "is outside the current directory"},
+		{"pull_template", map[string]any{"template": "go", "force": true}, `unknown field "force"`},
+		{"pull_template", map[string]any{"template": "go@3"}, "template 'go' has no revision 3"},
+		{"show_template", map[string]any{"template": "../" + filepath.Base(escaped)}, "invalid template name"},
+		{"show_template", map[string]any{"template": escaped}, "invalid template name"},
+		{"show_template", map[string]any{"template": "escape"}, "is outside the templates directory"},
+		{"diff_template", map[string]any{"template": "../" + filepath.Base(escaped)}, "invalid template name"},
+		{"diff_template", map[string]any{"template": "escape"}, "is outside the templates directory"},
+		{"pull_template", map[string]any{"template": "../" + filepath.Base(escaped), "dry_run": false}, "invalid template name"},
+		{"pull_template", map[string]any{"template": "go/../../" + filepath.Base(escaped), "dry_run": false}, "invalid template name"},
+		{"pull_template", map[string]any{"template": "escape", "dry_run": false}, "is outside the templates directory"},
+		{"pull_template", map[string]any{"template": "../x@1", "dry_run": false}, "invalid template name"},
+	}
+	for _, tt := range tests {
+		result, err := c.CallTool(tt.tool, tt.args)
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", tt.tool, err)
+		}
+		if !result.IsError || !strings.Contains(result.Content[0].Text, tt.wantErr) {
+			t.Errorf("%s(%v) = %+v, want error %q", tt.tool, tt.args, result, tt.wantErr)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "AGENTS.md")); !os.IsNotExist(err) {
+		t.Errorf("nothing should be written outside the work directory, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "AGENTS.md")); !os.IsNotExist(err) {
+		t.Errorf("files from outside the templates directory should not be pulled, got %v", err)
+	}
+}
+
+func TestMCPServeCmd(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "go", map[string]string{"AGENTS.md": "x"})
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_templates"}}` + "\n"
+	var out bytes.Buffer
+	cmd := NewMCPServeCmd(templatesDir, t.TempDir(), testConfig())
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(&out)
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("mcp serve failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"structuredContent":{"templates":["go"]}`) {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func executeMCPCmd(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestMCPAddListRemove(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "work", map[string]string{
+		".vscode/mcp.json":      "{\n  \"servers\": {},\n  \"inputs\": [],\n}\n",
+		".gemini/settings.json": `{"theme": "Default"}`,
+		".claude/settings.json": `{"permissions": {}}`,
+	})
+	templateDir := filepath.Join(templatesDir, "work")
+	cfg := &config.Config{Includes: append(config.DefaultIncludes[:len(config.DefaultIncludes):len(config.DefaultIncludes)], ".mcp.json")}
+
+	output, err := executeMCPCmd(t, NewMCPAddCmd(templatesDir, t.TempDir(), cfg),
+		"github", "--template", "work", "-e", "TOKEN=${TOKEN}", "--", "npx", "-y", "server-github")
+	if err != nil {
+		t.Fatalf("mcp add failed: %v\n%s", err, output)
+	}
+	want := "Added server 'github' in template 'work':\n" +
+		"  .vscode/mcp.json (copilot)\n" +
+		"  .mcp.json (claude)\n" +
+		"  .gemini/settings.json (gemini)\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+	// .cursor/mcp.json is not included, and .claude/settings.json has no servers
+	for _, path := range []string{".cursor/mcp.json"} {
+		if _, err := os.Stat(filepath.Join(templateDir, path)); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be created", path)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(templateDir, ".claude", "settings.json"))
+	if string(data) != `{"permissions": {}}` {
+		t.Errorf(".claude/settings.json = %s, want it unchanged", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(templateDir, ".gemini", "settings.json"))
+	wantGemini := "{\n  \"theme\": \"Default\",\n  \"mcpServers\": {\n    \"github\": {\n      \"command\": \"npx\",\n      \"args\": [\n        \"-y\",\n        \"server-github\"\n      ],\n      \"env\": {\n        \"TOKEN\": \"${TOKEN}\"\n      }\n    }\n  }\n}\n"
+	if string(data) != wantGemini {
+		t.Errorf(".gemini/settings.json = %s, want %s", data, wantGemini)
+	}
+
+	// A second server only for some tools
+	output, err = executeMCPCmd(t, NewMCPAddCmd(templatesDir, t.TempDir(), cfg),
+		"docs", "--template", "work", "--url", "https://example.com/mcp", "--tool", "copilot,cursor")
+	if err != nil {
+		t.Fatalf("mcp add failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "  .cursor/mcp.json (cursor)\n") {
+		t.Errorf("expected --tool to create .cursor/mcp.json, got:\n%s", output)
+	}
+
+	output, err = executeMCPCmd(t, NewMCPListCmd(templatesDir, t.TempDir()), "--template", "work")
+	if err != nil {
+		t.Fatalf("mcp list failed: %v\n%s", err, output)
+	}
+	wantList := "MCP servers in template 'work':\n" +
+		"  github           stdio npx -y server-github\n" +
+		"      in: .vscode/mcp.json, .mcp.json, .gemini/settings.json\n" +
+		"      missing in: .cursor/mcp.json\n" +
+		"  docs             http  https://example.com/mcp\n" +
+		"      in: .vscode/mcp.json, .cursor/mcp.json\n" +
+		"      missing in: .mcp.json, .gemini/settings.json\n"
+	if output != wantList {
+		t.Errorf("list output = %q, want %q", output, wantList)
+	}
+
+	// Adding the server again propagates it to the new cursor file, then
+	// changes nothing
+	githubArgs := []string{"github", "--template", "work", "-e", "TOKEN=${TOKEN}", "--", "npx", "-y", "server-github"}
+	output, err = executeMCPCmd(t, NewMCPAddCmd(templatesDir, t.TempDir(), cfg), githubArgs...)
+	if err != nil || !strings.HasSuffix(output, "Added server 'github' in template 'work':\n  .cursor/mcp.json (cursor)\n") {
+		t.Errorf("expected github to be added to .cursor/mcp.json, got %v\n%s", err, output)
+	}
+	output, err = executeMCPCmd(t, NewMCPAddCmd(templatesDir, t.TempDir(), cfg), githubArgs...)
+	if err != nil || !strings.Contains(output, "Server 'github' is up to date in template 'work'.") {
+		t.Errorf("expected no changes, got %v\n%s", err, output)
+	}
+
+	output, err = executeMCPCmd(t, NewMCPRemoveCmd(templatesDir, t.TempDir()), "github", "--template", "work")
+	if err != nil {
+		t.Fatalf("mcp remove failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Removed server 'github' in template 'work':\n  .vscode/mcp.json (copilot)\n  .cursor/mcp.json (cursor)\n  .mcp.json (claude)\n  .gemini/settings.json (gemini)\n") {
+		t.Errorf("unexpected remove output:\n%s", output)
+	}
+	_, err = executeMCPCmd(t, NewMCPRemoveCmd(templatesDir, t.TempDir()), "github", "--template", "work")
+	if err == nil || !strings.Contains(err.Error(), "server 'github' not found in template 'work'") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestMCPAddCommentedFile(t *testing.T) {
+	commented := "{\n  // Project servers\n  \"servers\": {}\n}\n"
+	workDir := setupTestSourceDir(t, map[string]string{
+		".vscode/mcp.json": commented,
+		".cursor/mcp.json": `{"mcpServers": {}}`,
+	})
+	_, err := executeMCPCmd(t, NewMCPAddCmd(t.TempDir(), workDir, testConfig()), "docs", "--url", "https://example.com/mcp")
+	if err == nil || !strings.Contains(err.Error(), ".vscode/mcp.json: the file has comments") {
+		t.Fatalf("expected an error about comments, got %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(workDir, ".vscode", "mcp.json"))
+	if string(data) != commented {
+		t.Errorf(".vscode/mcp.json = %s, want it unchanged", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(workDir, ".cursor", "mcp.json"))
+	if string(data) != `{"mcpServers": {}}` {
+		t.Errorf(".cursor/mcp.json = %s, want it unchanged", data)
+	}
+}
+
+func TestMCPListDiffers(t *testing.T) {
+	workDir := setupTestSourceDir(t, map[string]string{
+		".vscode/mcp.json": `{"servers": {"github": {"type": "stdio", "command": "npx", "args": ["server-github"]}}}`,
+		".mcp.json":        `{"mcpServers": {"github": {"command": "npx", "args": ["server-github@2"]}}}`,
+	})
+	output, err := executeMCPCmd(t, NewMCPListCmd(t.TempDir(), workDir))
+	if err != nil {
+		t.Fatalf("mcp list failed: %v", err)
+	}
+	if !strings.Contains(output, "MCP servers in the current directory:") || !strings.Contains(output, "      defined differently in: .mcp.json\n") {
+		t.Errorf("expected the difference to be reported, got:\n%s", output)
+	}
+
+	output, err = executeMCPCmd(t, NewMCPListCmd(t.TempDir(), t.TempDir()))
+	if err != nil || output != "No MCP config files in the current directory.\n" {
+		t.Errorf("list without files = %q, %v", output, err)
+	}
+}
+
+func TestMCPAddErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no command or url", []string{"github"}, "a stdio server needs a command"},
+		{"env for remote", []string{"docs", "--url", "https://x", "-e", "A=1"}, "has no command, args or env"},
+		{"bad env", []string{"github", "-e", "TOKEN", "npx"}, "--env: invalid \"TOKEN\""},
+		{"unknown tool", []string{"github", "--tool", "vim", "npx"}, `unknown tool "vim"`},
+		{"no files", []string{"github", "npx"}, "no MCP config files in the current directory"},
+		{"missing template", []string{"github", "--template", "nope", "npx"}, "template \"nope\" not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeMCPCmd(t, NewMCPAddCmd(t.TempDir(), t.TempDir(), &config.Config{}), tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -10,12 +10,13 @@ import (
 	"github.com/openjny/dotgh/internal/config"
 	"github.com/openjny/dotgh/internal/diff"
 	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/openjny/dotgh/internal/sync"
 	"github.com/spf13/cobra"
 )
 
 // Command metadata constants
 const (
-	pullCmdUse   = "pull <template>"
+	pullCmdUse   = "pull [template][@rev]"
 	pullCmdShort = "Pull a template to the current directory"
 	pullCmdLong  = `Pull a template to the current directory with Git-style sync behavior.
 
@@ -24,35 +25,48 @@ deletes files that exist locally but not in the template.
 
 Use --merge to only add and update files without deleting.
 Use --yes to skip the confirmation prompt.
+Use --dry-run to print the changes without applying them (--dry-run=json
+for JSON).
+Use <template>@<rev> to pull a revision from 'dotgh history'.
+The template can be omitted if a .dotgh.yaml project config file sets one.
+
+If the template has a rules source (.dotgh/rules), the instruction files of
+the tools that the includes cover, such as AGENTS.md and CLAUDE.md, are
+generated from it.
 
 Examples:
   dotgh pull my-template          # Full sync with confirmation
   dotgh pull my-template --yes    # Full sync without confirmation  
-  dotgh pull my-template --merge  # Merge only (no deletions)`
+  dotgh pull my-template --merge  # Merge only (no deletions)
+  dotgh pull my-template --dry-run  # Show the changes only
+  dotgh pull my-template@3        # Pull revision 3 of the template`
 )
 
 var pullCmd = &cobra.Command{
 	Use:   pullCmdUse,
 	Short: pullCmdShort,
 	Long:  pullCmdLong,
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runPull,
 }
 
 var (
-	pullMergeFlag bool
-	pullYesFlag   bool
+	pullMergeFlag  bool
+	pullYesFlag    bool
+	pullDryRunFlag string
 )
 
 func init() {
 	pullCmd.Flags().BoolVarP(&pullMergeFlag, "merge", "m", false, "Merge mode: only add/update files, no deletions")
 	pullCmd.Flags().BoolVarP(&pullYesFlag, "yes", "y", false, "Skip confirmation prompt")
+	addDryRunFlag(pullCmd.Flags(), &pullDryRunFlag)
 }
 
 // PullOptions contains options for the pull command.
 type PullOptions struct {
 	MergeMode bool
 	Yes       bool
+	DryRun    string // Output format of the plan, or empty to apply it
 	Stdin     io.Reader
 }
 
@@ -72,15 +86,17 @@ func NewPullCmdWithConfig(customTemplatesDir, customTargetDir string, cfg *confi
 // This is primarily used for testing with custom stdin.
 func NewPullCmdWithOptions(customTemplatesDir, customTargetDir string, cfg *config.Config, defaultOpts *PullOptions) *cobra.Command {
 	var merge, yes bool
+	var dryRun string
 	cmd := &cobra.Command{
 		Use:   pullCmdUse,
 		Short: pullCmdShort,
 		Long:  pullCmdLong,
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts := PullOptions{
 				MergeMode: merge,
 				Yes:       yes,
+				DryRun:    dryRun,
 				Stdin:     cmd.InOrStdin(),
 			}
 			if defaultOpts != nil {
@@ -88,11 +104,16 @@ func NewPullCmdWithOptions(customTemplatesDir, customTargetDir string, cfg *conf
 					opts.Stdin = defaultOpts.Stdin
 				}
 			}
-			return pullTemplate(cmd, args[0], customTemplatesDir, customTargetDir, opts, cfg)
+			templateName, err := templateArg(args, cfg)
+			if err != nil {
+				return err
+			}
+			return pullTemplate(cmd, templateName, customTemplatesDir, customTargetDir, opts, cfg)
 		},
 	}
 	cmd.Flags().BoolVarP(&merge, "merge", "m", false, "Merge mode: only add/update files, no deletions")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
+	addDryRunFlag(cmd.Flags(), &dryRun)
 	return cmd
 }
 
@@ -101,9 +122,12 @@ func runPull(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("get current directory: %w", err)
 	}
+	if pullDryRunFlag == "" {
+		pullIfStale(cmd, config.GetConfigDir())
+	}
 
 	// Load config to get templates directory
-	cfg, err := config.Load()
+	cfg, err := config.Resolve("", cwd)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -111,16 +135,40 @@ func runPull(cmd *cobra.Command, args []string) error {
 	opts := PullOptions{
 		MergeMode: pullMergeFlag,
 		Yes:       pullYesFlag,
+		DryRun:    pullDryRunFlag,
 		Stdin:     cmd.InOrStdin(),
 	}
 
-	return pullTemplate(cmd, args[0], cfg.GetTemplatesDir(), cwd, opts, cfg)
+	templateName, err := templateArg(args, cfg)
+	if err != nil {
+		return err
+	}
+	return pullTemplate(cmd, templateName, cfg.GetTemplatesDir(), cwd, opts, cfg)
+}
+
+// templateArg returns the template named on the command line, or the default
+// template set in the config (usually by a project config file).
+func templateArg(args []string, cfg *config.Config) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if cfg != nil && cfg.Template != "" {
+		return cfg.Template, nil
+	}
+	return "", fmt.Errorf("no template given (name one, or set 'template' in %s)", config.ProjectFileName)
 }
 
 // pullTemplate pulls the specified template to the target directory.
 func pullTemplate(cmd *cobra.Command, templateName, templatesDir, targetDir string, opts PullOptions, cfg *config.Config) error {
 	w := cmd.OutOrStdout()
-	templatePath := filepath.Join(templatesDir, templateName)
+	if err := checkDryRun(opts.DryRun); err != nil {
+		return err
+	}
+	_, templatePath, cleanup, err := resolveTemplateRef(templatesDir, templateName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
 	// Check if template exists
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
@@ -129,18 +177,41 @@ func pullTemplate(cmd *cobra.Command, templateName, templatesDir, targetDir stri
 
 	// Load config if not provided
 	if cfg == nil {
-		var err error
-		cfg, err = config.Load()
+		cfg, err = config.Resolve("", targetDir)
 		if err != nil {
 			return fmt.Errorf("load config: %w", err)
 		}
 	}
 
+	// Generate the instruction files of a template with a rules source
+	templatePath, derived, cleanupStage, err := stageTemplate(templatePath, cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanupStage()
+
 	// Compute diff
 	diffResult, err := diff.ComputeDiff(templatePath, targetDir, cfg.Includes, cfg.Excludes, opts.MergeMode)
 	if err != nil {
 		return fmt.Errorf("compute diff: %w", err)
 	}
+	locked, err := skipLocked(templatePath, diffResult)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun != "" {
+		p := &dryRunPlan{Command: "pull " + templateName}
+		p.addChanges("directory", targetDir, diffResult)
+		if len(derived) > 0 {
+			p.note("generated from the template's rules source: %s", strings.Join(derivedPaths(derived), ", "))
+		}
+		if len(locked) > 0 {
+			p.note("skipped, encrypted in the sync repository and no sync key is available: %s", strings.Join(locked, ", "))
+		}
+		return printDryRun(w, opts.DryRun, p)
+	}
+	printSkippedLocked(w, locked)
 
 	// Check if there are any changes
 	if !diffResult.HasChanges() {
@@ -155,6 +226,9 @@ func pullTemplate(cmd *cobra.Command, templateName, templatesDir, targetDir stri
 	}
 	_, _ = fmt.Fprintf(w, "Pulling template '%s' (%s):\n", templateName, mode)
 	printDiffSummary(w, diffResult)
+	if len(derived) > 0 {
+		_, _ = fmt.Fprintf(w, "Generated from the template's rules source: %s\n\n", strings.Join(derivedPaths(derived), ", "))
+	}
 
 	// Ask for confirmation unless --yes is specified
 	if !opts.Yes {
@@ -180,6 +254,49 @@ func pullTemplate(cmd *cobra.Command, templateName, templatesDir, targetDir stri
 	return nil
 }
 
+// skipLocked removes the added and modified files of srcDir that are
+// placeholders for encrypted files sync could not decrypt from result, so
+// that they never replace the real files, and returns their paths.
+func skipLocked(srcDir string, result *diff.DiffResult) ([]string, error) {
+	var locked []string
+	keep := func(changes []diff.FileChange) ([]diff.FileChange, error) {
+		var kept []diff.FileChange
+		for _, change := range changes {
+			data, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(change.Path)))
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", change.Path, err)
+			}
+			if sync.IsPlaceholder(data) {
+				locked = append(locked, change.Path)
+				continue
+			}
+			kept = append(kept, change)
+		}
+		return kept, nil
+	}
+	var err error
+	if result.Added, err = keep(result.Added); err != nil {
+		return nil, err
+	}
+	if result.Modified, err = keep(result.Modified); err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+// printSkippedLocked warns about the files skipped by skipLocked.
+func printSkippedLocked(w io.Writer, locked []string) {
+	if len(locked) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Warning: %d file(s) skipped because they are encrypted in the sync repository and no sync key is available:\n", len(locked))
+	for _, f := range locked {
+		_, _ = fmt.Fprintf(w, "  - %s\n", f)
+	}
+	_, _ = fmt.Fprintf(w, "Copy %s from another machine into the config directory or set %s, then run 'dotgh sync pull'.\n\n",
+		sync.KeyFileName, sync.KeyEnvVar)
+}
+
 // printDiffSummary prints the diff summary to the writer.
 func printDiffSummary(w io.Writer, d *diff.DiffResult) {
 	for _, change := range d.Added {
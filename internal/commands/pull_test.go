@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -283,6 +284,27 @@ func TestPullRequiresTemplateName(t *testing.T) {
 	}
 }
 
+func TestPullUsesDefaultTemplate(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "my-template", map[string]string{
+		"AGENTS.md": "# Agents",
+	})
+	targetDir := t.TempDir()
+
+	cfg := testConfig()
+	cfg.Template = "my-template"
+	cmd := NewPullCmdWithConfig(templatesDir, targetDir, cfg)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("pull command failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(targetDir, "AGENTS.md")); err != nil {
+		t.Errorf("AGENTS.md should be pulled from the default template: %v", err)
+	}
+}
+
 func TestPullWithExcludes(t *testing.T) {
 	templatesDir := setupTestTemplateWithFiles(t, "my-template", map[string]string{
 		"AGENTS.md":                       "# Agents",
@@ -376,3 +398,63 @@ func TestPullMixedChanges(t *testing.T) {
 		t.Errorf("output should show deletion, got:\n%s", output)
 	}
 }
+
+func TestPullRejectsTemplateOutsideTemplatesDir(t *testing.T) {
+	templatesDir := setupTestTemplateWithFiles(t, "my-template", map[string]string{"AGENTS.md": "# Agents"})
+	escaped := t.TempDir()
+	createTestFile(t, escaped, "AGENTS.md", "not a template")
+	if err := os.Symlink(escaped, filepath.Join(templatesDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, wantErr := range map[string]string{
+		"../" + filepath.Base(escaped): "invalid template name",
+		escaped:                        "invalid template name",
+		"escape":                       "is outside the templates directory",
+	} {
+		targetDir := t.TempDir()
+		_, err := executePullCmd(t, templatesDir, targetDir, name, false, true, nil, "")
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("pull %q: error = %v, want %q", name, err, wantErr)
+		}
+		if _, err := os.Stat(filepath.Join(targetDir, "AGENTS.md")); !os.IsNotExist(err) {
+			t.Errorf("pull %q should not write files, got %v", name, err)
+		}
+	}
+}
+
+func TestPullSkipsEncryptedPlaceholders(t *testing.T) {
+	placeholder := "dotgh: encrypted file (.vscode/mcp.json)\nThis file is encrypted in the sync repository and no key is available on this machine.\n"
+	templatesDir := setupTestTemplateWithFiles(t, "my-template", map[string]string{
+		"AGENTS.md":        "# Agents",
+		".vscode/mcp.json": placeholder,
+	})
+	targetDir := t.TempDir()
+	createTestFile(t, targetDir, ".vscode/mcp.json", `{"servers": {}}`)
+
+	output, err := executeDiffCmd(t, templatesDir, targetDir, "my-template", false, false, nil)
+	if !errors.Is(err, ErrDiffFound) {
+		t.Fatalf("diff error = %v, want ErrDiffFound", err)
+	}
+	if strings.Contains(output, "M .vscode/mcp.json") || !strings.Contains(output, "1 file(s) skipped") {
+		t.Errorf("diff should skip the placeholder, got:\n%s", output)
+	}
+
+	output, err = executePullCmd(t, templatesDir, targetDir, "my-template", false, true, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "1 file(s) skipped") || !strings.Contains(output, "  - .vscode/mcp.json") {
+		t.Errorf("output should warn about the placeholder, got:\n%s", output)
+	}
+	content, err := os.ReadFile(filepath.Join(targetDir, ".vscode", "mcp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"servers": {}}` {
+		t.Errorf("placeholder should not replace the local file, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "AGENTS.md")); err != nil {
+		t.Errorf("other files should still be pulled: %v", err)
+	}
+}
@@ -1,20 +1,26 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/convert"
 	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/history"
 	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/openjny/dotgh/internal/secret"
 	"github.com/spf13/cobra"
 )
 
 // Command metadata constants for push
 const (
-	pushCmdUse   = "push <template>"
+	pushCmdUse   = "push [template]"
 	pushCmdShort = "Save the current directory's settings as a template"
 	pushCmdLong  = `Save the current directory's settings as a template with Git-style sync behavior.
 
@@ -23,38 +29,64 @@ deletes files in the template that don't exist locally.
 
 Use --merge to only add and update files without deleting.
 Use --yes to skip the confirmation prompt.
+Use --dry-run to print the changes, including those of auto sync, without
+applying them (--dry-run=json for JSON).
 
-If the template doesn't exist, it will be created.
+Files are scanned for secrets (API tokens, private keys, high-entropy strings,
+and literal env values in MCP server configs) before they are copied.
+Use --redact-secrets to replace them with placeholders in the template, or
+--allow-secrets to skip the scan.
+
+If the template doesn't exist, it will be created. The template can be
+omitted if a .dotgh.yaml project config file sets one.
+
+A warning is shown if the current directory has files of AI coding tools
+that the includes do not cover; run 'dotgh detect' to add them.
+
+If the template has a rules source (.dotgh/rules), the instruction files
+generated from it are not pushed, and push refuses to run if they were edited
+here; edit the rules source instead.
 
 Examples:
   dotgh push my-template          # Full sync with confirmation
   dotgh push my-template --yes    # Full sync without confirmation
-  dotgh push my-template --merge  # Merge only (no deletions)`
+  dotgh push my-template --merge  # Merge only (no deletions)
+  dotgh push my-template --dry-run  # Show the changes only
+  dotgh push my-template --redact-secrets  # Replace secrets with placeholders`
 )
 
 var pushCmd = &cobra.Command{
 	Use:   pushCmdUse,
 	Short: pushCmdShort,
 	Long:  pushCmdLong,
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runPush,
 }
 
 var (
-	pushMergeFlag bool
-	pushYesFlag   bool
+	pushMergeFlag         bool
+	pushYesFlag           bool
+	pushAllowSecretsFlag  bool
+	pushRedactSecretsFlag bool
+	pushDryRunFlag        string
 )
 
 func init() {
 	pushCmd.Flags().BoolVarP(&pushMergeFlag, "merge", "m", false, "Merge mode: only add/update files, no deletions")
 	pushCmd.Flags().BoolVarP(&pushYesFlag, "yes", "y", false, "Skip confirmation prompt")
+	pushCmd.Flags().BoolVar(&pushAllowSecretsFlag, "allow-secrets", false, "Skip secret scanning")
+	pushCmd.Flags().BoolVar(&pushRedactSecretsFlag, "redact-secrets", false, "Replace detected secrets with placeholders")
+	addDryRunFlag(pushCmd.Flags(), &pushDryRunFlag)
 }
 
 // PushOptions contains options for the push command.
 type PushOptions struct {
-	MergeMode bool
-	Yes       bool
-	Stdin     io.Reader
+	MergeMode     bool
+	Yes           bool
+	AllowSecrets  bool
+	RedactSecrets bool
+	DryRun        string // Output format of the plan, or empty to apply it
+	Stdin         io.Reader
 }
 
 // NewPushCmd creates a new push command with custom directories.
@@ -72,28 +104,43 @@ func NewPushCmdWithConfig(customTemplatesDir, customSourceDir string, cfg *confi
 // NewPushCmdWithOptions creates a new push command with custom directories, config, and options.
 // This is primarily used for testing with custom stdin.
 func NewPushCmdWithOptions(customTemplatesDir, customSourceDir string, cfg *config.Config, defaultOpts *PushOptions) *cobra.Command {
-	var merge, yes bool
+	var merge, yes, allowSecrets, redactSecrets bool
+	var dryRun string
 	cmd := &cobra.Command{
 		Use:   pushCmdUse,
 		Short: pushCmdShort,
 		Long:  pushCmdLong,
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts := PushOptions{
-				MergeMode: merge,
-				Yes:       yes,
-				Stdin:     cmd.InOrStdin(),
+				MergeMode:     merge,
+				Yes:           yes,
+				AllowSecrets:  allowSecrets,
+				RedactSecrets: redactSecrets,
+				DryRun:        dryRun,
+				Stdin:         cmd.InOrStdin(),
 			}
 			if defaultOpts != nil {
 				if defaultOpts.Stdin != nil {
 					opts.Stdin = defaultOpts.Stdin
 				}
 			}
-			return pushTemplate(cmd, args[0], customTemplatesDir, customSourceDir, opts, cfg)
+			templateName, err := templateArg(args, cfg)
+			if err != nil {
+				return err
+			}
+			plan, err := pushTemplate(cmd, templateName, customTemplatesDir, customSourceDir, opts, cfg)
+			if err != nil || plan == nil {
+				return err
+			}
+			return printDryRun(cmd.OutOrStdout(), opts.DryRun, plan)
 		},
 	}
 	cmd.Flags().BoolVarP(&merge, "merge", "m", false, "Merge mode: only add/update files, no deletions")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Skip secret scanning")
+	cmd.Flags().BoolVar(&redactSecrets, "redact-secrets", false, "Replace detected secrets with placeholders")
+	addDryRunFlag(cmd.Flags(), &dryRun)
 	return cmd
 }
 
@@ -104,50 +151,120 @@ func runPush(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load config to get templates directory
-	cfg, err := config.Load()
+	cfg, err := config.Resolve("", cwd)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
 	opts := PushOptions{
-		MergeMode: pushMergeFlag,
-		Yes:       pushYesFlag,
-		Stdin:     cmd.InOrStdin(),
+		MergeMode:     pushMergeFlag,
+		Yes:           pushYesFlag,
+		AllowSecrets:  pushAllowSecretsFlag,
+		RedactSecrets: pushRedactSecretsFlag,
+		DryRun:        pushDryRunFlag,
+		Stdin:         cmd.InOrStdin(),
 	}
 
-	return pushTemplate(cmd, args[0], cfg.GetTemplatesDir(), cwd, opts, cfg)
+	templateName, err := templateArg(args, cfg)
+	if err != nil {
+		return err
+	}
+	plan, err := pushTemplate(cmd, templateName, cfg.GetTemplatesDir(), cwd, opts, cfg)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Push template '%s'", templateName)
+	if plan != nil {
+		if err := planAutoSync(cmd.Context(), plan, config.GetConfigDir(), message); err != nil {
+			return err
+		}
+		return printDryRun(cmd.OutOrStdout(), opts.DryRun, plan)
+	}
+	autoSync(cmd, config.GetConfigDir(), templateName, message)
+	return nil
 }
 
 // pushTemplate saves the current directory's target files to a template.
-func pushTemplate(cmd *cobra.Command, templateName, templatesDir, sourceDir string, opts PushOptions, cfg *config.Config) error {
+// With --dry-run, it changes nothing and returns the plan instead.
+func pushTemplate(cmd *cobra.Command, templateName, templatesDir, sourceDir string, opts PushOptions, cfg *config.Config) (*dryRunPlan, error) {
 	w := cmd.OutOrStdout()
+	if err := checkDryRun(opts.DryRun); err != nil {
+		return nil, err
+	}
+	if strings.Contains(templateName, "@") {
+		return nil, fmt.Errorf("cannot push to a template revision %q", templateName)
+	}
 	templatePath := filepath.Join(templatesDir, templateName)
 
 	// Load config if not provided
 	if cfg == nil {
 		var err error
-		cfg, err = config.Load()
+		cfg, err = config.Resolve("", sourceDir)
 		if err != nil {
-			return fmt.Errorf("load config: %w", err)
+			return nil, fmt.Errorf("load config: %w", err)
 		}
 	}
 
+	// Point out tool files that would be left out
+	if opts.DryRun == "" {
+		warnUncovered(w, sourceDir, cfg)
+	}
+
 	// Check if template exists
 	templateExists := true
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
 		templateExists = false
 	}
 
+	// Files generated from a rules source are not pushed, and must not be
+	// edited here, since the edits would be lost on the next pull
+	derived, err := derivedFiles(templatePath, cfg)
+	if err != nil {
+		return nil, err
+	}
+	edited, err := editedDerived(sourceDir, derived)
+	if err != nil {
+		return nil, err
+	}
+	if len(edited) > 0 {
+		return nil, fmt.Errorf("%s generated from the rules source of template '%s' but edited here; "+
+			"move the edits to %s in the template ('dotgh edit %s') or run 'dotgh pull %s' to regenerate them",
+			strings.Join(edited, ", "), templateName, convert.SourceDir, templateName, templateName)
+	}
+	excludes := append(slices.Clone(cfg.Excludes), derivedPaths(derived)...)
+
 	// Compute diff (source -> template)
-	diffResult, err := diff.ComputeDiff(sourceDir, templatePath, cfg.Includes, cfg.Excludes, opts.MergeMode)
+	diffResult, err := diff.ComputeDiff(sourceDir, templatePath, cfg.Includes, excludes, opts.MergeMode)
 	if err != nil {
-		return fmt.Errorf("compute diff: %w", err)
+		return nil, fmt.Errorf("compute diff: %w", err)
+	}
+
+	resolve := func(p string) string { return filepath.Join(sourceDir, filepath.FromSlash(p)) }
+	if opts.DryRun != "" {
+		p := &dryRunPlan{Command: "push " + templateName, template: templateName}
+		if msg := uncoveredWarning(sourceDir, cfg); msg != "" {
+			p.note("%s; run 'dotgh detect' to add their patterns", msg)
+		}
+		if !diffResult.HasChanges() {
+			return p, nil
+		}
+		p.addChanges(fmt.Sprintf("template '%s'", templateName), templatePath, diffResult)
+		p.Snapshots = []string{templateName}
+		if err := p.addSecrets(cfg, diffResult.ChangedPaths(), resolve, secretOptions{
+			Allow:  opts.AllowSecrets,
+			Redact: opts.RedactSecrets,
+			Yes:    opts.Yes,
+		}); err != nil {
+			return nil, err
+		}
+		p.stage = func(dir string) error { return diff.ApplyChanges(sourceDir, dir, diffResult) }
+		return p, nil
 	}
 
 	// Check if there are any changes
 	if !diffResult.HasChanges() {
 		_, _ = fmt.Fprintf(w, "Template '%s' is already in sync.\n", templateName)
-		return nil
+		return nil, nil
 	}
 
 	// Print diff summary
@@ -162,34 +279,61 @@ func pushTemplate(cmd *cobra.Command, templateName, templatesDir, sourceDir stri
 	}
 	printDiffSummary(w, diffResult)
 
+	// Share one buffered reader across prompts so input is not lost between them
+	stdin := bufio.NewReader(opts.Stdin)
+
+	// Scan files to be written for secrets
+	findings, err := checkSecrets(w, cfg, diffResult.ChangedPaths(), resolve, secretOptions{
+		Allow:  opts.AllowSecrets,
+		Redact: opts.RedactSecrets,
+		Yes:    opts.Yes,
+		Stdin:  stdin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Ask for confirmation unless --yes is specified
 	if !opts.Yes {
-		confirmed, err := prompt.Confirm("Apply these changes?", true, w, opts.Stdin)
+		confirmed, err := prompt.Confirm("Apply these changes?", true, w, stdin)
 		if err != nil {
-			return fmt.Errorf("confirmation: %w", err)
+			return nil, fmt.Errorf("confirmation: %w", err)
 		}
 		if !confirmed {
 			_, _ = fmt.Fprintln(w, "Aborted.")
-			return nil
+			return nil, nil
 		}
 	}
 
+	// Keep the current version in the local history before overwriting it
+	recordHistory(w, templatesDir, templateName, history.SourcePrePush)
+
 	// Create template directory if it doesn't exist
 	if !templateExists {
 		if err := os.MkdirAll(templatePath, 0755); err != nil {
-			return fmt.Errorf("create template directory: %w", err)
+			return nil, fmt.Errorf("create template directory: %w", err)
 		}
 	}
 
 	// Apply changes
 	if err := diff.ApplyChanges(sourceDir, templatePath, diffResult); err != nil {
-		return fmt.Errorf("apply changes: %w", err)
+		return nil, fmt.Errorf("apply changes: %w", err)
 	}
 
+	// Replace secrets in the template copies
+	if err := secret.RedactFiles(templatePath, findings); err != nil {
+		return nil, fmt.Errorf("redact secrets: %w", err)
+	}
+
+	snap := recordHistory(w, templatesDir, templateName, history.SourcePush)
+
 	// Print result
 	_, _ = fmt.Fprintln(w)
 	printApplySummary(w, diffResult)
 	_, _ = fmt.Fprintf(w, "Template saved to: %s\n", templatePath)
+	if snap != nil {
+		_, _ = fmt.Fprintf(w, "Snapshot: %s@%d\n", templateName, snap.Rev)
+	}
 
-	return nil
+	return nil, nil
 }
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -400,3 +401,93 @@ func TestPushMixedChanges(t *testing.T) {
 		t.Errorf("output should show deletion, got:\n%s", output)
 	}
 }
+
+// mcpConfigWithToken is an MCP config containing a literal env value.
+const mcpConfigWithToken = `{
+  "servers": {
+    "github": {
+      "command": "npx",
+      "env": {
+        "GITHUB_TOKEN": "literal-token-value"
+      }
+    }
+  }
+}
+`
+
+func TestPushBlocksSecrets(t *testing.T) {
+	sourceDir := setupTestSourceDir(t, map[string]string{
+		"AGENTS.md":        "# Agents",
+		".vscode/mcp.json": mcpConfigWithToken,
+	})
+	templatesDir := t.TempDir()
+
+	output, err := executePushCmd(t, templatesDir, sourceDir, "my-template", false, true, nil, "")
+
+	if !errors.Is(err, ErrSecretsDetected) {
+		t.Fatalf("expected ErrSecretsDetected, got: %v", err)
+	}
+	if !strings.Contains(output, ".vscode/mcp.json:6: mcp-env GITHUB_TOKEN=") {
+		t.Errorf("output should report file and line, got:\n%s", output)
+	}
+	if strings.Contains(output, "literal-token-value") {
+		t.Errorf("output should not contain the secret, got:\n%s", output)
+	}
+	if _, err := os.Stat(filepath.Join(templatesDir, "my-template")); !os.IsNotExist(err) {
+		t.Error("template should not be created when secrets are blocked")
+	}
+}
+
+func TestPushRedactsSecrets(t *testing.T) {
+	sourceDir := setupTestSourceDir(t, map[string]string{
+		".vscode/mcp.json": mcpConfigWithToken,
+	})
+	templatesDir := t.TempDir()
+
+	// Answer "y" to the redaction prompt and the apply prompt
+	_, err := executePushCmd(t, templatesDir, sourceDir, "my-template", false, false, nil, "y\ny\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(templatesDir, "my-template", ".vscode", "mcp.json"))
+	if err != nil {
+		t.Fatalf("failed to read template file: %v", err)
+	}
+	if strings.Contains(string(content), "literal-token-value") {
+		t.Errorf("template should not contain the secret, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "${input:github-token}") {
+		t.Errorf("template should contain an input placeholder, got:\n%s", content)
+	}
+
+	// The source file must be left untouched
+	source, _ := os.ReadFile(filepath.Join(sourceDir, ".vscode", "mcp.json"))
+	if string(source) != mcpConfigWithToken {
+		t.Error("source file should not be modified")
+	}
+}
+
+func TestPushSecretsWarnMode(t *testing.T) {
+	sourceDir := setupTestSourceDir(t, map[string]string{
+		".vscode/mcp.json": mcpConfigWithToken,
+	})
+	templatesDir := t.TempDir()
+
+	cfg := testConfig()
+	cfg.Secrets.Mode = config.SecretsModeWarn
+	cmd := NewPushCmdWithConfig(templatesDir, sourceDir, cfg)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"my-template", "--yes"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Potential secrets detected") {
+		t.Errorf("output should warn about secrets, got:\n%s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(templatesDir, "my-template", ".vscode", "mcp.json")); err != nil {
+		t.Errorf("file should be pushed in warn mode: %v", err)
+	}
+}
@@ -1,15 +1,45 @@
 package commands
 
 import (
+	"github.com/openjny/dotgh/internal/config"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// rootConfigFile is the config file selected with --config.
+	rootConfigFile string
+	// rootProfile is the config profile selected with --profile.
+	rootProfile string
+	// rootConfigProfile is the config profile selected with
+	// --config-profile, which sync commands take along with their own
+	// --profile.
+	rootConfigProfile string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "dotgh",
 	Short: "A CLI tool to manage AI coding guidelines and templates",
 	Long: `dotgh is a cross-platform CLI tool that allows you to easily apply,
 update, and manage AI coding guidelines and configuration templates
-across multiple projects.`,
+across multiple projects.
+
+Use --profile <name> or DOTGH_PROFILE=<name> to apply a config profile
+(see 'dotgh config profiles'). Under 'dotgh sync', --profile selects a sync
+profile instead, so use --config-profile <name> or DOTGH_PROFILE there.
+
+Settings are resolved from, in increasing order of precedence: the defaults,
+the config file (--config, or config.yaml in the config directory, which
+DOTGH_CONFIG_DIR overrides), the selected profile, the project .dotgh.yaml,
+and the DOTGH_EDITOR, DOTGH_TEMPLATES_DIR, DOTGH_INCLUDES and DOTGH_EXCLUDES
+environment variables.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		config.SetConfigFile(rootConfigFile)
+		profile := rootProfile
+		if rootConfigProfile != "" {
+			profile = rootConfigProfile
+		}
+		config.SetProfile(profile)
+	},
 }
 
 // Execute runs the root command.
@@ -18,12 +48,20 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&rootConfigFile, "config", "", "Config file to use (default: config.yaml in $"+config.ConfigDirEnvVar+" or the user config directory)")
+	rootCmd.PersistentFlags().StringVar(&rootProfile, "profile", "", "Config profile to apply (default: $"+config.ProfileEnvVar+")")
+	rootCmd.PersistentFlags().StringVar(&rootConfigProfile, "config-profile", "", "Config profile to apply, for commands whose --profile selects a sync profile")
+
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(detectCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(historyCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(configCmd)
@@ -1,7 +1,13 @@
 package commands
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/openjny/dotgh/internal/config"
 )
 
 func TestExecute(t *testing.T) {
@@ -24,3 +30,31 @@ func TestRootCmdHasListSubcommand(t *testing.T) {
 		t.Error("root command should have 'list' subcommand")
 	}
 }
+
+func TestRootProfileAndSyncProfile(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnvVar, configDir)
+	t.Setenv(config.ProfileEnvVar, "")
+	configYAML := "includes:\n  - AGENTS.md\nprofiles:\n  claude:\n    includes:\n      - CLAUDE.md\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		rootProfile, rootConfigProfile, syncPushProfile = "", "", ""
+		config.SetProfile("")
+		rootCmd.SetArgs(nil)
+	})
+
+	// Under sync, --profile selects the sync profile and --config-profile
+	// the config profile
+	rootCmd.SetArgs([]string{"sync", "push", "--config-profile", "claude", "--profile", "work"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "dotgh sync init <repository> --profile work") {
+		t.Errorf("Execute() error = %v, want the uninitialized sync profile 'work'", err)
+	}
+	if got := config.SelectedProfile(); got != "claude" {
+		t.Errorf("SelectedProfile() = %q, want %q", got, "claude")
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/openjny/dotgh/internal/secret"
+)
+
+// ErrSecretsDetected is returned when secret scanning blocks an operation.
+var ErrSecretsDetected = errors.New("secrets detected")
+
+// secretOptions controls how detected secrets are handled.
+type secretOptions struct {
+	Allow  bool // Skip scanning entirely (--allow-secrets)
+	Redact bool // Replace secrets with placeholders (--redact-secrets)
+	Yes    bool // Non-interactive: never prompt
+	Stdin  io.Reader
+}
+
+// checkSecrets scans the given files and reports any findings. paths are the
+// relative paths used for reporting and redaction; resolve maps each one to
+// the file to read. It returns the findings that should be redacted in the
+// destination after the changes are applied, or ErrSecretsDetected if the
+// operation must stop.
+func checkSecrets(w io.Writer, cfg *config.Config, paths []string, resolve func(string) string, opts secretOptions) ([]secret.Finding, error) {
+	mode := cfg.Secrets.GetMode()
+	findings, err := scanSecrets(cfg, paths, resolve, opts.Allow)
+	if err != nil || len(findings) == 0 {
+		return nil, err
+	}
+
+	_, _ = fmt.Fprintln(w, "Potential secrets detected:")
+	for _, f := range findings {
+		_, _ = fmt.Fprintf(w, "  %s\n", f)
+	}
+	_, _ = fmt.Fprintln(w)
+
+	if opts.Redact {
+		_, _ = fmt.Fprintln(w, "Secrets will be replaced with placeholders.")
+		return findings, nil
+	}
+
+	if mode == config.SecretsModeWarn {
+		_, _ = fmt.Fprintln(w, "Warning: continuing because secrets.mode is 'warn'.")
+		return nil, nil
+	}
+
+	if !opts.Yes {
+		confirmed, err := prompt.Confirm("Replace secrets with placeholders?", true, w, opts.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("confirmation: %w", err)
+		}
+		if confirmed {
+			return findings, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: use --redact-secrets to replace them, --allow-secrets to ignore them, or add them to secrets.allowlist", ErrSecretsDetected)
+}
+
+// scanSecrets scans the given files like checkSecrets, without reporting the
+// findings. Nothing is scanned if allow is set or secrets.mode is "off".
+func scanSecrets(cfg *config.Config, paths []string, resolve func(string) string, allow bool) ([]secret.Finding, error) {
+	if allow || cfg.Secrets.GetMode() == config.SecretsModeOff || len(paths) == 0 {
+		return nil, nil
+	}
+
+	scanner, err := secret.NewScanner(cfg.Secrets.Allowlist, cfg.Secrets.AllowPaths)
+	if err != nil {
+		return nil, fmt.Errorf("secret scanner: %w", err)
+	}
+
+	var findings []secret.Finding
+	for _, p := range paths {
+		found, err := scanner.ScanFile(resolve(p), p)
+		if err != nil {
+			return nil, fmt.Errorf("scan for secrets: %w", err)
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
@@ -1,7 +1,12 @@
 package commands
 
 import (
+	"fmt"
+	"path/filepath"
+	"time"
+
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/sync"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +21,13 @@ of your dotgh configuration synchronization.
 Use 'dotgh sync init <repo>' to set up synchronization with a Git repository.
 Use 'dotgh sync push' to push local changes to the remote repository.
 Use 'dotgh sync pull' to pull changes from the remote repository.
-Use 'dotgh sync status' to check the current sync status.`,
+Use 'dotgh sync status' to check the current sync status.
+Use 'dotgh sync resolve' to resolve conflicts after a pull.
+Use 'dotgh sync log' and 'dotgh sync restore' to recover earlier versions.
+Use 'dotgh sync keygen' to create a key for encrypted files.
+
+Use --profile <name> with init, push, pull, status and resolve to sync a
+separate set of templates with another repository (e.g. work and personal).`,
 }
 
 func init() {
@@ -24,6 +35,10 @@ func init() {
 	syncCmd.AddCommand(syncStatusCmd)
 	syncCmd.AddCommand(syncPushCmd)
 	syncCmd.AddCommand(syncPullCmd)
+	syncCmd.AddCommand(syncResolveCmd)
+	syncCmd.AddCommand(syncLogCmd)
+	syncCmd.AddCommand(syncRestoreCmd)
+	syncCmd.AddCommand(syncKeygenCmd)
 }
 
 // NewSyncCmd creates a new sync command for testing.
@@ -43,13 +58,82 @@ of your dotgh configuration synchronization.
 Use 'dotgh sync init <repo>' to set up synchronization with a Git repository.
 Use 'dotgh sync push' to push local changes to the remote repository.
 Use 'dotgh sync pull' to pull changes from the remote repository.
-Use 'dotgh sync status' to check the current sync status.`,
+Use 'dotgh sync status' to check the current sync status.
+Use 'dotgh sync resolve' to resolve conflicts after a pull.
+Use 'dotgh sync log' and 'dotgh sync restore' to recover earlier versions.
+Use 'dotgh sync keygen' to create a key for encrypted files.
+
+Use --profile <name> with init, push, pull, status and resolve to sync a
+separate set of templates with another repository (e.g. work and personal).`,
 	}
 
 	cmd.AddCommand(NewSyncInitCmd(configDir))
 	cmd.AddCommand(NewSyncStatusCmd(configDir))
 	cmd.AddCommand(NewSyncPushCmd(configDir))
 	cmd.AddCommand(NewSyncPullCmd(configDir))
+	cmd.AddCommand(NewSyncResolveCmd(configDir))
+	cmd.AddCommand(NewSyncLogCmd(configDir))
+	cmd.AddCommand(NewSyncRestoreCmd(configDir))
+	cmd.AddCommand(NewSyncKeygenCmd(configDir))
 
 	return cmd
 }
+
+// newSyncManager creates a sync manager for a sync profile in configDir with
+// the templates directory and encryption settings taken from the config file
+// and the sync key. An empty profile selects the default profile.
+func newSyncManager(configDir, profile string) (*sync.Manager, *config.Config, error) {
+	if profile != "" {
+		if err := sync.ValidateProfileName(profile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cfg, err := config.Resolve(configDir, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+
+	key, err := sync.LoadKey(configDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load sync key: %w", err)
+	}
+
+	manager := sync.NewProfileManager(configDir, profile)
+	manager.SetConfigFile(config.ConfigFilePath(configDir))
+	if err := manager.SetGitBackend(cfg.Sync.GitBackend); err != nil {
+		return nil, nil, fmt.Errorf("sync.git_backend: %w", err)
+	}
+	if cfg.Sync.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Sync.Timeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sync.timeout: %w", err)
+		}
+		manager.SetTimeout(timeout)
+	}
+	if profile == "" {
+		if err := manager.SetTemplateFilter(cfg.Sync.Templates.Include, cfg.Sync.Templates.Exclude); err != nil {
+			return nil, nil, fmt.Errorf("sync.templates: %w", err)
+		}
+		manager.SetLocalKeys(cfg.Sync.LocalKeys)
+	} else {
+		templates := cfg.Sync.Profiles[profile].Templates
+		if err := manager.SetTemplateFilter(templates.Include, templates.Exclude); err != nil {
+			return nil, nil, fmt.Errorf("sync.profiles.%s.templates: %w", profile, err)
+		}
+	}
+	if cfg.TemplatesDir != "" {
+		manager.SetTemplatesDir(filepath.Join(cfg.GetTemplatesDir(), profile))
+	}
+	manager.SetEncryption(cfg.Sync.Encrypt, key)
+	return manager, cfg, nil
+}
+
+// syncCommand returns the 'dotgh sync' command line running sub for the
+// profile of manager, for use in hints.
+func syncCommand(manager *sync.Manager, sub string) string {
+	if profile := manager.Profile(); profile != "" {
+		return "dotgh sync " + sub + " --profile " + profile
+	}
+	return "dotgh sync " + sub
+}
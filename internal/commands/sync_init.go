@@ -2,14 +2,17 @@ package commands
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/openjny/dotgh/internal/config"
 	"github.com/openjny/dotgh/internal/git"
-	"github.com/openjny/dotgh/internal/sync"
 	"github.com/spf13/cobra"
 )
 
-var syncInitBranch string
+var (
+	syncInitBranch  string
+	syncInitProfile string
+)
 
 var syncInitCmd = &cobra.Command{
 	Use:   "init <repository>",
@@ -19,16 +22,23 @@ var syncInitCmd = &cobra.Command{
 The repository will be cloned to store your dotgh configuration and templates.
 If the repository is empty, it will be initialized with a README file.
 
+With --profile, a named sync profile is set up with its own repository and
+branch. Its templates live in the '<profile>' directory of the templates
+directory and are listed as '<profile>/<template>'; they are synced with
+'dotgh sync push --profile <profile>' and never by the default profile.
+
 Examples:
   dotgh sync init git@github.com:user/dotgh-sync.git
   dotgh sync init https://github.com/user/dotgh-sync.git
-  dotgh sync init git@github.com:user/dotgh-sync.git --branch main`,
+  dotgh sync init git@github.com:user/dotgh-sync.git --branch main
+  dotgh sync init git@github.com:corp/dotgh-work.git --profile work`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSyncInit,
 }
 
 func init() {
 	syncInitCmd.Flags().StringVarP(&syncInitBranch, "branch", "b", "main", "Branch to use for sync")
+	syncInitCmd.Flags().StringVar(&syncInitProfile, "profile", "", "Name of the sync profile to set up")
 }
 
 func runSyncInit(cmd *cobra.Command, args []string) error {
@@ -37,24 +47,39 @@ func runSyncInit(cmd *cobra.Command, args []string) error {
 
 func runSyncInitWithDir(cmd *cobra.Command, args []string, configDir string) error {
 	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
 	repoURL := args[0]
 	branch := syncInitBranch
 
-	// Check if git is installed
-	if !git.IsGitInstalled() {
-		return fmt.Errorf("git is not installed or not in PATH")
+	// Create sync manager
+	manager, cfg, err := newSyncManager(configDir, syncInitProfile)
+	if err != nil {
+		return err
 	}
 
-	// Create sync manager
-	manager := sync.NewManager(configDir)
+	// The default backend needs the git binary
+	if cfg.Sync.GitBackend != git.BackendGoGit && !git.IsGitInstalled() {
+		return fmt.Errorf("git is not installed or not in PATH (or set sync.git_backend to %q)", git.BackendGoGit)
+	}
 
 	// Check if already initialized
 	if manager.IsInitialized() {
 		return fmt.Errorf("sync is already initialized at %s", manager.SyncDirPath())
 	}
 
+	// The profile namespace must not take over an existing template, which
+	// holds files; a namespace holds template directories only
+	if profile := manager.Profile(); profile != "" {
+		entries, _ := os.ReadDir(manager.TemplatesDir())
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				return fmt.Errorf("a template named '%s' already exists; choose another profile name", profile)
+			}
+		}
+	}
+
 	// Initialize sync
-	if err := manager.Initialize(repoURL, branch); err != nil {
+	if err := manager.Initialize(ctx, repoURL, branch); err != nil {
 		return fmt.Errorf("initialize sync: %w", err)
 	}
 
@@ -62,6 +87,15 @@ func runSyncInitWithDir(cmd *cobra.Command, args []string, configDir string) err
 	_, _ = fmt.Fprintf(w, "  Repository: %s\n", repoURL)
 	_, _ = fmt.Fprintf(w, "  Branch: %s\n", branch)
 	_, _ = fmt.Fprintf(w, "  Sync directory: %s\n", manager.SyncDirPath())
+	if profile := manager.Profile(); profile != "" {
+		_, _ = fmt.Fprintf(w, "  Profile: %s (templates in %s)\n", profile, manager.TemplatesDir())
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "Next steps:")
+		_, _ = fmt.Fprintf(w, "  %s    # Push the profile templates\n", syncCommand(manager, "push"))
+		_, _ = fmt.Fprintf(w, "  %s    # Pull the profile templates from remote\n", syncCommand(manager, "pull"))
+		_, _ = fmt.Fprintf(w, "  %s  # Check sync status\n", syncCommand(manager, "status"))
+		return nil
+	}
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Next steps:")
 	_, _ = fmt.Fprintln(w, "  dotgh sync push    # Push your local config and templates")
@@ -73,7 +107,7 @@ func runSyncInitWithDir(cmd *cobra.Command, args []string, configDir string) err
 
 // NewSyncInitCmd creates a new sync init command for testing.
 func NewSyncInitCmd(configDir string) *cobra.Command {
-	var branch string
+	var branch, profile string
 
 	cmd := &cobra.Command{
 		Use:   "init <repository>",
@@ -83,21 +117,28 @@ func NewSyncInitCmd(configDir string) *cobra.Command {
 The repository will be cloned to store your dotgh configuration and templates.
 If the repository is empty, it will be initialized with a README file.
 
+With --profile, a named sync profile is set up with its own repository and
+branch. Its templates live in the '<profile>' directory of the templates
+directory and are listed as '<profile>/<template>'; they are synced with
+'dotgh sync push --profile <profile>' and never by the default profile.
+
 Examples:
   dotgh sync init git@github.com:user/dotgh-sync.git
   dotgh sync init https://github.com/user/dotgh-sync.git
-  dotgh sync init git@github.com:user/dotgh-sync.git --branch main`,
+  dotgh sync init git@github.com:user/dotgh-sync.git --branch main
+  dotgh sync init git@github.com:corp/dotgh-work.git --profile work`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Temporarily set the global variable for the function
-			oldBranch := syncInitBranch
-			syncInitBranch = branch
-			defer func() { syncInitBranch = oldBranch }()
+			oldBranch, oldProfile := syncInitBranch, syncInitProfile
+			syncInitBranch, syncInitProfile = branch, profile
+			defer func() { syncInitBranch, syncInitProfile = oldBranch, oldProfile }()
 
 			return runSyncInitWithDir(cmd, args, configDir)
 		},
 	}
 
 	cmd.Flags().StringVarP(&branch, "branch", "b", "main", "Branch to use for sync")
+	cmd.Flags().StringVar(&profile, "profile", "", "Name of the sync profile to set up")
 	return cmd
 }
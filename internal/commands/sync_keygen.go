@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var syncKeygenForce bool
+
+var syncKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a key for encrypted sync files",
+	Long: `Generate a random key used to encrypt files in the sync repository.
+
+Files matching the 'sync.encrypt' patterns in config.yaml are stored encrypted
+(scrypt + AES-256-GCM) in the sync repository. The key is written to sync.key
+in the config directory and is never synced. Copy it to every machine that
+should be able to decrypt the files, or set the DOTGH_SYNC_KEY environment
+variable instead.
+
+Examples:
+  dotgh sync keygen
+  dotgh sync keygen --force   # Replace an existing key`,
+	Args: cobra.NoArgs,
+	RunE: runSyncKeygen,
+}
+
+func init() {
+	syncKeygenCmd.Flags().BoolVarP(&syncKeygenForce, "force", "f", false, "Overwrite an existing key")
+}
+
+func runSyncKeygen(cmd *cobra.Command, args []string) error {
+	return runSyncKeygenWithDir(cmd, config.GetConfigDir())
+}
+
+func runSyncKeygenWithDir(cmd *cobra.Command, configDir string) error {
+	w := cmd.OutOrStdout()
+	keyPath := filepath.Join(configDir, sync.KeyFileName)
+
+	if _, err := os.Stat(keyPath); err == nil && !syncKeygenForce {
+		return fmt.Errorf("key already exists at %s (use --force to replace it)", keyPath)
+	}
+
+	key, err := sync.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	if err := sync.WriteKey(configDir, key); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "Sync key written to: %s\n", keyPath)
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Copy this file to your other machines to decrypt encrypted files.")
+	_, _ = fmt.Fprintln(w, "Keep it out of version control.")
+
+	return nil
+}
+
+// NewSyncKeygenCmd creates a new sync keygen command for testing.
+func NewSyncKeygenCmd(configDir string) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a key for encrypted sync files",
+		Long:  syncKeygenCmd.Long,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldForce := syncKeygenForce
+			syncKeygenForce = force
+			defer func() { syncKeygenForce = oldForce }()
+
+			return runSyncKeygenWithDir(cmd, configDir)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite an existing key")
+	return cmd
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// syncLogCmdLong is the long description for the sync log command.
+const syncLogCmdLong = `Show the sync history of the config file and templates.
+
+Without arguments, lists commits that changed config.yaml or any template.
+Pass a template name to list only commits that changed that template, or
+'config' for commits that changed config.yaml.
+
+Use the revisions shown here with 'dotgh sync restore'.
+
+Examples:
+  dotgh sync log
+  dotgh sync log my-template
+  dotgh sync log config -n 5`
+
+// defaultSyncLogLimit is the default number of commits shown by sync log.
+const defaultSyncLogLimit = 20
+
+var syncLogLimit int
+
+var syncLogCmd = &cobra.Command{
+	Use:   "log [template|config]",
+	Short: "Show sync history",
+	Long:  syncLogCmdLong,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSyncLog,
+}
+
+func init() {
+	syncLogCmd.Flags().IntVarP(&syncLogLimit, "limit", "n", defaultSyncLogLimit, "Maximum number of commits to show (0 for all)")
+}
+
+func runSyncLog(cmd *cobra.Command, args []string) error {
+	return runSyncLogWithDir(cmd, args, config.GetConfigDir())
+}
+
+func runSyncLogWithDir(cmd *cobra.Command, args []string, configDir string) error {
+	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+
+	manager, _, err := newSyncManager(configDir, "")
+	if err != nil {
+		return err
+	}
+	if !manager.IsInitialized() {
+		return fmt.Errorf("sync is not initialized. Run 'dotgh sync init <repository>' first")
+	}
+
+	target := ""
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	entries, err := manager.Log(ctx, target, syncLogLimit)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		if target == "" {
+			_, _ = fmt.Fprintln(w, "No sync history.")
+		} else {
+			_, _ = fmt.Fprintf(w, "No sync history for %s.\n", target)
+		}
+		return nil
+	}
+
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(w, "%s  %s  %s\n", e.ShortHash, e.Date.Local().Format("2006-01-02 15:04"), e.Subject)
+	}
+	return nil
+}
+
+// NewSyncLogCmd creates a new sync log command for testing.
+func NewSyncLogCmd(configDir string) *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "log [template|config]",
+		Short: "Show sync history",
+		Long:  syncLogCmdLong,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Temporarily set the global variable
+			oldLimit := syncLogLimit
+			syncLogLimit = limit
+			defer func() { syncLogLimit = oldLimit }()
+
+			return runSyncLogWithDir(cmd, args, configDir)
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", defaultSyncLogLimit, "Maximum number of commits to show (0 for all)")
+	return cmd
+}
@@ -1,24 +1,73 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/git"
+	"github.com/openjny/dotgh/internal/prompt"
 	"github.com/openjny/dotgh/internal/sync"
 	"github.com/spf13/cobra"
 )
 
+// syncPullCmdLong is the long description for the sync pull command.
+const syncPullCmdLong = `Pull configuration and templates from the remote repository.
+
+This command pulls the latest changes from the remote repository and mirrors
+the config.yaml and templates into your local dotgh config directory
+(honoring 'templates_dir'). Templates deleted in the sync repository are
+deleted locally. The planned changes are shown and confirmed before they
+are applied.
+
+If the pull results in merge conflicts, nothing is copied and the conflicts
+must be resolved with 'dotgh sync resolve'.
+
+With --if-stale, the pull is skipped if the last one was more recent than the
+given duration, so it can run before other commands without slowing them down.
+
+Use --dry-run to print the changes without making them (--dry-run=json for
+JSON). The changes are computed as of the last fetch, without contacting the
+remote; add --fetch to fetch first, which updates the remote-tracking branch
+of the sync repository.
+
+With --profile, only the templates of that profile are pulled from its own
+repository.
+
+Examples:
+  dotgh sync pull
+  dotgh sync pull --yes
+  dotgh sync pull --if-stale 1h --yes
+  dotgh sync pull --dry-run
+  dotgh sync pull --dry-run --fetch
+  dotgh sync pull --profile work`
+
+var (
+	syncPullYes     bool
+	syncPullIfStale time.Duration
+	syncPullProfile string
+	syncPullDryRun  string
+	syncPullFetch   bool
+)
+
 var syncPullCmd = &cobra.Command{
 	Use:   "pull",
 	Short: "Pull config and templates from remote",
-	Long: `Pull configuration and templates from the remote repository.
-
-This command pulls the latest changes from the remote repository and
-copies the config.yaml and templates to your local dotgh config directory.
+	Long:  syncPullCmdLong,
+	RunE:  runSyncPull,
+}
 
-Examples:
-  dotgh sync pull`,
-	RunE: runSyncPull,
+func init() {
+	syncPullCmd.Flags().BoolVarP(&syncPullYes, "yes", "y", false, "Skip confirmation prompt")
+	syncPullCmd.Flags().DurationVar(&syncPullIfStale, "if-stale", 0, "Only pull if the last pull is older than this duration (e.g. 30m, 1h)")
+	syncPullCmd.Flags().StringVar(&syncPullProfile, "profile", "", "Name of the sync profile (default: the default profile)")
+	addDryRunFlag(syncPullCmd.Flags(), &syncPullDryRun)
+	syncPullCmd.Flags().BoolVar(&syncPullFetch, "fetch", false, "With --dry-run, fetch from the remote first")
 }
 
 func runSyncPull(cmd *cobra.Command, args []string) error {
@@ -27,50 +76,215 @@ func runSyncPull(cmd *cobra.Command, args []string) error {
 
 func runSyncPullWithDir(cmd *cobra.Command, configDir string) error {
 	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+	if err := checkDryRun(syncPullDryRun); err != nil {
+		return err
+	}
+	if syncPullFetch && syncPullDryRun == "" {
+		return fmt.Errorf("--fetch can only be used with --dry-run")
+	}
 
-	manager := sync.NewManager(configDir)
+	manager, _, err := newSyncManager(configDir, syncPullProfile)
+	if err != nil {
+		return err
+	}
 
 	// Check if initialized
 	if !manager.IsInitialized() {
-		return fmt.Errorf("sync is not initialized. Run 'dotgh sync init <repository>' first")
+		return fmt.Errorf("sync is not initialized. Run '%s' first", syncCommand(manager, "init <repository>"))
+	}
+
+	if syncPullIfStale > 0 {
+		if last := manager.LastPull(); !last.IsZero() && time.Since(last) < syncPullIfStale {
+			_, _ = fmt.Fprintf(w, "Already up to date (last pulled %s ago).\n", time.Since(last).Round(time.Second))
+			return nil
+		}
+	}
+
+	if syncPullDryRun != "" {
+		p, err := planSyncPull(ctx, manager, configDir, syncPullFetch)
+		if err != nil {
+			return err
+		}
+		return printDryRun(w, syncPullDryRun, p)
 	}
 
 	// Pull from remote
-	if err := manager.Pull(); err != nil {
-		// Pull might fail if no remote tracking branch exists yet, which is fine
-		_, _ = fmt.Fprintf(w, "Note: Could not pull from remote (this is normal for new repos)\n")
+	if err := manager.Pull(ctx); err != nil {
+		switch {
+		case errors.Is(err, sync.ErrUnresolvedConflicts):
+			return fmt.Errorf("%w. Run '%s' first", err, syncCommand(manager, "resolve"))
+		case errors.Is(err, git.ErrMergeConflict):
+			printConflicts(ctx, w, manager)
+			_, _ = fmt.Fprintln(w, "Local config and templates were not changed.")
+			_, _ = fmt.Fprintf(w, "Run '%s' to resolve the conflicts.\n", syncCommand(manager, "resolve"))
+			return fmt.Errorf("pull from remote: %w", git.ErrMergeConflict)
+		case errors.Is(err, git.ErrNotSupported):
+			return fmt.Errorf("local and remote changes have diverged, and the %s git backend cannot merge them. Set 'sync.git_backend' to %s (requires git) and run '%s' again",
+				git.BackendGoGit, git.BackendExec, syncCommand(manager, "pull"))
+		case errors.Is(err, git.ErrNoUpstream):
+			// The branch has not been pushed yet, which is normal for new repos
+			_, _ = fmt.Fprintf(w, "Note: Could not pull from remote (this is normal for new repos)\n")
+		default:
+			return fmt.Errorf("pull from remote: %w", err)
+		}
 	}
 
-	// Copy config and templates from sync directory to local
-	if err := manager.CopyConfigFromSync(); err != nil {
-		return fmt.Errorf("copy config: %w", err)
+	return applySyncPull(w, cmd.InOrStdin(), manager, configDir, syncPullYes)
+}
+
+// planSyncPull computes what sync pull would do, as of the last fetch or,
+// if fetch is set, after fetching the remote.
+func planSyncPull(ctx context.Context, manager *sync.Manager, configDir string, fetch bool) (*dryRunPlan, error) {
+	p := &dryRunPlan{Command: strings.TrimPrefix(syncCommand(manager, "pull"), "dotgh ")}
+	if !fetch {
+		p.note("the plan is as of the last fetch; use --fetch to fetch from the remote first")
+	} else if err := manager.Fetch(ctx); err != nil {
+		p.note("could not fetch from remote, so the plan is as of the last fetch: %v", err)
+	}
+	preview, err := manager.PreviewPull(ctx)
+	if err != nil {
+		if errors.Is(err, sync.ErrUnresolvedConflicts) {
+			return nil, fmt.Errorf("%w. Run '%s' first", err, syncCommand(manager, "resolve"))
+		}
+		return nil, fmt.Errorf("compute changes: %w", err)
+	}
+
+	switch {
+	case preview.Upstream == "":
+		p.note("the branch has no upstream yet, so nothing would be pulled")
+	case preview.Plan == nil:
+		p.note("%d remote commit(s) from %s would be merged with %d local commit(s); the changes are only known after the merge",
+			preview.Behind, preview.Upstream, preview.Ahead)
+		if len(preview.Items) > 0 {
+			p.note("changed remotely: %s", strings.Join(preview.Items, ", "))
+		}
+	case preview.Behind > 0:
+		p.note("%d commit(s) would be pulled from %s", preview.Behind, preview.Upstream)
+	}
+	if preview.Plan != nil {
+		p.addChanges("local config directory", configDir, preview.Plan)
+	}
+	if len(preview.Locked) > 0 {
+		p.note("%d encrypted file(s) could not be decrypted because no sync key is available: %s",
+			len(preview.Locked), strings.Join(preview.Locked, ", "))
 	}
+	return p, nil
+}
 
-	if err := manager.CopyTemplatesFromSync(); err != nil {
-		return fmt.Errorf("copy templates: %w", err)
+// applySyncPull mirrors the sync directory into the local config directory
+// after showing the planned changes and asking for confirmation.
+func applySyncPull(w io.Writer, stdin io.Reader, manager *sync.Manager, configDir string, yes bool) error {
+	plan, err := manager.PlanPull()
+	if err != nil {
+		return fmt.Errorf("compute changes: %w", err)
 	}
 
-	_, _ = fmt.Fprintln(w, "Pulled successfully!")
-	_, _ = fmt.Fprintf(w, "  Config directory: %s\n", configDir)
+	if !plan.HasChanges() {
+		_, _ = fmt.Fprintln(w, "Already up to date.")
+	} else {
+		confirmed, err := confirmLocalChanges(w, stdin, plan, yes)
+		if err != nil || !confirmed {
+			return err
+		}
+
+		if err := manager.ApplyPull(plan); err != nil {
+			return fmt.Errorf("copy from sync directory: %w", err)
+		}
+
+		_, _ = fmt.Fprintln(w, "Pulled successfully!")
+		_, _ = fmt.Fprintf(w, "  Config directory: %s\n", configDir)
+	}
 
+	printLockedFiles(w, manager.LockedFiles(), configDir, syncCommand(manager, "pull"))
 	return nil
 }
 
+// confirmLocalChanges shows the changes planned for the local config directory
+// and asks for confirmation unless yes is set. It prints "Aborted." and
+// returns false if the user declines.
+func confirmLocalChanges(w io.Writer, stdin io.Reader, plan *diff.DiffResult, yes bool) (bool, error) {
+	_, _ = fmt.Fprintln(w, "Changes to local config directory:")
+	printDiffSummary(w, plan)
+
+	if yes {
+		return true, nil
+	}
+	confirmed, err := prompt.Confirm("Apply these changes?", true, w, stdin)
+	if err != nil {
+		return false, fmt.Errorf("confirmation: %w", err)
+	}
+	if !confirmed {
+		_, _ = fmt.Fprintln(w, "Aborted.")
+	}
+	return confirmed, nil
+}
+
+// printLockedFiles warns about encrypted files that could not be decrypted.
+// retry is the command to run again once the key is available.
+func printLockedFiles(w io.Writer, locked []string, configDir, retry string) {
+	if len(locked) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nWarning: %d encrypted file(s) could not be decrypted because no sync key is available:\n", len(locked))
+	for _, f := range locked {
+		_, _ = fmt.Fprintf(w, "  - %s\n", f)
+	}
+	_, _ = fmt.Fprintf(w, "Copy %s from another machine into %s or set %s, then run '%s' again.\n",
+		sync.KeyFileName, configDir, sync.KeyEnvVar, retry)
+}
+
+// printConflicts lists the files with unresolved conflicts in the sync repository.
+func printConflicts(ctx context.Context, w io.Writer, manager *sync.Manager) {
+	conflicts, err := manager.Conflicts(ctx)
+	if err != nil || len(conflicts) == 0 {
+		_, _ = fmt.Fprintln(w, "Merge conflict in sync repository.")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Merge conflict in %d file(s):\n", len(conflicts))
+	for _, c := range conflicts {
+		_, _ = fmt.Fprintf(w, "  %s%s\n", c.Path, conflictNote(c))
+	}
+}
+
+// conflictNote describes a delete/modify conflict.
+func conflictNote(c sync.Conflict) string {
+	switch {
+	case c.LocalDeleted:
+		return " (deleted locally)"
+	case c.RemoteDeleted:
+		return " (deleted remotely)"
+	}
+	return ""
+}
+
 // NewSyncPullCmd creates a new sync pull command for testing.
 func NewSyncPullCmd(configDir string) *cobra.Command {
+	var yes bool
+	var ifStale time.Duration
+	var profile, dryRun string
+	var fetch bool
+
 	cmd := &cobra.Command{
 		Use:   "pull",
 		Short: "Pull config and templates from remote",
-		Long: `Pull configuration and templates from the remote repository.
-
-This command pulls the latest changes from the remote repository and
-copies the config.yaml and templates to your local dotgh config directory.
-
-Examples:
-  dotgh sync pull`,
+		Long:  syncPullCmdLong,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Temporarily set the global variables
+			oldYes, oldIfStale, oldProfile, oldDryRun, oldFetch := syncPullYes, syncPullIfStale, syncPullProfile, syncPullDryRun, syncPullFetch
+			syncPullYes, syncPullIfStale, syncPullProfile, syncPullDryRun, syncPullFetch = yes, ifStale, profile, dryRun, fetch
+			defer func() {
+				syncPullYes, syncPullIfStale, syncPullProfile, syncPullDryRun, syncPullFetch = oldYes, oldIfStale, oldProfile, oldDryRun, oldFetch
+			}()
+
 			return runSyncPullWithDir(cmd, configDir)
 		},
 	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
+	cmd.Flags().DurationVar(&ifStale, "if-stale", 0, "Only pull if the last pull is older than this duration (e.g. 30m, 1h)")
+	cmd.Flags().StringVar(&profile, "profile", "", "Name of the sync profile (default: the default profile)")
+	addDryRunFlag(cmd.Flags(), &dryRun)
+	cmd.Flags().BoolVar(&fetch, "fetch", false, "With --dry-run, fetch from the remote first")
 	return cmd
 }
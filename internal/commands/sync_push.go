@@ -1,32 +1,70 @@
 package commands
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/openjny/dotgh/internal/secret"
 	"github.com/openjny/dotgh/internal/sync"
 	"github.com/spf13/cobra"
 )
 
-var syncPushMessage string
+// syncPushCmdLong is the long description for the sync push command.
+const syncPushCmdLong = `Push local configuration and templates to the remote repository.
 
-var syncPushCmd = &cobra.Command{
-	Use:   "push",
-	Short: "Push local config and templates to remote",
-	Long: `Push local configuration and templates to the remote repository.
+This command mirrors your local config.yaml and templates directory (honoring
+'templates_dir') into the sync repository, commits the changes, and pushes to
+the remote. Templates deleted locally are deleted from the sync repository.
+The planned changes are shown and confirmed before they are applied.
+
+Changed files are scanned for secrets before they are copied. Use
+--redact-secrets to replace them with placeholders in the sync repository,
+or --allow-secrets to skip the scan.
 
-This command copies your local config.yaml and templates directory to the
-sync repository, commits the changes, and pushes to the remote.
+Use --dry-run to print the changes, the commit and the push without making
+them (--dry-run=json for JSON).
+
+With --profile, only the templates of that profile are pushed to its own
+repository.
 
 Examples:
   dotgh sync push
-  dotgh sync push -m "Update templates"`,
-	RunE: runSyncPush,
+  dotgh sync push -m "Update templates"
+  dotgh sync push --yes
+  dotgh sync push --redact-secrets
+  dotgh sync push --dry-run
+  dotgh sync push --profile work`
+
+var (
+	syncPushMessage       string
+	syncPushYes           bool
+	syncPushAllowSecrets  bool
+	syncPushRedactSecrets bool
+	syncPushProfile       string
+	syncPushDryRun        string
+)
+
+var syncPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push local config and templates to remote",
+	Long:  syncPushCmdLong,
+	RunE:  runSyncPush,
 }
 
 func init() {
 	syncPushCmd.Flags().StringVarP(&syncPushMessage, "message", "m", "", "Commit message (default: auto-generated)")
+	syncPushCmd.Flags().BoolVarP(&syncPushYes, "yes", "y", false, "Skip confirmation prompt")
+	syncPushCmd.Flags().BoolVar(&syncPushAllowSecrets, "allow-secrets", false, "Skip secret scanning")
+	syncPushCmd.Flags().BoolVar(&syncPushRedactSecrets, "redact-secrets", false, "Replace detected secrets with placeholders")
+	syncPushCmd.Flags().StringVar(&syncPushProfile, "profile", "", "Name of the sync profile (default: the default profile)")
+	addDryRunFlag(syncPushCmd.Flags(), &syncPushDryRun)
 }
 
 func runSyncPush(cmd *cobra.Command, args []string) error {
@@ -35,46 +73,117 @@ func runSyncPush(cmd *cobra.Command, args []string) error {
 
 func runSyncPushWithDir(cmd *cobra.Command, configDir string) error {
 	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+	if err := checkDryRun(syncPushDryRun); err != nil {
+		return err
+	}
 
-	manager := sync.NewManager(configDir)
+	manager, cfg, err := newSyncManager(configDir, syncPushProfile)
+	if err != nil {
+		return err
+	}
 
 	// Check if initialized
 	if !manager.IsInitialized() {
-		return fmt.Errorf("sync is not initialized. Run 'dotgh sync init <repository>' first")
+		return fmt.Errorf("sync is not initialized. Run '%s' first", syncCommand(manager, "init <repository>"))
+	}
+
+	if manager.IsMerging() {
+		return fmt.Errorf("%w. Run '%s' first", sync.ErrUnresolvedConflicts, syncCommand(manager, "resolve"))
 	}
 
-	// Copy config and templates to sync directory
-	if err := manager.CopyConfigToSync(); err != nil {
-		return fmt.Errorf("copy config: %w", err)
+	// Compute the changes to mirror into the sync directory
+	plan, err := manager.PlanPush()
+	if err != nil {
+		return fmt.Errorf("compute changes: %w", err)
+	}
+
+	if syncPushDryRun != "" {
+		p, err := planSyncPush(ctx, manager, cfg, plan)
+		if err != nil {
+			return err
+		}
+		return printDryRun(w, syncPushDryRun, p)
 	}
 
-	if err := manager.CopyTemplatesToSync(); err != nil {
-		return fmt.Errorf("copy templates: %w", err)
+	if plan.HasChanges() {
+		_, _ = fmt.Fprintln(w, "Changes to sync repository:")
+		printDiffSummary(w, plan)
+
+		// Share one buffered reader across prompts so input is not lost between them
+		stdin := bufio.NewReader(cmd.InOrStdin())
+
+		// Scan files that are about to change for secrets.
+		// Files stored encrypted are not scanned.
+		findings, err := checkSecrets(w, cfg, plaintextPaths(manager, plan), manager.LocalPath, secretOptions{
+			Allow:  syncPushAllowSecrets,
+			Redact: syncPushRedactSecrets,
+			Yes:    syncPushYes,
+			Stdin:  stdin,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !syncPushYes {
+			confirmed, err := prompt.Confirm("Apply these changes?", true, w, stdin)
+			if err != nil {
+				return fmt.Errorf("confirmation: %w", err)
+			}
+			if !confirmed {
+				_, _ = fmt.Fprintln(w, "Aborted.")
+				return nil
+			}
+		}
+
+		if err := manager.ApplyPush(plan); err != nil {
+			return fmt.Errorf("copy to sync directory: %w", err)
+		}
+
+		// Replace secrets in the sync copies
+		if err := secret.RedactFiles(manager.SyncDirPath(), findings); err != nil {
+			return fmt.Errorf("redact secrets: %w", err)
+		}
+		// Keep the local files, which still hold the secrets, from being
+		// pushed again or overwritten by the placeholders on pull
+		var redacted []string
+		for _, f := range findings {
+			if !slices.Contains(redacted, f.Path) {
+				redacted = append(redacted, f.Path)
+			}
+		}
+		if err := manager.RecordRedacted(redacted); err != nil {
+			return err
+		}
 	}
 
 	// Check if there are changes to commit
-	status, err := manager.GetSyncStatus()
+	status, err := manager.GetSyncStatus(ctx)
 	if err != nil {
 		return fmt.Errorf("get status: %w", err)
 	}
 
 	if !status.HasChanges {
+		// Commits such as a resolved merge may still need to be published
+		if ahead, _, err := manager.AheadBehind(ctx); err == nil && ahead > 0 {
+			if err := manager.Push(ctx); err != nil {
+				return fmt.Errorf("push to remote: %w", err)
+			}
+			_, _ = fmt.Fprintln(w, "Pushed successfully!")
+			_, _ = fmt.Fprintf(w, "  Commits: %d\n", ahead)
+			return nil
+		}
 		_, _ = fmt.Fprintln(w, "Nothing to push. Local config and templates are in sync.")
 		return nil
 	}
 
-	// Generate commit message if not provided
-	message := syncPushMessage
-	if message == "" {
-		message = fmt.Sprintf("Sync update: %s", time.Now().Format("2006-01-02 15:04:05"))
-	}
-
 	// Commit and push
-	if err := manager.StageAndCommit(message); err != nil {
+	message := syncPushCommitMessage()
+	if err := manager.StageAndCommit(ctx, message); err != nil {
 		return fmt.Errorf("commit changes: %w", err)
 	}
 
-	if err := manager.Push(); err != nil {
+	if err := manager.Push(ctx); err != nil {
 		return fmt.Errorf("push to remote: %w", err)
 	}
 
@@ -90,31 +199,80 @@ func runSyncPushWithDir(cmd *cobra.Command, configDir string) error {
 	return nil
 }
 
+// planSyncPush computes what sync push would do with plan, the changes to
+// mirror into the sync directory.
+func planSyncPush(ctx context.Context, manager *sync.Manager, cfg *config.Config, plan *diff.DiffResult) (*dryRunPlan, error) {
+	p := &dryRunPlan{Command: strings.TrimPrefix(syncCommand(manager, "push"), "dotgh ")}
+	p.addChanges("sync repository", manager.SyncDirPath(), plan)
+	if err := p.addSecrets(cfg, plaintextPaths(manager, plan), manager.LocalPath, secretOptions{
+		Allow:  syncPushAllowSecrets,
+		Redact: syncPushRedactSecrets,
+		Yes:    syncPushYes,
+	}); err != nil {
+		return nil, err
+	}
+
+	status, err := manager.GetSyncStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+	if plan.HasChanges() || status.HasChanges {
+		p.Commits = append(p.Commits, syncPushCommitMessage())
+		p.Push = pushTarget(ctx, manager)
+	} else if ahead, _, err := manager.AheadBehind(ctx); err == nil && ahead > 0 {
+		p.Push = pushTarget(ctx, manager)
+		p.note("%d commit(s) not pushed yet would be pushed", ahead)
+	}
+	return p, nil
+}
+
+// plaintextPaths returns the changed paths of plan that are not stored
+// encrypted, which are scanned for secrets.
+func plaintextPaths(manager *sync.Manager, plan *diff.DiffResult) []string {
+	var paths []string
+	for _, p := range plan.ChangedPaths() {
+		if !manager.IsEncryptedPath(p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// syncPushCommitMessage returns the commit message given with --message, or
+// generates one.
+func syncPushCommitMessage() string {
+	if syncPushMessage != "" {
+		return syncPushMessage
+	}
+	return fmt.Sprintf("Sync update: %s", time.Now().Format("2006-01-02 15:04:05"))
+}
+
 // NewSyncPushCmd creates a new sync push command for testing.
 func NewSyncPushCmd(configDir string) *cobra.Command {
-	var message string
+	var message, profile, dryRun string
+	var yes, allowSecrets, redactSecrets bool
 
 	cmd := &cobra.Command{
 		Use:   "push",
 		Short: "Push local config and templates to remote",
-		Long: `Push local configuration and templates to the remote repository.
-
-This command copies your local config.yaml and templates directory to the
-sync repository, commits the changes, and pushes to the remote.
-
-Examples:
-  dotgh sync push
-  dotgh sync push -m "Update templates"`,
+		Long:  syncPushCmdLong,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Temporarily set the global variable
-			oldMessage := syncPushMessage
-			syncPushMessage = message
-			defer func() { syncPushMessage = oldMessage }()
+			// Temporarily set the global variables
+			oldMessage, oldYes, oldAllow, oldRedact, oldProfile, oldDryRun := syncPushMessage, syncPushYes, syncPushAllowSecrets, syncPushRedactSecrets, syncPushProfile, syncPushDryRun
+			syncPushMessage, syncPushYes, syncPushAllowSecrets, syncPushRedactSecrets, syncPushProfile, syncPushDryRun = message, yes, allowSecrets, redactSecrets, profile, dryRun
+			defer func() {
+				syncPushMessage, syncPushYes, syncPushAllowSecrets, syncPushRedactSecrets, syncPushProfile, syncPushDryRun = oldMessage, oldYes, oldAllow, oldRedact, oldProfile, oldDryRun
+			}()
 
 			return runSyncPushWithDir(cmd, configDir)
 		},
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Commit message (default: auto-generated)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Skip secret scanning")
+	cmd.Flags().BoolVar(&redactSecrets, "redact-secrets", false, "Replace detected secrets with placeholders")
+	cmd.Flags().StringVar(&profile, "profile", "", "Name of the sync profile (default: the default profile)")
+	addDryRunFlag(cmd.Flags(), &dryRun)
 	return cmd
 }
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/git"
+	"github.com/openjny/dotgh/internal/prompt"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// syncResolveCmdLong is the long description for the sync resolve command.
+const syncResolveCmdLong = `Resolve merge conflicts left by 'dotgh sync pull'.
+
+For each conflicted file, choose which version to keep:
+  local   Keep the version from this machine
+  remote  Keep the version from the remote repository
+  edit    Open the file in your editor and resolve the conflict markers
+
+Encrypted files can only be resolved with local or remote.
+
+Once every file is resolved, the merge is committed and the result is
+copied to your local config directory. Run 'dotgh sync push' afterwards
+to publish the merge.
+
+Examples:
+  dotgh sync resolve
+  dotgh sync resolve --remote
+  dotgh sync resolve --local --yes`
+
+// defaultMergeMessage is the commit message for resolved merges.
+const defaultMergeMessage = "Merge remote changes"
+
+var (
+	syncResolveLocal   bool
+	syncResolveRemote  bool
+	syncResolveMessage string
+	syncResolveYes     bool
+	syncResolveProfile string
+)
+
+var syncResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Resolve sync merge conflicts",
+	Long:  syncResolveCmdLong,
+	RunE:  runSyncResolve,
+}
+
+func init() {
+	syncResolveCmd.Flags().BoolVar(&syncResolveLocal, "local", false, "Keep the local version of every conflicted file")
+	syncResolveCmd.Flags().BoolVar(&syncResolveRemote, "remote", false, "Keep the remote version of every conflicted file")
+	syncResolveCmd.Flags().StringVarP(&syncResolveMessage, "message", "m", "", "Merge commit message (default: \""+defaultMergeMessage+"\")")
+	syncResolveCmd.Flags().BoolVarP(&syncResolveYes, "yes", "y", false, "Skip confirmation prompt when applying the merged files")
+	syncResolveCmd.Flags().StringVar(&syncResolveProfile, "profile", "", "Name of the sync profile (default: the default profile)")
+	syncResolveCmd.MarkFlagsMutuallyExclusive("local", "remote")
+}
+
+func runSyncResolve(cmd *cobra.Command, args []string) error {
+	return runSyncResolveWithDir(cmd, config.GetConfigDir())
+}
+
+func runSyncResolveWithDir(cmd *cobra.Command, configDir string) error {
+	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+
+	manager, cfg, err := newSyncManager(configDir, syncResolveProfile)
+	if err != nil {
+		return err
+	}
+
+	// Check if initialized
+	if !manager.IsInitialized() {
+		return fmt.Errorf("sync is not initialized. Run '%s' first", syncCommand(manager, "init <repository>"))
+	}
+
+	if !manager.IsMerging() {
+		_, _ = fmt.Fprintln(w, "No conflicts to resolve.")
+		return nil
+	}
+	// go-git can neither merge nor commit a merge started by git
+	if manager.GitBackend() == git.BackendGoGit {
+		return fmt.Errorf("resolving conflicts is not supported by the %s git backend. Set 'sync.git_backend' to %s (requires git) and run '%s' again",
+			git.BackendGoGit, git.BackendExec, syncCommand(manager, "resolve"))
+	}
+
+	conflicts, err := manager.Conflicts(ctx)
+	if err != nil {
+		return err
+	}
+	printConflicts(ctx, w, manager)
+
+	// Share one buffered reader across prompts so input is not lost between them
+	stdin := bufio.NewReader(cmd.InOrStdin())
+
+	for _, c := range conflicts {
+		choice, err := resolveConflict(cmd, manager, cfg, c, stdin)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "  Resolved %s (%s)\n", c.Path, choice)
+	}
+
+	message := syncResolveMessage
+	if message == "" {
+		message = defaultMergeMessage
+	}
+	if err := manager.CompleteMerge(ctx, message); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w, "Merge completed.")
+
+	if err := applySyncPull(w, stdin, manager, configDir, syncResolveYes); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "Run '%s' to publish the merge.\n", syncCommand(manager, "push"))
+	return nil
+}
+
+// resolveConflict resolves a single conflicted file, asking the user which
+// version to keep unless --local or --remote was given. It returns the choice.
+func resolveConflict(cmd *cobra.Command, manager *sync.Manager, cfg *config.Config, c sync.Conflict, stdin *bufio.Reader) (string, error) {
+	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+
+	switch {
+	case syncResolveLocal:
+		return string(sync.ResolveLocal), manager.ResolveConflict(ctx, c, sync.ResolveLocal)
+	case syncResolveRemote:
+		return string(sync.ResolveRemote), manager.ResolveConflict(ctx, c, sync.ResolveRemote)
+	}
+
+	options := []string{string(sync.ResolveLocal), string(sync.ResolveRemote)}
+	if !manager.IsEncryptedPath(c.Path) {
+		options = append(options, "edit")
+	}
+
+	for {
+		choice, err := prompt.Select(fmt.Sprintf("Resolve %s%s", c.Path, conflictNote(c)), options, w, stdin)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", c.Path, err)
+		}
+
+		if choice != "edit" {
+			return choice, manager.ResolveConflict(ctx, c, sync.Resolution(choice))
+		}
+
+		// Open the conflicted file in the editor
+		editorArgs := buildEditorCommand(cfg.Editor, manager.ConflictPath(c))
+		execCmd := exec.Command(editorArgs[0], editorArgs[1:]...)
+		execCmd.Stdin = os.Stdin
+		execCmd.Stdout = os.Stdout
+		execCmd.Stderr = os.Stderr
+		if err := execCmd.Run(); err != nil {
+			return "", fmt.Errorf("run editor: %w", err)
+		}
+
+		err = manager.MarkResolved(ctx, c)
+		if errors.Is(err, sync.ErrConflictMarkers) {
+			_, _ = fmt.Fprintf(w, "%s still contains conflict markers.\n", c.Path)
+			continue
+		}
+		return choice, err
+	}
+}
+
+// NewSyncResolveCmd creates a new sync resolve command for testing.
+func NewSyncResolveCmd(configDir string) *cobra.Command {
+	var local, remote, yes bool
+	var message, profile string
+
+	cmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Resolve sync merge conflicts",
+		Long:  syncResolveCmdLong,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Temporarily set the global variables
+			oldLocal, oldRemote, oldMessage, oldYes, oldProfile := syncResolveLocal, syncResolveRemote, syncResolveMessage, syncResolveYes, syncResolveProfile
+			syncResolveLocal, syncResolveRemote, syncResolveMessage, syncResolveYes, syncResolveProfile = local, remote, message, yes, profile
+			defer func() {
+				syncResolveLocal, syncResolveRemote, syncResolveMessage, syncResolveYes, syncResolveProfile = oldLocal, oldRemote, oldMessage, oldYes, oldProfile
+			}()
+
+			return runSyncResolveWithDir(cmd, configDir)
+		},
+	}
+
+	cmd.Flags().BoolVar(&local, "local", false, "Keep the local version of every conflicted file")
+	cmd.Flags().BoolVar(&remote, "remote", false, "Keep the remote version of every conflicted file")
+	cmd.Flags().StringVarP(&message, "message", "m", "", "Merge commit message (default: \""+defaultMergeMessage+"\")")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt when applying the merged files")
+	cmd.Flags().StringVar(&profile, "profile", "", "Name of the sync profile (default: the default profile)")
+	cmd.MarkFlagsMutuallyExclusive("local", "remote")
+	return cmd
+}
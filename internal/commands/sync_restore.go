@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// syncRestoreCmdLong is the long description for the sync restore command.
+const syncRestoreCmdLong = `Restore config and templates from a previous sync snapshot.
+
+Copies the files recorded at <rev> back into your local config directory.
+Pass a template name to restore only that template, or 'config' to restore
+only config.yaml. Without a target, config.yaml and all templates are
+restored, and templates that did not exist at <rev> are deleted.
+
+The planned changes are shown and confirmed before they are applied.
+Run 'dotgh sync push' afterwards to record the restored version.
+
+<rev> is any Git revision in the sync repository, such as a commit from
+'dotgh sync log' or HEAD~1.
+
+Examples:
+  dotgh sync restore a1b2c3d my-template
+  dotgh sync restore HEAD~1 config
+  dotgh sync restore a1b2c3d --yes`
+
+var syncRestoreYes bool
+
+var syncRestoreCmd = &cobra.Command{
+	Use:   "restore <rev> [template|config]",
+	Short: "Restore config or templates from sync history",
+	Long:  syncRestoreCmdLong,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runSyncRestore,
+}
+
+func init() {
+	syncRestoreCmd.Flags().BoolVarP(&syncRestoreYes, "yes", "y", false, "Skip confirmation prompt")
+}
+
+func runSyncRestore(cmd *cobra.Command, args []string) error {
+	return runSyncRestoreWithDir(cmd, args, config.GetConfigDir())
+}
+
+func runSyncRestoreWithDir(cmd *cobra.Command, args []string, configDir string) error {
+	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
+
+	manager, _, err := newSyncManager(configDir, "")
+	if err != nil {
+		return err
+	}
+
+	if !manager.IsInitialized() {
+		return fmt.Errorf("sync is not initialized. Run 'dotgh sync init <repository>' first")
+	}
+
+	rev, target := args[0], ""
+	if len(args) > 1 {
+		target = args[1]
+	}
+
+	snapshot, err := manager.Snapshot(ctx, rev)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = snapshot.Close() }()
+
+	var name string
+	switch target {
+	case "":
+		name = "config and templates"
+	case sync.TargetConfig:
+		name = "config.yaml"
+	default:
+		name = fmt.Sprintf("template %q", target)
+	}
+	if !snapshot.Has(target) {
+		return fmt.Errorf("%s not found at %s", name, snapshot.Commit.ShortHash)
+	}
+
+	_, _ = fmt.Fprintf(w, "Restoring %s from %s (%s)\n", name, snapshot.Commit.ShortHash, snapshot.Commit.Subject)
+
+	plan, err := snapshot.PlanRestore(target)
+	if err != nil {
+		return fmt.Errorf("compute changes: %w", err)
+	}
+
+	if !plan.HasChanges() {
+		_, _ = fmt.Fprintln(w, "Local files already match this snapshot.")
+	} else {
+		confirmed, err := confirmLocalChanges(w, cmd.InOrStdin(), plan, syncRestoreYes)
+		if err != nil || !confirmed {
+			return err
+		}
+
+		if err := snapshot.ApplyRestore(plan); err != nil {
+			return fmt.Errorf("restore: %w", err)
+		}
+
+		_, _ = fmt.Fprintln(w, "Restored successfully!")
+		_, _ = fmt.Fprintln(w, "Run 'dotgh sync push' to record the restored version.")
+	}
+
+	printLockedFiles(w, snapshot.LockedFiles(), configDir, "dotgh sync restore")
+	return nil
+}
+
+// NewSyncRestoreCmd creates a new sync restore command for testing.
+func NewSyncRestoreCmd(configDir string) *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <rev> [template|config]",
+		Short: "Restore config or templates from sync history",
+		Long:  syncRestoreCmdLong,
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Temporarily set the global variable
+			oldYes := syncRestoreYes
+			syncRestoreYes = yes
+			defer func() { syncRestoreYes = oldYes }()
+
+			return runSyncRestoreWithDir(cmd, args, configDir)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
+	return cmd
+}
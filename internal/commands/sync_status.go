@@ -8,14 +8,36 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// syncStatusCmdLong is the long description for the sync status command.
+const syncStatusCmdLong = `Show the current synchronization status.
+
+Displays information about the sync repository and current branch, how many
+commits it is ahead of or behind the remote, local config and templates that
+have changed since the last sync, and templates that differ from the remote.
+
+The remote is fetched first. Use --no-fetch to work offline with the last
+fetched state. Use --profile to show the status of a named sync profile.
+
+Examples:
+  dotgh sync status
+  dotgh sync status --no-fetch
+  dotgh sync status --profile work`
+
+var (
+	syncStatusNoFetch bool
+	syncStatusProfile string
+)
+
 var syncStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show sync status",
-	Long: `Show the current synchronization status.
+	Long:  syncStatusCmdLong,
+	RunE:  runSyncStatus,
+}
 
-Displays information about the sync repository, current branch,
-and whether there are any uncommitted local changes.`,
-	RunE: runSyncStatus,
+func init() {
+	syncStatusCmd.Flags().BoolVar(&syncStatusNoFetch, "no-fetch", false, "Do not fetch from the remote")
+	syncStatusCmd.Flags().StringVar(&syncStatusProfile, "profile", "", "Name of the sync profile (default: the default profile)")
 }
 
 func runSyncStatus(cmd *cobra.Command, args []string) error {
@@ -24,24 +46,42 @@ func runSyncStatus(cmd *cobra.Command, args []string) error {
 
 func runSyncStatusWithDir(cmd *cobra.Command, configDir string) error {
 	w := cmd.OutOrStdout()
+	ctx := cmd.Context()
 
-	manager := sync.NewManager(configDir)
-	status, err := manager.GetSyncStatus()
+	manager, _, err := newSyncManager(configDir, syncStatusProfile)
 	if err != nil {
-		return fmt.Errorf("get sync status: %w", err)
+		return err
 	}
 
-	if status.State == sync.StatusNotInitialized {
+	if !manager.IsInitialized() {
 		_, _ = fmt.Fprintln(w, "Sync is not initialized.")
-		_, _ = fmt.Fprintln(w, "Run 'dotgh sync init <repository>' to set up synchronization.")
+		_, _ = fmt.Fprintf(w, "Run '%s' to set up synchronization.\n", syncCommand(manager, "init <repository>"))
 		return nil
 	}
 
+	fetched := false
+	if !syncStatusNoFetch {
+		if err := manager.Fetch(ctx); err != nil {
+			_, _ = fmt.Fprintf(w, "Warning: could not fetch from remote: %v\n\n", err)
+		} else {
+			fetched = true
+		}
+	}
+
+	status, err := manager.GetSyncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("get sync status: %w", err)
+	}
+
 	_, _ = fmt.Fprintln(w, "Sync Status:")
 	_, _ = fmt.Fprintf(w, "  Repository: %s\n", status.RepoURL)
 	_, _ = fmt.Fprintf(w, "  Branch: %s\n", status.Branch)
 	_, _ = fmt.Fprintf(w, "  Status: %s\n", status.State)
+	_, _ = fmt.Fprintf(w, "  Remote: %s\n", describeRemote(manager, status, fetched))
 	_, _ = fmt.Fprintf(w, "  Sync directory: %s\n", manager.SyncDirPath())
+	if manager.Profile() != "" {
+		_, _ = fmt.Fprintf(w, "  Profile: %s (templates in %s)\n", manager.Profile(), manager.TemplatesDir())
+	}
 
 	if status.HasChanges {
 		_, _ = fmt.Fprintln(w, "\nUncommitted changes:")
@@ -50,21 +90,83 @@ func runSyncStatusWithDir(cmd *cobra.Command, configDir string) error {
 		}
 	}
 
+	// Local config and templates compared with the sync snapshot
+	plan, err := manager.PlanPush()
+	if err != nil {
+		return fmt.Errorf("compare local files: %w", err)
+	}
+	if plan.HasChanges() {
+		_, _ = fmt.Fprintf(w, "\nLocal changes not yet synced (run '%s'):\n", syncCommand(manager, "push"))
+		printDiffSummary(w, plan)
+	}
+
+	// Sync snapshot compared with the remote
+	if status.Upstream != "" && (status.Ahead > 0 || status.Behind > 0) {
+		items, err := manager.RemoteDifferences(ctx)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			_, _ = fmt.Fprintf(w, "\nDiffers from %s:\n", status.Upstream)
+			for _, item := range items {
+				_, _ = fmt.Fprintf(w, "  - %s\n", item)
+			}
+		}
+	}
+
 	return nil
 }
 
+// describeRemote summarizes how the sync branch compares with its upstream.
+func describeRemote(manager *sync.Manager, status *sync.SyncStatus, fetched bool) string {
+	if status.Upstream == "" {
+		return "not pushed yet"
+	}
+
+	push, pull := syncCommand(manager, "push"), syncCommand(manager, "pull")
+	var desc, hint string
+	switch {
+	case status.Ahead > 0 && status.Behind > 0:
+		desc = fmt.Sprintf("%d ahead, %d behind %s", status.Ahead, status.Behind, status.Upstream)
+		hint = fmt.Sprintf("run '%s', then '%s'", pull, push)
+	case status.Ahead > 0:
+		desc = fmt.Sprintf("%d ahead of %s", status.Ahead, status.Upstream)
+		hint = fmt.Sprintf("run '%s'", push)
+	case status.Behind > 0:
+		desc = fmt.Sprintf("%d behind %s", status.Behind, status.Upstream)
+		hint = fmt.Sprintf("run '%s'", pull)
+	default:
+		desc = fmt.Sprintf("up to date with %s", status.Upstream)
+	}
+	if !fetched {
+		desc += " as of last fetch"
+	}
+	if hint != "" {
+		desc += " (" + hint + ")"
+	}
+	return desc
+}
+
 // NewSyncStatusCmd creates a new sync status command for testing.
 func NewSyncStatusCmd(configDir string) *cobra.Command {
+	var noFetch bool
+	var profile string
+
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show sync status",
-		Long: `Show the current synchronization status.
-
-Displays information about the sync repository, current branch,
-and whether there are any uncommitted local changes.`,
+		Long:  syncStatusCmdLong,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Temporarily set the global variables
+			oldNoFetch, oldProfile := syncStatusNoFetch, syncStatusProfile
+			syncStatusNoFetch, syncStatusProfile = noFetch, profile
+			defer func() { syncStatusNoFetch, syncStatusProfile = oldNoFetch, oldProfile }()
+
 			return runSyncStatusWithDir(cmd, configDir)
 		},
 	}
+
+	cmd.Flags().BoolVar(&noFetch, "no-fetch", false, "Do not fetch from the remote")
+	cmd.Flags().StringVar(&profile, "profile", "", "Name of the sync profile (default: the default profile)")
 	return cmd
 }
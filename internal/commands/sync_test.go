@@ -8,6 +8,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/git"
+	"github.com/openjny/dotgh/internal/sync"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +35,19 @@ func TestSyncCommand(t *testing.T) {
 	})
 }
 
+func TestSyncManagerConfigFile(t *testing.T) {
+	configDir := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "custom.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("includes:\n  - AGENTS.md\n"), 0644))
+	config.SetConfigFile(configFile)
+	t.Cleanup(func() { config.SetConfigFile("") })
+
+	// The config file selected with --config is the one synced
+	manager, _, err := newSyncManager(configDir, "")
+	require.NoError(t, err)
+	assert.Equal(t, configFile, manager.LocalPath("config.yaml"))
+}
+
 func TestSyncInitCommand(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test in short mode")
@@ -143,6 +160,54 @@ func TestSyncStatusCommand(t *testing.T) {
 		assert.Contains(t, output, "Status:")
 		assert.Contains(t, output, "clean")
 	})
+
+	t.Run("compares with remote and local files", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping integration test in short mode")
+		}
+		t.Setenv("DOTGH_SYNC_KEY", "")
+
+		bareDir := t.TempDir()
+		bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+		bareCmd.Dir = bareDir
+		require.NoError(t, bareCmd.Run())
+
+		run := func(c *cobra.Command, args ...string) string {
+			var buf bytes.Buffer
+			c.SetArgs(args)
+			c.SetOut(&buf)
+			require.NoError(t, c.Execute())
+			return buf.String()
+		}
+
+		configDir1 := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(configDir1, "config.yaml"), []byte("includes:\n  - AGENTS.md\n"), 0644))
+		createTestFile(t, filepath.Join(configDir1, "templates", "myproject"), "AGENTS.md", "# v1")
+		run(NewSyncInitCmd(configDir1), bareDir, "-b", "main")
+		run(NewSyncPushCmd(configDir1), "--yes")
+
+		configDir2 := t.TempDir()
+		run(NewSyncInitCmd(configDir2), bareDir, "-b", "main")
+		run(NewSyncPullCmd(configDir2), "--yes")
+
+		output := run(NewSyncStatusCmd(configDir2))
+		assert.Contains(t, output, "Remote: up to date with origin/main")
+		assert.NotContains(t, output, "Local changes")
+
+		// The remote moves ahead and a local template changes
+		createTestFile(t, filepath.Join(configDir1, "templates", "myproject"), "AGENTS.md", "# v2")
+		run(NewSyncPushCmd(configDir1), "--yes")
+		createTestFile(t, filepath.Join(configDir2, "templates", "other"), "AGENTS.md", "# Other")
+
+		output = run(NewSyncStatusCmd(configDir2), "--no-fetch")
+		assert.Contains(t, output, "up to date with origin/main as of last fetch")
+		assert.Contains(t, output, "Local changes not yet synced")
+		assert.Contains(t, output, "+ templates/other/AGENTS.md")
+
+		output = run(NewSyncStatusCmd(configDir2))
+		assert.Contains(t, output, "Remote: 1 behind origin/main")
+		assert.Contains(t, output, "Differs from origin/main:\n  - templates/myproject\n")
+	})
 }
 
 func TestSyncPushCommand(t *testing.T) {
@@ -176,7 +241,7 @@ func TestSyncPushCommand(t *testing.T) {
 		pushCmd := NewSyncPushCmd(configDir)
 		var buf bytes.Buffer
 		pushCmd.SetOut(&buf)
-		pushCmd.SetArgs([]string{"-m", "sync config"})
+		pushCmd.SetArgs([]string{"-m", "sync config", "--yes"})
 
 		err := pushCmd.Execute()
 		require.NoError(t, err)
@@ -192,6 +257,77 @@ func TestSyncPushCommand(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("redacts secrets before committing", func(t *testing.T) {
+		bareDir := t.TempDir()
+		bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+		bareCmd.Dir = bareDir
+		require.NoError(t, bareCmd.Run())
+
+		configDir := t.TempDir()
+		templateDir := filepath.Join(configDir, "templates", "myproject", ".vscode")
+		require.NoError(t, os.MkdirAll(templateDir, 0755))
+		mcpJSON := `{"servers": {"github": {"env": {"GITHUB_TOKEN": "literal-token-value"}}}}`
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "mcp.json"), []byte(mcpJSON), 0644))
+
+		initCmd := NewSyncInitCmd(configDir)
+		initCmd.SetArgs([]string{bareDir, "-b", "main"})
+		initCmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, initCmd.Execute())
+
+		// Blocked without flags (prompt answered "no")
+		blocked := NewSyncPushCmd(configDir)
+		blocked.SetOut(&bytes.Buffer{})
+		blocked.SetErr(&bytes.Buffer{})
+		blocked.SetIn(strings.NewReader("n\n"))
+		err := blocked.Execute()
+		require.ErrorIs(t, err, ErrSecretsDetected)
+		_, err = os.Stat(filepath.Join(configDir, ".sync", "templates"))
+		assert.True(t, os.IsNotExist(err), "nothing should be copied when blocked")
+
+		pushCmd := NewSyncPushCmd(configDir)
+		var buf bytes.Buffer
+		pushCmd.SetOut(&buf)
+		pushCmd.SetArgs([]string{"--redact-secrets", "--yes"})
+		require.NoError(t, pushCmd.Execute())
+
+		synced, err := os.ReadFile(filepath.Join(configDir, ".sync", "templates", "myproject", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(synced), "literal-token-value")
+		assert.Contains(t, string(synced), "${input:github-token}")
+
+		// Local template is untouched
+		local, err := os.ReadFile(filepath.Join(templateDir, "mcp.json"))
+		require.NoError(t, err)
+		assert.Equal(t, mcpJSON, string(local))
+
+		// The redacted file is in sync: push does not report it again, and
+		// pull keeps the local value instead of the placeholder
+		again := NewSyncPushCmd(configDir)
+		buf.Reset()
+		again.SetOut(&buf)
+		again.SetArgs([]string{"--yes"})
+		require.NoError(t, again.Execute())
+		assert.Contains(t, buf.String(), "Nothing to push")
+		assert.NotContains(t, buf.String(), "Potential secrets")
+
+		pullCmd := NewSyncPullCmd(configDir)
+		pullCmd.SetOut(&bytes.Buffer{})
+		pullCmd.SetArgs([]string{"--yes"})
+		require.NoError(t, pullCmd.Execute())
+		local, err = os.ReadFile(filepath.Join(templateDir, "mcp.json"))
+		require.NoError(t, err)
+		assert.Equal(t, mcpJSON, string(local))
+
+		// Once edited locally, the file is scanned again
+		edited := strings.Replace(mcpJSON, "literal-token-value", "other-token-value", 1)
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "mcp.json"), []byte(edited), 0644))
+		blocked = NewSyncPushCmd(configDir)
+		blocked.SetOut(&bytes.Buffer{})
+		blocked.SetErr(&bytes.Buffer{})
+		blocked.SetArgs([]string{"--yes"})
+		require.ErrorIs(t, blocked.Execute(), ErrSecretsDetected)
+	})
+
 	t.Run("fails when not initialized", func(t *testing.T) {
 		configDir := t.TempDir()
 
@@ -237,7 +373,7 @@ func TestSyncPullCommand(t *testing.T) {
 		pushCmd := NewSyncPushCmd(configDir1)
 		var pushBuf bytes.Buffer
 		pushCmd.SetOut(&pushBuf)
-		pushCmd.SetArgs([]string{"-m", "initial sync"})
+		pushCmd.SetArgs([]string{"-m", "initial sync", "--yes"})
 		require.NoError(t, pushCmd.Execute())
 
 		// Verify push created files in sync directory
@@ -260,6 +396,7 @@ func TestSyncPullCommand(t *testing.T) {
 
 		// Pull to configDir2 (copy from sync to config dir)
 		pullCmd := NewSyncPullCmd(configDir2)
+		pullCmd.SetArgs([]string{"--yes"})
 		var buf bytes.Buffer
 		pullCmd.SetOut(&buf)
 
@@ -291,3 +428,623 @@ func TestSyncPullCommand(t *testing.T) {
 		assert.Contains(t, err.Error(), "not initialized")
 	})
 }
+
+func TestSyncGoGitBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	bareCmd.Dir = bareDir
+	require.NoError(t, bareCmd.Run())
+
+	configYAML := []byte("includes:\n  - AGENTS.md\nsync:\n  git_backend: go-git\n")
+	initSync := func(configDir string) {
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), configYAML, 0644))
+		initCmd := NewSyncInitCmd(configDir)
+		initCmd.SetArgs([]string{bareDir, "-b", "main"})
+		initCmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, initCmd.Execute())
+	}
+
+	configDir1 := t.TempDir()
+	initSync(configDir1)
+	createTestFile(t, filepath.Join(configDir1, "templates", "work"), "AGENTS.md", "# Work")
+
+	pushCmd := NewSyncPushCmd(configDir1)
+	var pushBuf bytes.Buffer
+	pushCmd.SetOut(&pushBuf)
+	pushCmd.SetArgs([]string{"-m", "initial", "--yes"})
+	require.NoError(t, pushCmd.Execute(), pushBuf.String())
+	assert.Contains(t, pushBuf.String(), "Pushed successfully!")
+
+	configDir2 := t.TempDir()
+	initSync(configDir2)
+
+	pullCmd := NewSyncPullCmd(configDir2)
+	var pullBuf bytes.Buffer
+	pullCmd.SetOut(&pullBuf)
+	pullCmd.SetArgs([]string{"--yes"})
+	require.NoError(t, pullCmd.Execute(), pullBuf.String())
+
+	data, err := os.ReadFile(filepath.Join(configDir2, "templates", "work", "AGENTS.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Work", string(data))
+
+	t.Run("rejects unknown backends", func(t *testing.T) {
+		configDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("sync:\n  git_backend: svn\n"), 0644))
+		statusCmd := NewSyncStatusCmd(configDir)
+		statusCmd.SetOut(&bytes.Buffer{})
+		statusCmd.SetErr(&bytes.Buffer{})
+		assert.ErrorContains(t, statusCmd.Execute(), "unknown git backend")
+	})
+}
+
+func TestSyncKeygenCommand(t *testing.T) {
+	t.Run("writes a new key", func(t *testing.T) {
+		configDir := t.TempDir()
+
+		cmd := NewSyncKeygenCmd(configDir)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		require.NoError(t, cmd.Execute())
+
+		data, err := os.ReadFile(filepath.Join(configDir, "sync.key"))
+		require.NoError(t, err)
+		assert.NotEmpty(t, strings.TrimSpace(string(data)))
+		assert.Contains(t, buf.String(), "Sync key written")
+	})
+
+	t.Run("refuses to overwrite without force", func(t *testing.T) {
+		configDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "sync.key"), []byte("existing\n"), 0600))
+
+		cmd := NewSyncKeygenCmd(configDir)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+
+		cmd = NewSyncKeygenCmd(configDir)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{"--force"})
+		require.NoError(t, cmd.Execute())
+		data, err := os.ReadFile(filepath.Join(configDir, "sync.key"))
+		require.NoError(t, err)
+		assert.NotEqual(t, "existing\n", string(data))
+	})
+}
+
+func TestSyncEncryptedFiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	bareCmd.Dir = bareDir
+	require.NoError(t, bareCmd.Run())
+
+	// Machine 1: encrypted MCP config
+	configDir1 := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir1, "config.yaml"),
+		[]byte("includes:\n  - .vscode/mcp.json\nsync:\n  encrypt:\n    - .vscode/mcp.json\n"), 0644))
+	mcpJSON := `{"servers": {"private": {"url": "https://internal.example.com/mcp"}}}`
+	createTestFile(t, filepath.Join(configDir1, "templates", "work"), ".vscode/mcp.json", mcpJSON)
+	require.NoError(t, os.WriteFile(filepath.Join(configDir1, "sync.key"), []byte("shared-key\n"), 0600))
+
+	initCmd := NewSyncInitCmd(configDir1)
+	initCmd.SetArgs([]string{bareDir, "-b", "main"})
+	initCmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, initCmd.Execute())
+
+	pushCmd := NewSyncPushCmd(configDir1)
+	pushCmd.SetOut(&bytes.Buffer{})
+	pushCmd.SetArgs([]string{"-m", "encrypted", "--yes"})
+	require.NoError(t, pushCmd.Execute())
+
+	synced, err := os.ReadFile(filepath.Join(configDir1, ".sync", "templates", "work", ".vscode", "mcp.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(synced), "internal.example.com")
+
+	t.Run("machine without key gets placeholders and a warning", func(t *testing.T) {
+		configDir := t.TempDir()
+		initCmd := NewSyncInitCmd(configDir)
+		initCmd.SetArgs([]string{bareDir, "-b", "main"})
+		initCmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, initCmd.Execute())
+
+		pullCmd := NewSyncPullCmd(configDir)
+		pullCmd.SetArgs([]string{"--yes"})
+		var buf bytes.Buffer
+		pullCmd.SetOut(&buf)
+		require.NoError(t, pullCmd.Execute())
+
+		assert.Contains(t, buf.String(), "could not be decrypted")
+		assert.Contains(t, buf.String(), "templates/work/.vscode/mcp.json")
+		content, err := os.ReadFile(filepath.Join(configDir, "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "dotgh: encrypted file")
+	})
+
+	t.Run("machine with key decrypts transparently", func(t *testing.T) {
+		configDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "sync.key"), []byte("shared-key\n"), 0600))
+		initCmd := NewSyncInitCmd(configDir)
+		initCmd.SetArgs([]string{bareDir, "-b", "main"})
+		initCmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, initCmd.Execute())
+
+		pullCmd := NewSyncPullCmd(configDir)
+		pullCmd.SetArgs([]string{"--yes"})
+		var buf bytes.Buffer
+		pullCmd.SetOut(&buf)
+		require.NoError(t, pullCmd.Execute())
+
+		assert.NotContains(t, buf.String(), "could not be decrypted")
+		content, err := os.ReadFile(filepath.Join(configDir, "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.Equal(t, mcpJSON, string(content))
+	})
+}
+
+func TestSyncMirrorsTemplates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	bareCmd.Dir = bareDir
+	require.NoError(t, bareCmd.Run())
+
+	// Machine 1 keeps its templates outside the config directory.
+	// The random temp path looks like a secret, so only warn about it.
+	configDir1 := t.TempDir()
+	templatesDir1 := filepath.Join(t.TempDir(), "my-templates")
+	require.NoError(t, os.WriteFile(filepath.Join(configDir1, "config.yaml"),
+		[]byte("templates_dir: "+templatesDir1+"\nincludes:\n  - AGENTS.md\nsecrets:\n  mode: warn\n"), 0644))
+	createTestFile(t, filepath.Join(templatesDir1, "keep"), "AGENTS.md", "# Keep")
+	createTestFile(t, filepath.Join(templatesDir1, "old"), "AGENTS.md", "# Old")
+	createTestFile(t, templatesDir1, ".history/templates/keep/1.json", "{}")
+
+	initCmd := NewSyncInitCmd(configDir1)
+	initCmd.SetArgs([]string{bareDir, "-b", "main"})
+	initCmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, initCmd.Execute())
+
+	pushCmd := NewSyncPushCmd(configDir1)
+	pushCmd.SetOut(&bytes.Buffer{})
+	pushCmd.SetArgs([]string{"-m", "initial", "--yes"})
+	require.NoError(t, pushCmd.Execute())
+
+	syncDir1 := filepath.Join(configDir1, ".sync")
+	_, err := os.Stat(filepath.Join(syncDir1, "templates", "keep", "AGENTS.md"))
+	require.NoError(t, err, "templates_dir should be synced")
+	assert.NoDirExists(t, filepath.Join(syncDir1, "templates", ".history"), "local history should not be synced")
+
+	// Machine 2 uses the default templates directory
+	configDir2 := t.TempDir()
+	initCmd2 := NewSyncInitCmd(configDir2)
+	initCmd2.SetArgs([]string{bareDir, "-b", "main"})
+	initCmd2.SetOut(&bytes.Buffer{})
+	require.NoError(t, initCmd2.Execute())
+	require.NoError(t, os.WriteFile(filepath.Join(configDir2, "config.yaml"), []byte("includes:\n  - AGENTS.md\n"), 0644))
+
+	t.Run("push mirrors deletions after confirmation", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(filepath.Join(templatesDir1, "old")))
+
+		// Declining leaves the sync repository untouched
+		aborted := NewSyncPushCmd(configDir1)
+		var buf bytes.Buffer
+		aborted.SetOut(&buf)
+		aborted.SetIn(strings.NewReader("n\n"))
+		require.NoError(t, aborted.Execute())
+		assert.Contains(t, buf.String(), "- templates/old/AGENTS.md")
+		assert.Contains(t, buf.String(), "Aborted.")
+		_, err := os.Stat(filepath.Join(syncDir1, "templates", "old", "AGENTS.md"))
+		require.NoError(t, err)
+
+		confirmed := NewSyncPushCmd(configDir1)
+		confirmed.SetOut(&bytes.Buffer{})
+		confirmed.SetIn(strings.NewReader("y\n"))
+		confirmed.SetArgs([]string{"-m", "remove old"})
+		require.NoError(t, confirmed.Execute())
+		_, err = os.Stat(filepath.Join(syncDir1, "templates", "old"))
+		assert.True(t, os.IsNotExist(err), "deleted template should be removed from sync directory")
+	})
+
+	t.Run("pull mirrors deletions into the local templates", func(t *testing.T) {
+		createTestFile(t, filepath.Join(configDir2, "templates", "old"), "AGENTS.md", "# Old")
+
+		pullCmd := NewSyncPullCmd(configDir2)
+		var buf bytes.Buffer
+		pullCmd.SetOut(&buf)
+		pullCmd.SetArgs([]string{"--yes"})
+		require.NoError(t, pullCmd.Execute())
+
+		assert.Contains(t, buf.String(), "- templates/old/AGENTS.md")
+		_, err := os.Stat(filepath.Join(configDir2, "templates", "keep", "AGENTS.md"))
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(configDir2, "templates", "old"))
+		assert.True(t, os.IsNotExist(err), "template deleted remotely should be removed locally")
+	})
+}
+
+// setupSyncConflict creates two machines that changed the same template file
+// and leaves the second one with a conflicted pull. It returns the config
+// directory of the second machine.
+func setupSyncConflict(t *testing.T) string {
+	t.Helper()
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	bareCmd.Dir = bareDir
+	require.NoError(t, bareCmd.Run())
+
+	run := func(c *cobra.Command, args ...string) error {
+		c.SetArgs(args)
+		c.SetOut(&bytes.Buffer{})
+		c.SetErr(&bytes.Buffer{})
+		return c.Execute()
+	}
+
+	configDir1 := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir1, "config.yaml"), []byte("includes:\n  - AGENTS.md\n"), 0644))
+	createTestFile(t, filepath.Join(configDir1, "templates", "myproject"), "AGENTS.md", "# Base\n")
+	require.NoError(t, run(NewSyncInitCmd(configDir1), bareDir, "-b", "main"))
+	require.NoError(t, run(NewSyncPushCmd(configDir1), "--yes"))
+
+	configDir2 := t.TempDir()
+	require.NoError(t, run(NewSyncInitCmd(configDir2), bareDir, "-b", "main"))
+	require.NoError(t, run(NewSyncPullCmd(configDir2), "--yes"))
+
+	// Both machines change the same file; the second push is rejected
+	createTestFile(t, filepath.Join(configDir1, "templates", "myproject"), "AGENTS.md", "# Remote\n")
+	require.NoError(t, run(NewSyncPushCmd(configDir1), "--yes"))
+	createTestFile(t, filepath.Join(configDir2, "templates", "myproject"), "AGENTS.md", "# Local\n")
+	require.Error(t, run(NewSyncPushCmd(configDir2), "--yes"))
+
+	return configDir2
+}
+
+func TestSyncResolveCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	localFile := func(configDir string) string {
+		data, err := os.ReadFile(filepath.Join(configDir, "templates", "myproject", "AGENTS.md"))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("pull stops on conflicts before copying", func(t *testing.T) {
+		configDir := setupSyncConflict(t)
+
+		pullCmd := NewSyncPullCmd(configDir)
+		var buf bytes.Buffer
+		pullCmd.SetOut(&buf)
+		pullCmd.SetErr(&bytes.Buffer{})
+		pullCmd.SetArgs([]string{"--yes"})
+		err := pullCmd.Execute()
+		require.ErrorIs(t, err, git.ErrMergeConflict)
+		assert.Contains(t, buf.String(), "templates/myproject/AGENTS.md")
+		assert.Contains(t, buf.String(), "dotgh sync resolve")
+		assert.Equal(t, "# Local\n", localFile(configDir))
+
+		// Pull and push refuse to run until the conflicts are resolved
+		again := NewSyncPullCmd(configDir)
+		again.SetOut(&bytes.Buffer{})
+		again.SetErr(&bytes.Buffer{})
+		require.ErrorIs(t, again.Execute(), sync.ErrUnresolvedConflicts)
+
+		pushCmd := NewSyncPushCmd(configDir)
+		pushCmd.SetOut(&bytes.Buffer{})
+		pushCmd.SetErr(&bytes.Buffer{})
+		require.ErrorIs(t, pushCmd.Execute(), sync.ErrUnresolvedConflicts)
+	})
+
+	t.Run("keeps the remote version and completes the merge", func(t *testing.T) {
+		configDir := setupSyncConflict(t)
+		pullCmd := NewSyncPullCmd(configDir)
+		pullCmd.SetOut(&bytes.Buffer{})
+		pullCmd.SetErr(&bytes.Buffer{})
+		require.Error(t, pullCmd.Execute())
+
+		resolveCmd := NewSyncResolveCmd(configDir)
+		var buf bytes.Buffer
+		resolveCmd.SetOut(&buf)
+		resolveCmd.SetIn(strings.NewReader("r\n"))
+		resolveCmd.SetArgs([]string{"--yes"})
+		require.NoError(t, resolveCmd.Execute())
+
+		assert.Contains(t, buf.String(), "Resolved templates/myproject/AGENTS.md (remote)")
+		assert.Contains(t, buf.String(), "Merge completed.")
+		assert.Equal(t, "# Remote\n", localFile(configDir))
+		assert.False(t, sync.NewManager(configDir).IsMerging())
+
+		// The merge commit can now be published
+		pushCmd := NewSyncPushCmd(configDir)
+		var pushBuf bytes.Buffer
+		pushCmd.SetOut(&pushBuf)
+		pushCmd.SetArgs([]string{"--yes"})
+		require.NoError(t, pushCmd.Execute())
+		assert.Contains(t, pushBuf.String(), "Pushed successfully!")
+	})
+
+	t.Run("edit requires conflict markers to be removed", func(t *testing.T) {
+		configDir := setupSyncConflict(t)
+		pullCmd := NewSyncPullCmd(configDir)
+		pullCmd.SetOut(&bytes.Buffer{})
+		pullCmd.SetErr(&bytes.Buffer{})
+		require.Error(t, pullCmd.Execute())
+
+		// An editor that leaves the file unchanged
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("editor: \"true\"\n"), 0644))
+
+		resolveCmd := NewSyncResolveCmd(configDir)
+		var buf bytes.Buffer
+		resolveCmd.SetOut(&buf)
+		resolveCmd.SetIn(strings.NewReader("e\nl\n"))
+		resolveCmd.SetArgs([]string{"--yes", "-m", "keep mine"})
+		require.NoError(t, resolveCmd.Execute())
+
+		assert.Contains(t, buf.String(), "still contains conflict markers")
+		assert.Contains(t, buf.String(), "Resolved templates/myproject/AGENTS.md (local)")
+		assert.Equal(t, "# Local\n", localFile(configDir))
+	})
+
+	t.Run("go-git backend stops with a clear message", func(t *testing.T) {
+		configDir := setupSyncConflict(t)
+		setBackend := func(backend string) {
+			configYAML := "includes:\n  - AGENTS.md\nsync:\n  git_backend: " + backend + "\n"
+			require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configYAML), 0644))
+		}
+
+		setBackend("go-git")
+		pullCmd := NewSyncPullCmd(configDir)
+		pullCmd.SetOut(&bytes.Buffer{})
+		pullCmd.SetErr(&bytes.Buffer{})
+		pullCmd.SetArgs([]string{"--yes"})
+		err := pullCmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the go-git git backend cannot merge them. Set 'sync.git_backend' to exec")
+		assert.False(t, sync.NewManager(configDir).IsMerging())
+
+		// A merge started with the exec backend cannot be completed with go-git
+		setBackend("exec")
+		pullCmd = NewSyncPullCmd(configDir)
+		pullCmd.SetOut(&bytes.Buffer{})
+		pullCmd.SetErr(&bytes.Buffer{})
+		pullCmd.SetArgs([]string{"--yes"})
+		require.ErrorIs(t, pullCmd.Execute(), git.ErrMergeConflict)
+
+		setBackend("go-git")
+		resolveCmd := NewSyncResolveCmd(configDir)
+		resolveCmd.SetOut(&bytes.Buffer{})
+		resolveCmd.SetErr(&bytes.Buffer{})
+		resolveCmd.SetArgs([]string{"--remote", "--yes"})
+		err = resolveCmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "resolving conflicts is not supported by the go-git git backend")
+		assert.True(t, sync.NewManager(configDir).IsMerging(), "the merge is left for the exec backend")
+	})
+
+	t.Run("reports when there is nothing to resolve", func(t *testing.T) {
+		configDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(configDir, ".sync", ".git"), 0755))
+
+		resolveCmd := NewSyncResolveCmd(configDir)
+		var buf bytes.Buffer
+		resolveCmd.SetOut(&buf)
+		require.NoError(t, resolveCmd.Execute())
+		assert.Contains(t, buf.String(), "No conflicts to resolve.")
+	})
+}
+
+func TestSyncLogAndRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	bareDir := t.TempDir()
+	bareCmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	bareCmd.Dir = bareDir
+	require.NoError(t, bareCmd.Run())
+
+	run := func(c *cobra.Command, args ...string) (string, error) {
+		var buf bytes.Buffer
+		c.SetArgs(args)
+		c.SetOut(&buf)
+		c.SetErr(&bytes.Buffer{})
+		err := c.Execute()
+		return buf.String(), err
+	}
+
+	configDir := t.TempDir()
+	templateDir := filepath.Join(configDir, "templates", "myproject")
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("includes:\n  - AGENTS.md\n"), 0644))
+	createTestFile(t, templateDir, "AGENTS.md", "# v1")
+	_, err := run(NewSyncInitCmd(configDir), bareDir, "-b", "main")
+	require.NoError(t, err)
+	_, err = run(NewSyncPushCmd(configDir), "-m", "first version", "--yes")
+	require.NoError(t, err)
+
+	createTestFile(t, templateDir, "AGENTS.md", "# v2")
+	createTestFile(t, templateDir, "extra.md", "extra")
+	_, err = run(NewSyncPushCmd(configDir), "-m", "second version", "--yes")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("includes:\n  - CLAUDE.md\n"), 0644))
+	_, err = run(NewSyncPushCmd(configDir), "-m", "change config", "--yes")
+	require.NoError(t, err)
+
+	t.Run("log lists commits touching a template", func(t *testing.T) {
+		output, err := run(NewSyncLogCmd(configDir), "myproject")
+		require.NoError(t, err)
+		assert.Contains(t, output, "second version")
+		assert.Contains(t, output, "first version")
+		assert.NotContains(t, output, "change config")
+
+		output, err = run(NewSyncLogCmd(configDir), "config")
+		require.NoError(t, err)
+		assert.Contains(t, output, "change config")
+		assert.NotContains(t, output, "second version")
+
+		output, err = run(NewSyncLogCmd(configDir), "-n", "1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(output, "\n"))
+	})
+
+	t.Run("restores a template from a previous snapshot", func(t *testing.T) {
+		// Declining leaves local files untouched
+		restoreCmd := NewSyncRestoreCmd(configDir)
+		restoreCmd.SetIn(strings.NewReader("n\n"))
+		output, err := run(restoreCmd, "HEAD~2", "myproject")
+		require.NoError(t, err)
+		assert.Contains(t, output, "M templates/myproject/AGENTS.md")
+		assert.Contains(t, output, "- templates/myproject/extra.md")
+		assert.Contains(t, output, "Aborted.")
+
+		output, err = run(NewSyncRestoreCmd(configDir), "HEAD~2", "myproject", "--yes")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Restored successfully!")
+
+		content, err := os.ReadFile(filepath.Join(templateDir, "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# v1", string(content))
+		_, err = os.Stat(filepath.Join(templateDir, "extra.md"))
+		assert.True(t, os.IsNotExist(err))
+
+		// Config is not touched when restoring a template
+		cfg, err := os.ReadFile(filepath.Join(configDir, "config.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(cfg), "CLAUDE.md")
+	})
+
+	t.Run("restores the config file", func(t *testing.T) {
+		_, err := run(NewSyncRestoreCmd(configDir), "HEAD~1", "config", "--yes")
+		require.NoError(t, err)
+		cfg, err := os.ReadFile(filepath.Join(configDir, "config.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(cfg), "AGENTS.md")
+	})
+
+	t.Run("fails for unknown revisions and templates", func(t *testing.T) {
+		_, err := run(NewSyncRestoreCmd(configDir), "no-such-rev", "--yes")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown revision")
+
+		_, err = run(NewSyncRestoreCmd(configDir), "HEAD", "missing", "--yes")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `template "missing" not found`)
+	})
+}
+
+func TestSyncProfiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("DOTGH_SYNC_KEY", "")
+
+	run := func(c *cobra.Command, args ...string) (string, error) {
+		var buf bytes.Buffer
+		c.SetArgs(args)
+		c.SetOut(&buf)
+		c.SetErr(&bytes.Buffer{})
+		err := c.Execute()
+		return buf.String(), err
+	}
+	newBare := func() string {
+		dir := t.TempDir()
+		require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", dir).Run())
+		return dir
+	}
+	remoteFiles := func(bareDir string) string {
+		out, err := exec.Command("git", "-C", bareDir, "ls-tree", "-r", "--name-only", "main").Output()
+		require.NoError(t, err)
+		return string(out)
+	}
+	personalDir, workDir := newBare(), newBare()
+
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("includes:\n  - AGENTS.md\n"), 0644))
+	createTestFile(t, filepath.Join(configDir, "templates", "home"), "AGENTS.md", "# Home")
+
+	_, err := run(NewSyncInitCmd(configDir), personalDir, "-b", "main")
+	require.NoError(t, err)
+	output, err := run(NewSyncInitCmd(configDir), workDir, "-b", "main", "--profile", "work")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Profile: work")
+	assert.Contains(t, output, "dotgh sync push --profile work")
+	assert.DirExists(t, filepath.Join(configDir, ".sync-profiles", "work", ".git"))
+
+	createTestFile(t, filepath.Join(configDir, "templates", "work", "api"), "AGENTS.md", "# Work API")
+
+	t.Run("each profile pushes its own templates", func(t *testing.T) {
+		_, err := run(NewSyncPushCmd(configDir), "--yes")
+		require.NoError(t, err)
+		_, err = run(NewSyncPushCmd(configDir), "--yes", "--profile", "work")
+		require.NoError(t, err)
+
+		personal := remoteFiles(personalDir)
+		assert.Contains(t, personal, "templates/home/AGENTS.md")
+		assert.Contains(t, personal, "config.yaml")
+		assert.NotContains(t, personal, "work")
+
+		work := remoteFiles(workDir)
+		assert.Contains(t, work, "templates/api/AGENTS.md")
+		assert.NotContains(t, work, "home")
+		assert.NotContains(t, work, "config.yaml")
+	})
+
+	t.Run("templates are namespaced in list", func(t *testing.T) {
+		output, err := run(NewListCmdWithConfigDir(filepath.Join(configDir, "templates"), configDir))
+		require.NoError(t, err)
+		assert.Contains(t, output, "  home\n")
+		assert.Contains(t, output, "  work/api\n")
+	})
+
+	t.Run("pull and status select the profile", func(t *testing.T) {
+		other := t.TempDir()
+		_, err := run(NewSyncInitCmd(other), workDir, "-b", "main", "--profile", "work")
+		require.NoError(t, err)
+
+		output, err := run(NewSyncPullCmd(other), "--yes", "--profile", "work")
+		require.NoError(t, err)
+		assert.Contains(t, output, "+ templates/api/AGENTS.md")
+		data, err := os.ReadFile(filepath.Join(other, "templates", "work", "api", "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Work API", string(data))
+		assert.NoFileExists(t, filepath.Join(other, "config.yaml"))
+
+		output, err = run(NewSyncStatusCmd(other), "--profile", "work")
+		require.NoError(t, err)
+		assert.Contains(t, output, "Profile: work")
+		assert.Contains(t, output, "up to date with origin/main")
+
+		output, err = run(NewSyncStatusCmd(other))
+		require.NoError(t, err)
+		assert.Contains(t, output, "Sync is not initialized.")
+	})
+
+	t.Run("rejects invalid and colliding profile names", func(t *testing.T) {
+		_, err := run(NewSyncInitCmd(configDir), newBare(), "--profile", "../x")
+		assert.ErrorContains(t, err, "invalid profile name")
+
+		_, err = run(NewSyncInitCmd(configDir), newBare(), "--profile", "home")
+		assert.ErrorContains(t, err, "a template named 'home' already exists")
+
+		_, err = run(NewSyncPushCmd(configDir), "--yes", "--profile", "missing")
+		assert.ErrorContains(t, err, "dotgh sync init <repository> --profile missing")
+	})
+}
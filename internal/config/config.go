@@ -6,8 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 // DefaultIncludes defines the default glob patterns for files to copy from templates.
@@ -24,10 +22,134 @@ var DefaultIncludes = []string{
 
 // Config represents the dotgh configuration.
 type Config struct {
-	Editor       string   `yaml:"editor,omitempty"`
-	TemplatesDir string   `yaml:"templates_dir,omitempty"`
-	Includes     []string `yaml:"includes"`
-	Excludes     []string `yaml:"excludes,omitempty"`
+	Editor       string `yaml:"editor,omitempty"`
+	TemplatesDir string `yaml:"templates_dir,omitempty"`
+	// Presets names built-in presets whose includes and excludes are added
+	// to Includes and Excludes when the config is loaded.
+	Presets  []string `yaml:"presets,omitempty"`
+	Includes []string `yaml:"includes"`
+	Excludes []string `yaml:"excludes,omitempty"`
+	Secrets  Secrets  `yaml:"secrets,omitempty"`
+	Sync     Sync     `yaml:"sync,omitempty"`
+	AutoSync string   `yaml:"auto_sync,omitempty"`
+	// Template is the default template of pull, push and diff, usually set
+	// in a project config file.
+	Template string `yaml:"template,omitempty"`
+
+	// Profiles defines named overrides of the settings above, selected with
+	// --profile or DOTGH_PROFILE.
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// ActiveProfile is the name of the profile applied by Load, if any.
+	ActiveProfile string `yaml:"-"`
+	// ProjectFile is the path of the project config file applied by Load, if any.
+	ProjectFile string `yaml:"-"`
+
+	// origins maps top-level keys to where their values came from.
+	origins map[string]string
+}
+
+// OriginDefault is the origin of values that are not set in any config file.
+const OriginDefault = "default"
+
+// Origin returns where the effective value of a top-level key (e.g.
+// "includes") came from: the path of a config file, a profile, or
+// OriginDefault.
+func (c *Config) Origin(key string) string {
+	if origin, ok := c.origins[key]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// setOrigin records where the value of a top-level key came from.
+func (c *Config) setOrigin(key, origin string) {
+	if c.origins == nil {
+		c.origins = make(map[string]string)
+	}
+	c.origins[key] = origin
+}
+
+// Auto sync modes.
+const (
+	// AutoSyncOff leaves syncing to 'dotgh sync push'.
+	AutoSyncOff = "off"
+	// AutoSyncCommit commits template and config changes to the sync repository.
+	AutoSyncCommit = "commit"
+	// AutoSyncPush commits template and config changes and pushes them.
+	AutoSyncPush = "push"
+)
+
+// GetAutoSync returns the auto sync mode, defaulting to AutoSyncOff.
+func (c *Config) GetAutoSync() string {
+	if c.AutoSync == "" {
+		return AutoSyncOff
+	}
+	return c.AutoSync
+}
+
+// Sync configures `dotgh sync`.
+type Sync struct {
+	// Encrypt lists glob patterns for files stored encrypted in the sync repository.
+	Encrypt []string `yaml:"encrypt,omitempty"`
+	// GitBackend selects the git implementation: "exec" (default) runs the
+	// git binary, "go-git" uses a built-in implementation.
+	GitBackend string `yaml:"git_backend,omitempty"`
+	// Timeout bounds clone, fetch, pull and push, as a Go duration such as
+	// "30s" or "5m" (default "2m"). "0" disables the limit.
+	Timeout string `yaml:"timeout,omitempty"`
+	// PullIfStale makes read commands such as list, pull and diff pull from
+	// the sync repositories first when the last pull is older than this Go
+	// duration (e.g. "1h"). Empty disables it.
+	PullIfStale string `yaml:"pull_if_stale,omitempty"`
+	// Templates selects the templates that are synced (default: all).
+	Templates SyncTemplates `yaml:"templates,omitempty"`
+	// LocalKeys lists config keys that stay on this machine, as dotted paths
+	// such as "editor" or "sync.templates". They are removed from the synced
+	// config file, and their local values are kept when pulling.
+	LocalKeys []string `yaml:"local_keys,omitempty"`
+	// Profiles configures named sync profiles, set up with
+	// 'dotgh sync init --profile <name>'.
+	Profiles map[string]SyncProfile `yaml:"profiles,omitempty"`
+}
+
+// SyncProfile configures a named sync profile. Each profile has its own
+// repository and syncs the templates in its own namespace.
+type SyncProfile struct {
+	// Templates selects the profile templates that are synced (default: all).
+	Templates SyncTemplates `yaml:"templates,omitempty"`
+}
+
+// SyncTemplates selects templates by name with glob patterns. A template is
+// synced if it matches an Include pattern (or Include is empty) and no
+// Exclude pattern.
+type SyncTemplates struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Secret scanning modes.
+const (
+	// SecretsModeBlock aborts push operations when secrets are detected.
+	SecretsModeBlock = "block"
+	// SecretsModeWarn reports detected secrets but continues.
+	SecretsModeWarn = "warn"
+	// SecretsModeOff disables secret scanning.
+	SecretsModeOff = "off"
+)
+
+// Secrets configures secret scanning for push and sync push.
+type Secrets struct {
+	Mode       string   `yaml:"mode,omitempty"`
+	Allowlist  []string `yaml:"allowlist,omitempty"`
+	AllowPaths []string `yaml:"allow_paths,omitempty"`
+}
+
+// GetMode returns the secret scanning mode, defaulting to SecretsModeBlock.
+func (s Secrets) GetMode() string {
+	if s.Mode == "" {
+		return SecretsModeBlock
+	}
+	return s.Mode
 }
 
 // GetTemplatesDir returns the templates directory path.
@@ -63,8 +185,12 @@ func expandTilde(path string) string {
 }
 
 // GetConfigDir returns the path to the dotgh configuration directory.
-// It follows the XDG Base Directory Specification.
+// It follows the XDG Base Directory Specification, unless DOTGH_CONFIG_DIR
+// is set.
 func GetConfigDir() string {
+	if dir := os.Getenv(ConfigDirEnvVar); dir != "" {
+		return expandTilde(dir)
+	}
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		// Fallback to home directory
@@ -82,23 +208,72 @@ func GetDefaultTemplatesDir() string {
 
 // GetConfigPath returns the path to the dotgh configuration file.
 func GetConfigPath() string {
-	return filepath.Join(GetConfigDir(), "config.yaml")
+	return ConfigFilePath(GetConfigDir())
 }
 
-// Load loads the configuration from the default config directory.
-// If no config file exists, it returns the default configuration.
+// Resolve returns the effective configuration. Every command loads its
+// configuration through Resolve. Settings are taken from, in increasing order
+// of precedence:
+//
+//  1. the defaults
+//  2. the config file (config.yaml in configDir, or the file selected with
+//     SetConfigFile)
+//  3. the selected profile (see SelectedProfile)
+//  4. the project config file that applies to workDir, if workDir is set
+//  5. the DOTGH_* environment variables (see ApplyEnv)
+//
+// An empty configDir selects the default config directory.
+func Resolve(configDir, workDir string) (*Config, error) {
+	if configDir == "" {
+		configDir = GetConfigDir()
+	}
+	cfg, err := LoadBaseFromDir(configDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ApplyProfile(SelectedProfile()); err != nil {
+		return nil, err
+	}
+	if workDir != "" {
+		if path := FindProjectFile(workDir); path != "" {
+			project, err := LoadProject(path)
+			if err != nil {
+				return nil, err
+			}
+			cfg.ApplyProject(project, path)
+		}
+	}
+	cfg.ApplyEnv()
+	return cfg, nil
+}
+
+// Load loads the effective configuration for the current directory from the
+// default config directory (see Resolve).
 func Load() (*Config, error) {
-	return LoadFromDir(GetConfigDir())
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get current directory: %w", err)
+	}
+	return Resolve("", cwd)
 }
 
-// LoadFromDir loads the configuration from the specified directory.
+// LoadFromDir loads the effective configuration from the specified directory,
+// without a project config file (see Resolve).
 // If no config file exists, it returns the default configuration.
 func LoadFromDir(dir string) (*Config, error) {
-	configPath := filepath.Join(dir, "config.yaml")
+	return Resolve(dir, "")
+}
+
+// LoadBaseFromDir loads the config file for the specified directory without
+// applying a profile, project config file or environment variables.
+// If no config file exists, it returns the default configuration. Unknown
+// keys and values of the wrong type are errors.
+func LoadBaseFromDir(dir string) (*Config, error) {
+	configPath := ConfigFilePath(dir)
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) && configFile == "" {
 			// Return default config if file does not exist
 			return &Config{Includes: DefaultIncludes}, nil
 		}
@@ -106,8 +281,20 @@ func LoadFromDir(dir string) (*Config, error) {
 	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parse config file: %w", err)
+	root, err := decodeStrict(data, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", configPath, err)
+	}
+	if root != nil {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			cfg.setOrigin(root.Content[i].Value, configPath)
+		}
+	}
+	if err := cfg.expandPresets(); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", configPath, err)
+	}
+	if len(cfg.Presets) > 0 {
+		cfg.setOrigin("includes", configPath)
 	}
 
 	return &cfg, nil
@@ -118,6 +305,10 @@ func LoadFromDir(dir string) (*Config, error) {
 func GenerateDefaultConfigContent() string {
 	var sb strings.Builder
 
+	// Schema for editors with YAML language support
+	sb.WriteString("# yaml-language-server: $schema=" + SchemaURL + "\n")
+	sb.WriteString("\n")
+
 	// Editor section (commented out)
 	sb.WriteString("# editor: Specify the editor command (e.g., \"code --wait\", \"vim\")\n")
 	sb.WriteString("# If not set, VISUAL, EDITOR, GIT_EDITOR environment variables,\n")
@@ -134,6 +325,12 @@ func GenerateDefaultConfigContent() string {
 	sb.WriteString("# templates_dir: \"\"\n")
 	sb.WriteString("\n")
 
+	// Presets section (commented out)
+	sb.WriteString("# presets: Add the includes and excludes of built-in presets for AI coding tools.\n")
+	sb.WriteString("# Run 'dotgh config presets' to list them.\n")
+	sb.WriteString("# presets: [copilot, claude]\n")
+	sb.WriteString("\n")
+
 	// Includes section
 	sb.WriteString("# includes: Specify file patterns to manage as templates (required)\n")
 	sb.WriteString("# Supports glob patterns (*, ?, [abc]). ** (recursive) is not supported.\n")
@@ -151,6 +348,45 @@ func GenerateDefaultConfigContent() string {
 	sb.WriteString("#   - \".github/prompts/secret-*.prompt.md\"\n")
 	sb.WriteString("\n")
 
+	// Secrets section (commented out)
+	sb.WriteString("# secrets: Configure secret scanning for push and sync push\n")
+	sb.WriteString("# mode: block (default), warn, or off.\n")
+	sb.WriteString("# allowlist: regular expressions for values that are not secrets.\n")
+	sb.WriteString("# allow_paths: glob patterns for files that are never scanned.\n")
+	sb.WriteString("# secrets:\n")
+	sb.WriteString("#   mode: block\n")
+	sb.WriteString("#   allowlist:\n")
+	sb.WriteString("#     - \"^sk-example-\"\n")
+	sb.WriteString("#   allow_paths:\n")
+	sb.WriteString("#     - \".github/prompts/*.prompt.md\"\n")
+	sb.WriteString("\n")
+
+	// Sync section (commented out)
+	sb.WriteString("# sync: Configure 'dotgh sync'\n")
+	sb.WriteString("# encrypt: glob patterns for files stored encrypted in the sync repository.\n")
+	sb.WriteString("# Patterns match paths inside a template or in the sync repository.\n")
+	sb.WriteString("# Run 'dotgh sync keygen' to create the key.\n")
+	sb.WriteString("# sync:\n")
+	sb.WriteString("#   encrypt:\n")
+	sb.WriteString("#     - \".vscode/mcp.json\"\n")
+	sb.WriteString("\n")
+
+	// Auto sync section (commented out)
+	sb.WriteString("# auto_sync: Record changes made by push, delete and edit in the sync repository\n")
+	sb.WriteString("# off (default), commit, or push (commit and push to the remote).\n")
+	sb.WriteString("# auto_sync: commit\n")
+	sb.WriteString("\n")
+
+	// Profiles section (commented out)
+	sb.WriteString("# profiles: Named overrides of editor, templates_dir, presets, includes and excludes.\n")
+	sb.WriteString("# Select one with --profile <name> or DOTGH_PROFILE=<name>.\n")
+	sb.WriteString("# profiles:\n")
+	sb.WriteString("#   claude:\n")
+	sb.WriteString("#     includes:\n")
+	sb.WriteString("#       - \"CLAUDE.md\"\n")
+	sb.WriteString("#       - \".claude/commands/*.md\"\n")
+	sb.WriteString("\n")
+
 	return sb.String()
 }
 
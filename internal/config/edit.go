@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keyType returns the type of the setting at key, a dotted path such as
+// "sync.timeout" or "profiles.work.includes".
+func keyType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	path := ""
+	for _, name := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := yamlFields(t)
+			field, ok := fields[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: %s", joinKey(path, name), unknownKeyMessage(name, fields))
+			}
+			t = field
+		case reflect.Map:
+			if name == "" {
+				return nil, fmt.Errorf("%s: empty name", key)
+			}
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("%s: %s has no keys", key, path)
+		}
+		path = joinKey(path, name)
+	}
+	return t, nil
+}
+
+// CheckKey returns an error if key is not a dotted path to a setting.
+func CheckKey(key string) error {
+	_, err := keyType(key)
+	return err
+}
+
+// Value returns the effective value of key, a dotted path such as
+// "sync.timeout", or nil if it is not set.
+func (c *Config) Value(key string) (*yaml.Node, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := root.Encode(c); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&root}}
+	return lookupKey(doc, strings.Split(key, ".")), nil
+}
+
+// SetValues returns the config file content data with key set to values: a
+// single value for settings such as "editor", or the whole list for lists
+// such as "includes". Comments and the order of keys are kept, and data is
+// returned unchanged if the key already has these values.
+func SetValues(data []byte, key string, values []string) ([]byte, error) {
+	t, err := keyType(key)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Split(key, ".")
+	old := lookupKey(doc, path)
+
+	var value *yaml.Node
+	switch t.Kind() {
+	case reflect.String:
+		if len(values) != 1 {
+			return nil, fmt.Errorf("%s takes a single value", key)
+		}
+		value = newScalar(values[0], old)
+	case reflect.Slice:
+		value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if old != nil && old.Kind == yaml.SequenceNode {
+			value.Style = old.Style
+		}
+		for _, v := range values {
+			value.Content = append(value.Content, newScalar(v, lastItem(old)))
+		}
+	default:
+		return nil, fmt.Errorf("%s is a section; set the keys in it instead", key)
+	}
+	if old != nil {
+		value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	}
+
+	if !setKey(doc, path, value) {
+		return data, nil
+	}
+	return encodeDocument(doc)
+}
+
+// AddValues returns the config file content data with the values that are
+// not in the list at key yet appended to it. The list is created if needed.
+// Comments and the order of keys are kept, and data is returned unchanged if
+// the list already contains all values.
+func AddValues(data []byte, key string, values []string) ([]byte, error) {
+	if err := checkListKey(key); err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Split(key, ".")
+
+	list := lookupKey(doc, path)
+	if list == nil || list.Kind != yaml.SequenceNode {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setKey(doc, path, list)
+	}
+	changed := false
+	for _, v := range values {
+		if slices.ContainsFunc(list.Content, func(item *yaml.Node) bool { return item.Value == v }) {
+			continue
+		}
+		list.Content = append(list.Content, newScalar(v, lastItem(list)))
+		changed = true
+	}
+	if !changed {
+		return data, nil
+	}
+	return encodeDocument(doc)
+}
+
+// RemoveValues returns the config file content data with the values removed
+// from the list at key, or without key if no values are given. Comments and
+// the order of keys are kept, and data is returned unchanged if there is
+// nothing to remove.
+func RemoveValues(data []byte, key string, values []string) ([]byte, error) {
+	if len(values) > 0 {
+		if err := checkListKey(key); err != nil {
+			return nil, err
+		}
+	} else if err := CheckKey(key); err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Split(key, ".")
+
+	changed := false
+	if len(values) == 0 {
+		changed = removeKey(doc, path)
+	} else if list := lookupKey(doc, path); list != nil && list.Kind == yaml.SequenceNode {
+		n := len(list.Content)
+		list.Content = slices.DeleteFunc(list.Content, func(item *yaml.Node) bool {
+			return slices.Contains(values, item.Value)
+		})
+		changed = len(list.Content) != n
+	}
+	if !changed {
+		return data, nil
+	}
+	return encodeDocument(doc)
+}
+
+// KeyProblems returns the problems that ValidateFile finds in the config
+// file content data at key or below it.
+func KeyProblems(data []byte, key string) []Problem {
+	var problems []Problem
+	for _, p := range configProblems(data) {
+		if p.Key == key || strings.HasPrefix(p.Key, key+".") {
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// checkListKey returns an error if key is not a list setting.
+func checkListKey(key string) error {
+	t, err := keyType(key)
+	if err != nil {
+		return err
+	}
+	if t.Kind() != reflect.Slice {
+		return fmt.Errorf("%s is not a list; use 'dotgh config set' instead", key)
+	}
+	return nil
+}
+
+// newScalar returns a string node for value, quoted like the node like, if
+// any.
+func newScalar(value string, like *yaml.Node) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if like != nil && like.Kind == yaml.ScalarNode {
+		node.Style = like.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle)
+	}
+	return node
+}
+
+// lastItem returns the last item of the sequence node list, or nil.
+func lastItem(list *yaml.Node) *yaml.Node {
+	if list == nil || list.Kind != yaml.SequenceNode || len(list.Content) == 0 {
+		return nil
+	}
+	return list.Content[len(list.Content)-1]
+}
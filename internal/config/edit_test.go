@@ -0,0 +1,119 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const editTestConfig = `# Editor for this machine
+editor: vim # mine
+includes:
+  - "AGENTS.md" # shared
+sync:
+  templates:
+    exclude: ["work-*"]
+`
+
+func TestSetValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		values []string
+		want   []string
+	}{
+		{"scalar keeps comments", "editor", []string{"code --wait"}, []string{"# Editor for this machine\neditor: code --wait # mine\n"}},
+		{"list replaces items", "includes", []string{"CLAUDE.md"}, []string{"includes:\n  - \"CLAUDE.md\"\nsync:"}},
+		{"flow list keeps style", "sync.templates.exclude", []string{"a", "b"}, []string{`exclude: ["a", "b"]`}},
+		{"new nested key", "profiles.work.editor", []string{"true"}, []string{"profiles:\n  work:\n    editor: \"true\"\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetValues([]byte(editTestConfig), tt.key, tt.values)
+			if err != nil {
+				t.Fatalf("SetValues() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("SetValues() should contain %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("unchanged value", func(t *testing.T) {
+		got, err := SetValues([]byte(editTestConfig), "editor", []string{"vim"})
+		if err != nil || string(got) != editTestConfig {
+			t.Errorf("SetValues() = %q, %v, want the content unchanged", got, err)
+		}
+	})
+
+	for _, tt := range []struct {
+		key    string
+		values []string
+		want   string
+	}{
+		{"include", []string{"a"}, `include: unknown key (did you mean "includes"?)`},
+		{"editor", []string{"a", "b"}, "editor takes a single value"},
+		{"sync", []string{"a"}, "sync is a section"},
+		{"editor.name", []string{"a"}, "editor has no keys"},
+	} {
+		if _, err := SetValues([]byte(editTestConfig), tt.key, tt.values); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SetValues(%q) error = %v, want %q", tt.key, err, tt.want)
+		}
+	}
+}
+
+func TestAddAndRemoveValues(t *testing.T) {
+	got, err := AddValues([]byte(editTestConfig), "includes", []string{"AGENTS.md", "CLAUDE.md"})
+	if err != nil {
+		t.Fatalf("AddValues() error = %v", err)
+	}
+	if want := "  - \"AGENTS.md\" # shared\n  - \"CLAUDE.md\"\n"; !strings.Contains(string(got), want) {
+		t.Errorf("AddValues() should contain %q, got:\n%s", want, got)
+	}
+
+	again, err := AddValues(got, "includes", []string{"CLAUDE.md"})
+	if err != nil || string(again) != string(got) {
+		t.Errorf("AddValues() of a present value should change nothing, got %v:\n%s", err, again)
+	}
+
+	got, err = AddValues(got, "excludes", []string{"local.md"})
+	if err != nil || !strings.Contains(string(got), "excludes:\n  - local.md\n") {
+		t.Errorf("AddValues() should create the list, got %v:\n%s", err, got)
+	}
+
+	got, err = RemoveValues(got, "includes", []string{"AGENTS.md", "missing.md"})
+	if err != nil || strings.Contains(string(got), "AGENTS.md") || !strings.Contains(string(got), "CLAUDE.md") {
+		t.Errorf("RemoveValues() should remove only AGENTS.md, got %v:\n%s", err, got)
+	}
+
+	got, err = RemoveValues(got, "editor", nil)
+	if err != nil || strings.Contains(string(got), "editor") || !strings.Contains(string(got), "excludes:\n  - local.md\n") {
+		t.Errorf("RemoveValues() should remove the key, got %v:\n%s", err, got)
+	}
+
+	if _, err := AddValues(got, "editor", []string{"vim"}); err == nil || !strings.Contains(err.Error(), "not a list") {
+		t.Errorf("AddValues() on a scalar error = %v, want not a list", err)
+	}
+}
+
+func TestRemoveLastKey(t *testing.T) {
+	content := "# dotgh config for this machine\n\n# Editor\neditor: vim # mine\n\n# See the user guide for the keys\n"
+	got, err := RemoveValues([]byte(content), "editor", nil)
+	if err != nil {
+		t.Fatalf("RemoveValues() error = %v", err)
+	}
+	want := "# dotgh config for this machine\n\n{}\n\n# See the user guide for the keys\n"
+	if string(got) != want {
+		t.Errorf("RemoveValues() = %q, want %q", got, want)
+	}
+	if cfg := parseConfig(t, got); cfg.Editor != "" {
+		t.Errorf("parsed config = %+v, want an empty config", cfg)
+	}
+
+	got, err = RemoveValues([]byte("editor: vim\n"), "editor", nil)
+	if err != nil || len(got) != 0 {
+		t.Errorf("RemoveValues() without comments = %q, %v; want empty content", got, err)
+	}
+}
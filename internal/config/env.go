@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables overriding the configuration.
+const (
+	// ConfigDirEnvVar overrides the config directory.
+	ConfigDirEnvVar = "DOTGH_CONFIG_DIR"
+	// EditorEnvVar overrides editor.
+	EditorEnvVar = "DOTGH_EDITOR"
+	// TemplatesDirEnvVar overrides templates_dir.
+	TemplatesDirEnvVar = "DOTGH_TEMPLATES_DIR"
+	// IncludesEnvVar overrides includes with a comma-separated list of patterns.
+	IncludesEnvVar = "DOTGH_INCLUDES"
+	// ExcludesEnvVar overrides excludes with a comma-separated list of patterns.
+	ExcludesEnvVar = "DOTGH_EXCLUDES"
+)
+
+// configFile is the config file selected with SetConfigFile.
+var configFile string
+
+// SetConfigFile selects the config file read instead of config.yaml in the
+// config directory. An empty path restores the default.
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+// ConfigFilePath returns the path of the config file read for the config
+// directory dir: the file selected with SetConfigFile, or config.yaml in dir.
+func ConfigFilePath(dir string) string {
+	if configFile != "" {
+		return expandTilde(configFile)
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+// ApplyEnv overrides settings with the DOTGH_* environment variables that are
+// set to a non-empty value.
+func (c *Config) ApplyEnv() {
+	if v := os.Getenv(EditorEnvVar); v != "" {
+		c.Editor = v
+		c.setOrigin("editor", "env "+EditorEnvVar)
+	}
+	if v := os.Getenv(TemplatesDirEnvVar); v != "" {
+		c.TemplatesDir = v
+		c.setOrigin("templates_dir", "env "+TemplatesDirEnvVar)
+	}
+	if v := os.Getenv(IncludesEnvVar); v != "" {
+		c.Includes = splitList(v)
+		c.setOrigin("includes", "env "+IncludesEnvVar)
+	}
+	if v := os.Getenv(ExcludesEnvVar); v != "" {
+		c.Excludes = splitList(v)
+		c.setOrigin("excludes", "env "+ExcludesEnvVar)
+	}
+}
+
+// splitList splits a comma-separated list, dropping blank entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolvePrecedence(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+	configYAML := "editor: vim\ntemplates_dir: /from/file\nincludes:\n  - AGENTS.md\nprofiles:\n  claude:\n    editor: code\n    includes:\n      - CLAUDE.md\n"
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	workDir := t.TempDir()
+	projectPath := filepath.Join(workDir, ProjectFileName)
+	if err := os.WriteFile(projectPath, []byte("includes:\n  - .cursor/rules/*.mdc\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	for _, name := range []string{ProfileEnvVar, EditorEnvVar, TemplatesDirEnvVar, IncludesEnvVar, ExcludesEnvVar} {
+		t.Setenv(name, "")
+	}
+
+	check := func(t *testing.T, cfg *Config, key, wantOrigin string, got, want any) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+		if origin := cfg.Origin(key); origin != wantOrigin {
+			t.Errorf("Origin(%s) = %q, want %q", key, origin, wantOrigin)
+		}
+	}
+
+	t.Run("profile over file, project over profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "claude")
+		cfg, err := Resolve(configDir, workDir)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		check(t, cfg, "editor", "profile claude", cfg.Editor, "code")
+		check(t, cfg, "templates_dir", configPath, cfg.TemplatesDir, "/from/file")
+		check(t, cfg, "includes", projectPath, cfg.Includes, []string{".cursor/rules/*.mdc"})
+	})
+
+	t.Run("environment over everything", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "claude")
+		t.Setenv(EditorEnvVar, "nano")
+		t.Setenv(TemplatesDirEnvVar, "/from/env")
+		t.Setenv(IncludesEnvVar, "AGENTS.md, .github/prompts/*.prompt.md,")
+		t.Setenv(ExcludesEnvVar, "local.md")
+		cfg, err := Resolve(configDir, workDir)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		check(t, cfg, "editor", "env "+EditorEnvVar, cfg.Editor, "nano")
+		check(t, cfg, "templates_dir", "env "+TemplatesDirEnvVar, cfg.GetTemplatesDir(), "/from/env")
+		check(t, cfg, "includes", "env "+IncludesEnvVar, cfg.Includes, []string{"AGENTS.md", ".github/prompts/*.prompt.md"})
+		check(t, cfg, "excludes", "env "+ExcludesEnvVar, cfg.Excludes, []string{"local.md"})
+	})
+
+	t.Run("config file override", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "other.yaml")
+		if err := os.WriteFile(other, []byte("editor: emacs\n"), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		SetConfigFile(other)
+		defer SetConfigFile("")
+
+		cfg, err := Resolve(configDir, "")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		check(t, cfg, "editor", other, cfg.Editor, "emacs")
+
+		SetConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		if _, err := Resolve(configDir, ""); err == nil {
+			t.Error("expected error for a missing config file given explicitly")
+		}
+	})
+
+	t.Run("config directory override", func(t *testing.T) {
+		t.Setenv(ConfigDirEnvVar, configDir)
+		if got := GetConfigDir(); got != configDir {
+			t.Errorf("GetConfigDir() = %q, want %q", got, configDir)
+		}
+		if got := GetConfigPath(); got != configPath {
+			t.Errorf("GetConfigPath() = %q, want %q", got, configPath)
+		}
+	})
+}
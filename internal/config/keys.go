@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StripKeys returns the config file content data without the given keys.
+// Keys are dotted paths into nested mappings (e.g. "editor" or
+// "sync.templates"). Comments and the order of the remaining keys are kept,
+// and data is returned unchanged if none of the keys is present.
+func StripKeys(data []byte, keys []string) ([]byte, error) {
+	return MergeKeys(data, nil, keys)
+}
+
+// MergeKeys returns the config file content base with the values of the given
+// keys taken from the config file content from: keys present in from replace
+// or are added to those in base, and keys missing from from are removed.
+// Keys are dotted paths as in StripKeys. base is returned unchanged if it
+// already holds the same values.
+func MergeKeys(base, from []byte, keys []string) ([]byte, error) {
+	if len(keys) == 0 {
+		return base, nil
+	}
+
+	baseDoc, err := parseDocument(base)
+	if err != nil {
+		return nil, err
+	}
+	fromDoc, err := parseDocument(from)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, key := range keys {
+		path := strings.Split(key, ".")
+		value := lookupKey(fromDoc, path)
+		if value == nil {
+			changed = removeKey(baseDoc, path) || changed
+		} else {
+			changed = setKey(baseDoc, path, value) || changed
+		}
+	}
+	if !changed {
+		return base, nil
+	}
+
+	return encodeDocument(baseDoc)
+}
+
+// encodeDocument encodes a document node parsed by parseDocument, keeping
+// its comments. A document without keys gives empty content, or {} with the
+// comments of the document if it has any.
+func encodeDocument(doc *yaml.Node) ([]byte, error) {
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	if len(doc.Content[0].Content) == 0 {
+		if doc.HeadComment == "" && doc.FootComment == "" {
+			return nil, nil
+		}
+		doc.Content[0].Style = yaml.FlowStyle
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseDocument parses config file content into a document node whose root
+// is a mapping. Empty content gives an empty document.
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) > 0 && doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse config file: top level is not a mapping")
+	}
+	return &doc, nil
+}
+
+// lookupKey returns the value node at path, or nil if it does not exist.
+func lookupKey(doc *yaml.Node, path []string) *yaml.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	node := doc.Content[0]
+	for _, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		i := keyIndex(node, name)
+		if i < 0 {
+			return nil
+		}
+		node = node.Content[i+1]
+	}
+	return node
+}
+
+// removeKey removes the key at path and reports whether it existed.
+func removeKey(doc *yaml.Node, path []string) bool {
+	parent := lookupKey(doc, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false
+	}
+	i := keyIndex(parent, path[len(path)-1])
+	if i < 0 {
+		return false
+	}
+	parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+	return true
+}
+
+// setKey sets the key at path to value, creating intermediate mappings, and
+// reports whether the document changed.
+func setKey(doc *yaml.Node, path []string, value *yaml.Node) bool {
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	node := doc.Content[0]
+	for depth, name := range path {
+		if node.Kind != yaml.MappingNode {
+			// A scalar is in the way; replace it with a mapping
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		i := keyIndex(node, name)
+		last := depth == len(path)-1
+		if i < 0 {
+			next := value
+			if !last {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, next)
+			if last {
+				return true
+			}
+			node = next
+			continue
+		}
+		if last {
+			if sameNode(node.Content[i+1], value) {
+				return false
+			}
+			node.Content[i+1] = value
+			return true
+		}
+		node = node.Content[i+1]
+	}
+	return false
+}
+
+// keyIndex returns the index of the key node named name in mapping, or -1.
+func keyIndex(mapping *yaml.Node, name string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameNode reports whether two nodes hold the same value, ignoring comments
+// and styles.
+func sameNode(a, b *yaml.Node) bool {
+	var va, vb any
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	da, errA := yaml.Marshal(va)
+	db, errB := yaml.Marshal(vb)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
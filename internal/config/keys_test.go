@@ -0,0 +1,109 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const keysTestConfig = `# Editor for this machine
+editor: vim
+includes:
+  - "AGENTS.md" # shared
+sync:
+  encrypt:
+    - ".vscode/mcp.json"
+  templates:
+    exclude: ["work-*"]
+`
+
+func TestStripKeys(t *testing.T) {
+	t.Run("removes top-level and nested keys", func(t *testing.T) {
+		got, err := StripKeys([]byte(keysTestConfig), []string{"editor", "sync.templates"})
+		if err != nil {
+			t.Fatalf("StripKeys failed: %v", err)
+		}
+		out := string(got)
+		for _, unwanted := range []string{"editor", "vim", "work-*"} {
+			if strings.Contains(out, unwanted) {
+				t.Errorf("output should not contain %q, got:\n%s", unwanted, out)
+			}
+		}
+		for _, wanted := range []string{"# shared", `"AGENTS.md"`, ".vscode/mcp.json"} {
+			if !strings.Contains(out, wanted) {
+				t.Errorf("output should contain %q, got:\n%s", wanted, out)
+			}
+		}
+	})
+
+	t.Run("returns content unchanged without matching keys", func(t *testing.T) {
+		got, err := StripKeys([]byte(keysTestConfig), []string{"templates_dir", "sync.local_keys", "editor.nested"})
+		if err != nil {
+			t.Fatalf("StripKeys failed: %v", err)
+		}
+		if string(got) != keysTestConfig {
+			t.Errorf("content changed:\n%s", got)
+		}
+	})
+
+	t.Run("rejects invalid YAML", func(t *testing.T) {
+		if _, err := StripKeys([]byte("- a\n- b\n"), []string{"editor"}); err == nil {
+			t.Error("expected error for a non-mapping config")
+		}
+	})
+}
+
+func TestMergeKeys(t *testing.T) {
+	remote := "includes:\n  - CLAUDE.md\neditor: code\n"
+
+	t.Run("takes local values of local keys", func(t *testing.T) {
+		got, err := MergeKeys([]byte(remote), []byte(keysTestConfig), []string{"editor", "sync.templates"})
+		if err != nil {
+			t.Fatalf("MergeKeys failed: %v", err)
+		}
+		cfg := parseConfig(t, got)
+		if cfg.Editor != "vim" {
+			t.Errorf("Editor = %q, want %q", cfg.Editor, "vim")
+		}
+		if len(cfg.Includes) != 1 || cfg.Includes[0] != "CLAUDE.md" {
+			t.Errorf("Includes = %v, want remote includes", cfg.Includes)
+		}
+		if len(cfg.Sync.Templates.Exclude) != 1 || cfg.Sync.Templates.Exclude[0] != "work-*" {
+			t.Errorf("Sync.Templates.Exclude = %v, want local value", cfg.Sync.Templates.Exclude)
+		}
+		if len(cfg.Sync.Encrypt) != 0 {
+			t.Errorf("Sync.Encrypt = %v, want it left out", cfg.Sync.Encrypt)
+		}
+	})
+
+	t.Run("removes local keys missing locally", func(t *testing.T) {
+		got, err := MergeKeys([]byte(remote), []byte("includes: []\n"), []string{"editor"})
+		if err != nil {
+			t.Fatalf("MergeKeys failed: %v", err)
+		}
+		if cfg := parseConfig(t, got); cfg.Editor != "" {
+			t.Errorf("Editor = %q, want it removed", cfg.Editor)
+		}
+	})
+
+	t.Run("returns content unchanged when values match", func(t *testing.T) {
+		base := "# comment\neditor:   vim\n"
+		got, err := MergeKeys([]byte(base), []byte(keysTestConfig), []string{"editor"})
+		if err != nil {
+			t.Fatalf("MergeKeys failed: %v", err)
+		}
+		if string(got) != base {
+			t.Errorf("content changed:\n%s", got)
+		}
+	})
+}
+
+func parseConfig(t *testing.T, data []byte) Config {
+	t.Helper()
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("parse merged config: %v\n%s", err, data)
+	}
+	return cfg
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Preset is a built-in set of includes and excludes for an AI coding tool,
+// selected by name with the presets key.
+type Preset struct {
+	Name        string
+	Description string
+	Includes    []string
+	Excludes    []string
+}
+
+// Presets lists the built-in presets.
+var Presets = []Preset{
+	{
+		Name:        "copilot",
+		Description: "GitHub Copilot",
+		Includes:    DefaultIncludes,
+	},
+	{
+		Name:        "claude",
+		Description: "Claude Code",
+		Includes:    []string{"AGENTS.md", "CLAUDE.md", ".claude/settings.json", ".mcp.json"},
+		Excludes:    []string{"CLAUDE.local.md", ".claude/settings.local.json"},
+	},
+	{
+		Name:        "gemini",
+		Description: "Gemini CLI",
+		Includes:    []string{"AGENTS.md", "GEMINI.md", ".gemini/settings.json", ".gemini/system.md"},
+	},
+	{
+		Name:        "cursor",
+		Description: "Cursor",
+		Includes:    []string{"AGENTS.md", ".cursorrules", ".cursor/rules/*.mdc", ".cursor/mcp.json"},
+	},
+	{
+		Name:        "windsurf",
+		Description: "Windsurf",
+		Includes:    []string{"AGENTS.md", ".windsurfrules", ".windsurf/rules/*.md"},
+	},
+	{
+		Name:        "cline",
+		Description: "Cline",
+		Includes:    []string{"AGENTS.md", ".clinerules"},
+	},
+	{
+		Name:        "kilocode",
+		Description: "Kilo Code",
+		Includes:    []string{"AGENTS.md", ".kilocode/rules/*.md"},
+	},
+	{
+		Name:        "roo",
+		Description: "Roo Code",
+		Includes:    []string{"AGENTS.md", ".roorules", ".roo/rules/*.mdc"},
+	},
+}
+
+// LookupPreset returns the built-in preset with the given name.
+func LookupPreset(name string) (Preset, bool) {
+	i := slices.IndexFunc(Presets, func(p Preset) bool { return p.Name == name })
+	if i < 0 {
+		return Preset{}, false
+	}
+	return Presets[i], true
+}
+
+// PresetNames returns the names of the built-in presets.
+func PresetNames() []string {
+	names := make([]string, len(Presets))
+	for i, p := range Presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// ExpandPresets returns includes and excludes with those of the named
+// presets added in front, without duplicates. They are returned unchanged if
+// no presets are named.
+func ExpandPresets(names, includes, excludes []string) ([]string, []string, error) {
+	if len(names) == 0 {
+		return includes, excludes, nil
+	}
+	var allIncludes, allExcludes []string
+	for _, name := range names {
+		preset, ok := LookupPreset(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown preset %q (see 'dotgh config presets')", name)
+		}
+		allIncludes = appendNew(allIncludes, preset.Includes...)
+		allExcludes = appendNew(allExcludes, preset.Excludes...)
+	}
+	return appendNew(allIncludes, includes...), appendNew(allExcludes, excludes...), nil
+}
+
+// expandPresets adds the includes and excludes of the presets named in the
+// config and in its profiles to their own.
+func (c *Config) expandPresets() error {
+	var err error
+	if c.Includes, c.Excludes, err = ExpandPresets(c.Presets, c.Includes, c.Excludes); err != nil {
+		return err
+	}
+	for name, p := range c.Profiles {
+		if p.Includes, p.Excludes, err = ExpandPresets(p.Presets, p.Includes, p.Excludes); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+		c.Profiles[name] = p
+	}
+	return nil
+}
+
+// appendNew appends the items that are not in list yet.
+func appendNew(list []string, items ...string) []string {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandPresets(t *testing.T) {
+	includes, excludes, err := ExpandPresets([]string{"claude", "cursor"}, []string{"CLAUDE.md", "docs/*.md"}, []string{"local.md"})
+	if err != nil {
+		t.Fatalf("ExpandPresets() error = %v", err)
+	}
+	wantIncludes := []string{"AGENTS.md", "CLAUDE.md", ".claude/settings.json", ".mcp.json", ".cursorrules", ".cursor/rules/*.mdc", ".cursor/mcp.json", "docs/*.md"}
+	if !reflect.DeepEqual(includes, wantIncludes) {
+		t.Errorf("includes = %v, want %v", includes, wantIncludes)
+	}
+	wantExcludes := []string{"CLAUDE.local.md", ".claude/settings.local.json", "local.md"}
+	if !reflect.DeepEqual(excludes, wantExcludes) {
+		t.Errorf("excludes = %v, want %v", excludes, wantExcludes)
+	}
+
+	if includes, excludes, err := ExpandPresets(nil, nil, nil); err != nil || includes != nil || excludes != nil {
+		t.Errorf("ExpandPresets() without presets = %v, %v, %v, want nil lists", includes, excludes, err)
+	}
+	if _, _, err := ExpandPresets([]string{"vim"}, nil, nil); err == nil {
+		t.Error("ExpandPresets() should fail on an unknown preset")
+	}
+}
+
+func TestLoadFromDirWithPresets(t *testing.T) {
+	t.Setenv(ProfileEnvVar, "gemini")
+	dir := t.TempDir()
+	content := "presets: [copilot]\nincludes:\n  - CLAUDE.md\nprofiles:\n  gemini:\n    presets: [gemini]\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	base, err := LoadBaseFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadBaseFromDir() error = %v", err)
+	}
+	if want := append(append([]string{}, DefaultIncludes...), "CLAUDE.md"); !reflect.DeepEqual(base.Includes, want) {
+		t.Errorf("Includes = %v, want %v", base.Includes, want)
+	}
+
+	cfg, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir() error = %v", err)
+	}
+	gemini, _ := LookupPreset("gemini")
+	if !reflect.DeepEqual(cfg.Includes, gemini.Includes) {
+		t.Errorf("Includes with the gemini profile = %v, want %v", cfg.Includes, gemini.Includes)
+	}
+	if want := []string{"gemini"}; !reflect.DeepEqual(cfg.Presets, want) || cfg.Origin("presets") != "profile gemini" {
+		t.Errorf("Presets = %v from %q, want %v from the profile", cfg.Presets, cfg.Origin("presets"), want)
+	}
+}
+
+func TestSchemaListsPresets(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "schema", "config.schema.json"))
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	var schema struct {
+		Defs struct {
+			Presets struct {
+				Items struct {
+					Enum []string `json:"enum"`
+				} `json:"items"`
+			} `json:"presets"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	if got := schema.Defs.Presets.Items.Enum; !reflect.DeepEqual(got, PresetNames()) {
+		t.Errorf("schema presets = %v, want %v", got, PresetNames())
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ProfileEnvVar is the environment variable selecting the config profile
+// when the --profile flag is not given.
+const ProfileEnvVar = "DOTGH_PROFILE"
+
+// Profile is a named set of settings that override the base configuration.
+// Unset fields keep the base values.
+type Profile struct {
+	Editor       string   `yaml:"editor,omitempty"`
+	TemplatesDir string   `yaml:"templates_dir,omitempty"`
+	Presets      []string `yaml:"presets,omitempty"`
+	Includes     []string `yaml:"includes,omitempty"`
+	Excludes     []string `yaml:"excludes,omitempty"`
+}
+
+// selectedProfile is the profile selected with SetProfile.
+var selectedProfile string
+
+// SetProfile selects the config profile applied by Load and LoadFromDir.
+// An empty name falls back to the DOTGH_PROFILE environment variable.
+func SetProfile(name string) {
+	selectedProfile = name
+}
+
+// SelectedProfile returns the name of the selected config profile, or "" if
+// none is selected.
+func SelectedProfile() string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	return os.Getenv(ProfileEnvVar)
+}
+
+// ProfileNames returns the names of the profiles defined in the config,
+// sorted by name.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile overrides the base settings with those of the named profile
+// and records it as the active profile. An empty name leaves the config
+// unchanged.
+func (c *Config) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (see 'dotgh config profiles')", name)
+	}
+
+	origin := fmt.Sprintf("profile %s", name)
+	if profile.Editor != "" {
+		c.Editor = profile.Editor
+		c.setOrigin("editor", origin)
+	}
+	if profile.TemplatesDir != "" {
+		c.TemplatesDir = profile.TemplatesDir
+		c.setOrigin("templates_dir", origin)
+	}
+	if profile.Presets != nil {
+		c.Presets = profile.Presets
+		c.setOrigin("presets", origin)
+	}
+	if profile.Includes != nil {
+		c.Includes = profile.Includes
+		c.setOrigin("includes", origin)
+	}
+	if profile.Excludes != nil {
+		c.Excludes = profile.Excludes
+		c.setOrigin("excludes", origin)
+	}
+	c.ActiveProfile = name
+	return nil
+}
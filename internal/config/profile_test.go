@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const profilesTestConfig = `editor: vim
+includes:
+  - "AGENTS.md"
+excludes:
+  - "local.md"
+profiles:
+  claude:
+    includes:
+      - "CLAUDE.md"
+    editor: code --wait
+  bare:
+    excludes: []
+`
+
+func TestLoadFromDirWithProfile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(profilesTestConfig), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		flag, env    string
+		wantProfile  string
+		wantEditor   string
+		wantIncludes []string
+		wantExcludes []string
+		wantErr      string
+	}{
+		{
+			name:         "no profile",
+			wantEditor:   "vim",
+			wantIncludes: []string{"AGENTS.md"},
+			wantExcludes: []string{"local.md"},
+		},
+		{
+			name:         "profile from environment",
+			env:          "claude",
+			wantProfile:  "claude",
+			wantEditor:   "code --wait",
+			wantIncludes: []string{"CLAUDE.md"},
+			wantExcludes: []string{"local.md"},
+		},
+		{
+			name:         "flag overrides environment",
+			flag:         "bare",
+			env:          "claude",
+			wantProfile:  "bare",
+			wantEditor:   "vim",
+			wantIncludes: []string{"AGENTS.md"},
+			wantExcludes: []string{},
+		},
+		{
+			name:    "unknown profile",
+			env:     "missing",
+			wantErr: `unknown profile "missing"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProfileEnvVar, tt.env)
+			SetProfile(tt.flag)
+			defer SetProfile("")
+
+			cfg, err := LoadFromDir(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFromDir() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromDir() error = %v", err)
+			}
+			if cfg.ActiveProfile != tt.wantProfile {
+				t.Errorf("ActiveProfile = %q, want %q", cfg.ActiveProfile, tt.wantProfile)
+			}
+			if cfg.Editor != tt.wantEditor {
+				t.Errorf("Editor = %q, want %q", cfg.Editor, tt.wantEditor)
+			}
+			if !reflect.DeepEqual(cfg.Includes, tt.wantIncludes) {
+				t.Errorf("Includes = %v, want %v", cfg.Includes, tt.wantIncludes)
+			}
+			if !reflect.DeepEqual(cfg.Excludes, tt.wantExcludes) {
+				t.Errorf("Excludes = %v, want %v", cfg.Excludes, tt.wantExcludes)
+			}
+		})
+	}
+
+	t.Run("base config ignores the selected profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "missing")
+		cfg, err := LoadBaseFromDir(dir)
+		if err != nil {
+			t.Fatalf("LoadBaseFromDir() error = %v", err)
+		}
+		if got := cfg.ProfileNames(); !reflect.DeepEqual(got, []string{"bare", "claude"}) {
+			t.Errorf("ProfileNames() = %v", got)
+		}
+	})
+}
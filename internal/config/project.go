@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFileName is the name of the project config file, looked up in the
+// working directory and its parents.
+const ProjectFileName = ".dotgh.yaml"
+
+// Project is the configuration in a project config file. It is layered over
+// the user configuration: Includes replaces the includes, Excludes is added
+// to the excludes, and Template sets the default template. The includes and
+// excludes of Presets are added to Includes and Excludes when it is loaded.
+type Project struct {
+	Presets  []string `yaml:"presets,omitempty"`
+	Includes []string `yaml:"includes,omitempty"`
+	Excludes []string `yaml:"excludes,omitempty"`
+	Template string   `yaml:"template,omitempty"`
+}
+
+// FindProjectFile returns the path of the project config file that applies
+// to dir: the first one found in dir or its parents. It returns "" if there
+// is none.
+func FindProjectFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadProject loads a project config file.
+func LoadProject(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read project config file: %w", err)
+	}
+	var project Project
+	if _, err := decodeStrict(data, &project); err != nil {
+		return nil, fmt.Errorf("parse project config file %s: %w", path, err)
+	}
+	if project.Includes, project.Excludes, err = ExpandPresets(project.Presets, project.Includes, project.Excludes); err != nil {
+		return nil, fmt.Errorf("project config file %s: %w", path, err)
+	}
+	return &project, nil
+}
+
+// ApplyProject layers a project config, loaded from path, over the config.
+func (c *Config) ApplyProject(p *Project, path string) {
+	if p.Presets != nil {
+		c.Presets = p.Presets
+		c.setOrigin("presets", path)
+	}
+	if p.Includes != nil {
+		c.Includes = p.Includes
+		c.setOrigin("includes", path)
+	}
+	if len(p.Excludes) > 0 {
+		if len(c.Excludes) > 0 {
+			c.setOrigin("excludes", c.Origin("excludes")+" + "+path)
+		} else {
+			c.setOrigin("excludes", path)
+		}
+		c.Excludes = append(append([]string{}, c.Excludes...), p.Excludes...)
+	}
+	if p.Template != "" {
+		c.Template = p.Template
+		c.setOrigin("template", path)
+	}
+	c.ProjectFile = path
+}
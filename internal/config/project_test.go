@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveWithProject(t *testing.T) {
+	t.Setenv(ProfileEnvVar, "")
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("editor: vim\nincludes:\n  - AGENTS.md\n  - CLAUDE.md\nexcludes:\n  - local.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	workDir := filepath.Join(projectDir, "src", "pkg")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("failed to create work directory: %v", err)
+	}
+
+	t.Run("without a project file", func(t *testing.T) {
+		if path := FindProjectFile(workDir); path != "" {
+			t.Fatalf("FindProjectFile() = %q, want none", path)
+		}
+		cfg, err := Resolve(configDir, workDir)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if cfg.ProjectFile != "" || cfg.Template != "" {
+			t.Errorf("ProjectFile = %q, Template = %q, want both empty", cfg.ProjectFile, cfg.Template)
+		}
+		if got := cfg.Origin("editor"); got != configPath {
+			t.Errorf("Origin(editor) = %q, want %q", got, configPath)
+		}
+		if got := cfg.Origin("template"); got != OriginDefault {
+			t.Errorf("Origin(template) = %q, want %q", got, OriginDefault)
+		}
+	})
+
+	projectPath := filepath.Join(projectDir, ProjectFileName)
+	if err := os.WriteFile(projectPath, []byte("template: web\nexcludes:\n  - AGENTS.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write project config: %v", err)
+	}
+
+	t.Run("project file in a parent directory", func(t *testing.T) {
+		if path := FindProjectFile(workDir); path != projectPath {
+			t.Fatalf("FindProjectFile() = %q, want %q", path, projectPath)
+		}
+		cfg, err := Resolve(configDir, workDir)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if cfg.Template != "web" {
+			t.Errorf("Template = %q, want %q", cfg.Template, "web")
+		}
+		if want := []string{"AGENTS.md", "CLAUDE.md"}; !reflect.DeepEqual(cfg.Includes, want) {
+			t.Errorf("Includes = %v, want %v", cfg.Includes, want)
+		}
+		if want := []string{"local.md", "AGENTS.md"}; !reflect.DeepEqual(cfg.Excludes, want) {
+			t.Errorf("Excludes = %v, want %v", cfg.Excludes, want)
+		}
+		if got, want := cfg.Origin("excludes"), configPath+" + "+projectPath; got != want {
+			t.Errorf("Origin(excludes) = %q, want %q", got, want)
+		}
+		if got := cfg.Origin("template"); got != projectPath {
+			t.Errorf("Origin(template) = %q, want %q", got, projectPath)
+		}
+		if got := cfg.Origin("includes"); got != configPath {
+			t.Errorf("Origin(includes) = %q, want %q", got, configPath)
+		}
+	})
+
+	t.Run("project includes replace the user includes", func(t *testing.T) {
+		if err := os.WriteFile(projectPath, []byte("includes:\n  - .cursor/rules/*.mdc\n"), 0644); err != nil {
+			t.Fatalf("failed to write project config: %v", err)
+		}
+		cfg, err := Resolve(configDir, workDir)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if want := []string{".cursor/rules/*.mdc"}; !reflect.DeepEqual(cfg.Includes, want) {
+			t.Errorf("Includes = %v, want %v", cfg.Includes, want)
+		}
+		if want := []string{"local.md"}; !reflect.DeepEqual(cfg.Excludes, want) {
+			t.Errorf("Excludes = %v, want %v", cfg.Excludes, want)
+		}
+		if got := cfg.Origin("includes"); got != projectPath {
+			t.Errorf("Origin(includes) = %q, want %q", got, projectPath)
+		}
+	})
+
+	t.Run("invalid project file", func(t *testing.T) {
+		if err := os.WriteFile(projectPath, []byte("includes: [\n"), 0644); err != nil {
+			t.Fatalf("failed to write project config: %v", err)
+		}
+		if _, err := Resolve(configDir, workDir); err == nil {
+			t.Error("expected error for an invalid project config file")
+		}
+	})
+}
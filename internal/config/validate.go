@@ -0,0 +1,457 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaURL is the location of the JSON Schema of the config file. The
+// schema of the project config file is project.schema.json next to it.
+const SchemaURL = "https://raw.githubusercontent.com/openjny/dotgh/main/schema/config.schema.json"
+
+// Problem is an invalid setting found in a config file.
+type Problem struct {
+	// Line and Column locate the setting in the file. They are 0 for
+	// problems that have no position, such as a missing key.
+	Line   int
+	Column int
+	// Key is the dotted path of the setting (e.g. "sync.timeout").
+	Key     string
+	Message string
+}
+
+// String formats the problem as "line:column: key: message".
+func (p Problem) String() string {
+	var sb strings.Builder
+	if p.Line > 0 {
+		fmt.Fprintf(&sb, "%d:%d: ", p.Line, p.Column)
+	}
+	if p.Key != "" {
+		sb.WriteString(p.Key + ": ")
+	}
+	sb.WriteString(p.Message)
+	return sb.String()
+}
+
+// ValidateFile checks the config file at path. Besides the problems that
+// make loading fail (unknown keys and values of the wrong type), it reports
+// invalid glob patterns, empty includes, unknown presets and modes, invalid
+// durations and regular expressions, and templates directories that cannot
+// be written.
+func ValidateFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	return configProblems(data), nil
+}
+
+// configProblems checks config file content like ValidateFile.
+func configProblems(data []byte) []Problem {
+	problems, root := validateData(data, reflect.TypeOf(Config{}))
+	if root != nil && root.Kind == yaml.MappingNode && keyIndex(root, "includes") < 0 && keyIndex(root, "presets") < 0 {
+		problems = append(problems, Problem{Key: "includes", Message: "not set, so pull and push copy no files"})
+	}
+	sortProblems(problems)
+	return problems
+}
+
+// ValidateProjectFile checks the project config file at path, like
+// ValidateFile.
+func ValidateProjectFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read project config file: %w", err)
+	}
+	problems, _ := validateData(data, reflect.TypeOf(Project{}))
+	sortProblems(problems)
+	return problems, nil
+}
+
+// validateData checks config file content against the struct type t and the
+// value rules of the config keys. It returns the root node, or nil if data
+// holds no document or is not valid YAML.
+func validateData(data []byte, t reflect.Type) ([]Problem, *yaml.Node) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Problem{syntaxProblem(err)}, nil
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	problems := checkSchema(root, t, "")
+	if root.Kind == yaml.MappingNode {
+		problems = append(problems, checkValues(root)...)
+	}
+	return problems, root
+}
+
+// yamlLineError matches the position in errors of the YAML parser.
+var yamlLineError = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// syntaxProblem converts a YAML parse error into a problem.
+func syntaxProblem(err error) Problem {
+	msg := err.Error()
+	if m := yamlLineError.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Problem{Line: line, Column: 1, Message: m[2]}
+	}
+	return Problem{Message: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// sortProblems sorts problems by position. Problems without a position come
+// last.
+func sortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// decodeStrict decodes config file content into out, a pointer to a struct.
+// Unlike yaml.Unmarshal, it rejects unknown keys and values of the wrong
+// type, reporting their position. It returns the root mapping, or nil if
+// data holds no document.
+func decodeStrict(data []byte, out any) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if problems := checkSchema(root, reflect.TypeOf(out).Elem(), ""); len(problems) > 0 {
+		msg := problems[0].String()
+		if len(problems) > 1 {
+			msg += fmt.Sprintf(" (and %d more problems)", len(problems)-1)
+		}
+		return nil, fmt.Errorf("%s; run 'dotgh config validate' for details", msg)
+	}
+	if err := root.Decode(out); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// checkSchema checks that node can be decoded into a value of type t,
+// reporting unknown keys and values of the wrong type. key is the dotted
+// path of node.
+func checkSchema(node *yaml.Node, t reflect.Type, key string) []Problem {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	var problems []Problem
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return []Problem{mismatch(node, key, "a mapping")}
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			field, ok := fields[k.Value]
+			if !ok {
+				problems = append(problems, Problem{
+					Line:    k.Line,
+					Column:  k.Column,
+					Key:     joinKey(key, k.Value),
+					Message: unknownKeyMessage(k.Value, fields),
+				})
+				continue
+			}
+			problems = append(problems, checkSchema(v, field, joinKey(key, k.Value))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return []Problem{mismatch(node, key, "a mapping")}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			problems = append(problems, checkSchema(v, t.Elem(), joinKey(key, k.Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return []Problem{mismatch(node, key, "a list")}
+		}
+		for _, item := range node.Content {
+			problems = append(problems, checkSchema(item, t.Elem(), key)...)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode || node.Decode(reflect.New(t).Interface()) != nil {
+			return []Problem{mismatch(node, key, "a "+t.Kind().String())}
+		}
+	}
+	return problems
+}
+
+// yamlFields maps the YAML keys of the struct type t to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownKeyMessage describes an unknown key, suggesting the known key it is
+// most likely a typo of.
+func unknownKeyMessage(name string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for known := range fields {
+		if d := editDistance(name, known); d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	if best == "" {
+		return "unknown key"
+	}
+	return fmt.Sprintf("unknown key (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// mismatch reports a value that is not of the wanted kind.
+func mismatch(node *yaml.Node, key, want string) Problem {
+	var got string
+	switch node.Kind {
+	case yaml.MappingNode:
+		got = "a mapping"
+	case yaml.SequenceNode:
+		got = "a list"
+	default:
+		got = fmt.Sprintf("%q", node.Value)
+	}
+	return Problem{Line: node.Line, Column: node.Column, Key: key, Message: fmt.Sprintf("expected %s, got %s", want, got)}
+}
+
+// joinKey appends name to the dotted path key.
+func joinKey(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
+
+// Keys whose values checkValues checks. A "*" matches any name in a mapping,
+// such as a profile name.
+var (
+	globKeys = []string{
+		"includes",
+		"excludes",
+		"secrets.allow_paths",
+		"sync.encrypt",
+		"sync.templates.include",
+		"sync.templates.exclude",
+		"sync.profiles.*.templates.include",
+		"sync.profiles.*.templates.exclude",
+		"profiles.*.includes",
+		"profiles.*.excludes",
+	}
+	includesKeys     = []string{"includes", "profiles.*.includes"}
+	presetsKeys      = []string{"presets", "profiles.*.presets"}
+	templatesDirKeys = []string{"templates_dir", "profiles.*.templates_dir"}
+	enumKeys         = map[string][]string{
+		"auto_sync":        {AutoSyncOff, AutoSyncCommit, AutoSyncPush},
+		"secrets.mode":     {SecretsModeBlock, SecretsModeWarn, SecretsModeOff},
+		"sync.git_backend": {"exec", "go-git"},
+	}
+)
+
+// checkValues checks the values of the keys under the root mapping.
+func checkValues(root *yaml.Node) []Problem {
+	var problems []Problem
+
+	for _, pattern := range globKeys {
+		for _, e := range findKeys(root, pattern) {
+			if e.value.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, item := range e.value.Content {
+				if _, err := filepath.Match(item.Value, ""); err != nil {
+					problems = append(problems, Problem{Line: item.Line, Column: item.Column, Key: e.name, Message: fmt.Sprintf("invalid glob pattern %q", item.Value)})
+				}
+			}
+		}
+	}
+
+	for _, pattern := range includesKeys {
+		for _, e := range findKeys(root, pattern) {
+			empty := e.value.Kind == yaml.SequenceNode && len(e.value.Content) == 0
+			if empty || e.value.Tag == "!!null" {
+				problems = append(problems, Problem{Line: e.key.Line, Column: e.key.Column, Key: e.name, Message: "empty, so pull and push copy no files"})
+			}
+		}
+	}
+
+	for _, pattern := range presetsKeys {
+		for _, e := range findKeys(root, pattern) {
+			if e.value.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, item := range e.value.Content {
+				if _, ok := LookupPreset(item.Value); !ok {
+					problems = append(problems, Problem{Line: item.Line, Column: item.Column, Key: e.name, Message: fmt.Sprintf("unknown preset %q (one of %s)", item.Value, strings.Join(PresetNames(), ", "))})
+				}
+			}
+		}
+	}
+
+	for _, pattern := range templatesDirKeys {
+		for _, e := range findKeys(root, pattern) {
+			if e.value.Kind != yaml.ScalarNode || e.value.Value == "" {
+				continue
+			}
+			if err := checkWritableDir(expandTilde(e.value.Value)); err != nil {
+				problems = append(problems, Problem{Line: e.value.Line, Column: e.value.Column, Key: e.name, Message: err.Error()})
+			}
+		}
+	}
+
+	for key, allowed := range enumKeys {
+		for _, e := range findKeys(root, key) {
+			if e.value.Kind == yaml.ScalarNode && e.value.Value != "" && !slices.Contains(allowed, e.value.Value) {
+				problems = append(problems, Problem{Line: e.value.Line, Column: e.value.Column, Key: e.name, Message: fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), e.value.Value)})
+			}
+		}
+	}
+
+	for _, key := range []string{"sync.timeout", "sync.pull_if_stale"} {
+		for _, e := range findKeys(root, key) {
+			if e.value.Kind != yaml.ScalarNode || e.value.Value == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(e.value.Value); err != nil {
+				problems = append(problems, Problem{Line: e.value.Line, Column: e.value.Column, Key: e.name, Message: fmt.Sprintf("invalid duration %q (use e.g. \"30s\" or \"5m\")", e.value.Value)})
+			}
+		}
+	}
+
+	for _, e := range findKeys(root, "secrets.allowlist") {
+		if e.value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range e.value.Content {
+			if _, err := regexp.Compile(item.Value); err != nil {
+				problems = append(problems, Problem{Line: item.Line, Column: item.Column, Key: e.name, Message: fmt.Sprintf("invalid regular expression %q", item.Value)})
+			}
+		}
+	}
+
+	return problems
+}
+
+// keyEntry is a key found by findKeys.
+type keyEntry struct {
+	name       string
+	key, value *yaml.Node
+}
+
+// findKeys returns the keys under the mapping node that match the dotted
+// pattern, in which "*" matches any name.
+func findKeys(node *yaml.Node, pattern string) []keyEntry {
+	var found []keyEntry
+	var walk func(node *yaml.Node, path []string, prefix string)
+	walk = func(node *yaml.Node, path []string, prefix string) {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if path[0] != "*" && path[0] != k.Value {
+				continue
+			}
+			name := joinKey(prefix, k.Value)
+			if len(path) == 1 {
+				found = append(found, keyEntry{name: name, key: k, value: v})
+			} else {
+				walk(v, path[1:], name)
+			}
+		}
+	}
+	walk(node, strings.Split(pattern, "."), "")
+	return found
+}
+
+// checkWritableDir reports why files cannot be created in dir, or in its
+// nearest existing parent if dir does not exist yet.
+func checkWritableDir(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			f, err := os.CreateTemp(dir, ".dotgh-write-test-*")
+			if err != nil {
+				return fmt.Errorf("%s is not writable", dir)
+			}
+			name := f.Name()
+			_ = f.Close()
+			_ = os.Remove(name)
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+}
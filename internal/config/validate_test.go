@@ -0,0 +1,183 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "default config",
+			content: GenerateDefaultConfigContent(),
+		},
+		{
+			name:    "unknown keys",
+			content: "include:\n  - AGENTS.md\nsync:\n  timeot: 30s\n",
+			want: []string{
+				`1:1: include: unknown key (did you mean "includes"?)`,
+				`4:3: sync.timeot: unknown key (did you mean "timeout"?)`,
+				"includes: not set, so pull and push copy no files",
+			},
+		},
+		{
+			name:    "wrong types",
+			content: "includes: AGENTS.md\nprofiles:\n  work:\n    editor: [vim]\n",
+			want: []string{
+				`1:11: includes: expected a list, got "AGENTS.md"`,
+				"4:13: profiles.work.editor: expected a string, got a list",
+			},
+		},
+		{
+			name:    "invalid values",
+			content: "includes: []\nexcludes:\n  - \"[a-\"\nsecrets:\n  mode: strict\nsync:\n  timeout: 5\n  pull_if_stale: daily\nauto_sync: always\n",
+			want: []string{
+				"1:1: includes: empty, so pull and push copy no files",
+				`3:5: excludes: invalid glob pattern "[a-"`,
+				`5:9: secrets.mode: must be one of block, warn, off, got "strict"`,
+				`7:12: sync.timeout: invalid duration "5" (use e.g. "30s" or "5m")`,
+				`8:18: sync.pull_if_stale: invalid duration "daily" (use e.g. "30s" or "5m")`,
+				`9:12: auto_sync: must be one of off, commit, push, got "always"`,
+			},
+		},
+		{
+			name:    "syntax error",
+			content: "includes:\n  - a\n b: c\n",
+			want:    []string{"2:1: did not find expected key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			problems, err := ValidateFile(path)
+			if err != nil {
+				t.Fatalf("ValidateFile() error = %v", err)
+			}
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ValidateFile() =\n  %s\nwant\n  %s", strings.Join(got, "\n  "), strings.Join(tt.want, "\n  "))
+			}
+		})
+	}
+}
+
+func TestValidateFileTemplatesDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	content := "templates_dir: " + filepath.Join(dir, "new", "templates") + "\nincludes: [AGENTS.md]\nprofiles:\n  work:\n    templates_dir: " + file + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	problems, err := ValidateFile(path)
+	if err != nil {
+		t.Fatalf("ValidateFile() error = %v", err)
+	}
+	if len(problems) != 1 || problems[0].Key != "profiles.work.templates_dir" || !strings.Contains(problems[0].Message, "not a directory") {
+		t.Errorf("ValidateFile() = %v, want only profiles.work.templates_dir not a directory", problems)
+	}
+}
+
+func TestLoadFromDirRejectsUnknownKeys(t *testing.T) {
+	t.Setenv(ProfileEnvVar, "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("includes:\n  - AGENTS.md\nexclude:\n  - local.md\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	_, err := LoadFromDir(dir)
+	if err == nil {
+		t.Fatal("LoadFromDir() should fail on an unknown key")
+	}
+	if want := `3:1: exclude: unknown key (did you mean "excludes"?)`; !strings.Contains(err.Error(), want) {
+		t.Errorf("LoadFromDir() error = %v, want it to contain %q", err, want)
+	}
+}
+
+// TestSchemaMatchesConfig checks that the published JSON Schemas describe
+// the same keys as the config structs.
+func TestSchemaMatchesConfig(t *testing.T) {
+	tests := []struct {
+		file string
+		typ  reflect.Type
+	}{
+		{"config.schema.json", reflect.TypeOf(Config{})},
+		{"project.schema.json", reflect.TypeOf(Project{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("..", "..", "schema", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read schema: %v", err)
+			}
+			var schema map[string]any
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatalf("failed to parse schema: %v", err)
+			}
+			compareSchema(t, schema, schema, tt.typ, "")
+		})
+	}
+}
+
+// compareSchema compares the keys of the struct type typ, and of the structs
+// nested in it, with the properties of node, a subschema of root.
+func compareSchema(t *testing.T, root, node map[string]any, typ reflect.Type, key string) {
+	t.Helper()
+	if ref, ok := node["$ref"].(string); ok {
+		node = root
+		for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node, _ = node[name].(map[string]any)
+		}
+	}
+	if node == nil {
+		t.Errorf("%s: no schema", key)
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		props, _ := node["properties"].(map[string]any)
+		if node["additionalProperties"] != false {
+			t.Errorf("%s: additionalProperties should be false", key)
+		}
+		fields := yamlFields(typ)
+		for name, field := range fields {
+			sub, ok := props[name].(map[string]any)
+			if !ok {
+				t.Errorf("%s: missing from schema", joinKey(key, name))
+				continue
+			}
+			compareSchema(t, root, sub, field, joinKey(key, name))
+		}
+		for name := range props {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("%s: in schema but not in %s", joinKey(key, name), typ.Name())
+			}
+		}
+	case reflect.Map:
+		sub, _ := node["additionalProperties"].(map[string]any)
+		compareSchema(t, root, sub, typ.Elem(), joinKey(key, "*"))
+	}
+}
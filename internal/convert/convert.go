@@ -0,0 +1,155 @@
+// Package convert converts instruction files between the formats of AI
+// coding assistants, such as Copilot instructions, Cursor rules and
+// CLAUDE.md.
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MainRuleName is the file name given to the main rule in formats that keep
+// every rule in a file of its own, such as Cursor.
+const MainRuleName = "main"
+
+// Rule is a set of instructions, the unit that is converted.
+type Rule struct {
+	// Name identifies the rule within its format, e.g. "go" for
+	// .cursor/rules/go.mdc. It is empty for the main instructions file,
+	// such as CLAUDE.md.
+	Name        string
+	Description string
+	// Globs limits the rule to matching files.
+	Globs []string
+	// Always applies the rule to every request, regardless of Globs.
+	Always bool
+	Body   string
+	// Source is the slash-separated path the rule was read from.
+	Source string
+}
+
+// File is a file written by a format.
+type File struct {
+	// Path is slash-separated and relative to the template or project.
+	Path    string
+	Content []byte
+}
+
+// Loss describes information that a conversion could not carry over.
+type Loss struct {
+	// Source is the path of the rule that lost information.
+	Source  string
+	Message string
+}
+
+// Format reads and writes the instruction files of one AI coding assistant.
+type Format interface {
+	// Name is the name of the format, matching its preset (e.g. "cursor").
+	Name() string
+	// Read returns the rules in dir, or none if dir has no files of the format.
+	Read(dir string) ([]Rule, error)
+	// Write renders rules as files, reporting what the format cannot express.
+	Write(rules []Rule) ([]File, []Loss)
+}
+
+// formats lists the supported formats in the order Detect prefers them.
+var formats = []Format{
+	sourceFormat{},
+	copilotFormat{},
+	cursorFormat{},
+	singleFileFormat{name: "claude", path: "CLAUDE.md"},
+	singleFileFormat{name: "gemini", path: "GEMINI.md"},
+	singleFileFormat{name: "agents", path: "AGENTS.md"},
+}
+
+// Names returns the names of the supported formats.
+func Names() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+// Lookup returns the format with the given name.
+func Lookup(name string) (Format, error) {
+	i := slices.IndexFunc(formats, func(f Format) bool { return f.Name() == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown format %q (one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return formats[i], nil
+}
+
+// Detect returns the formats that have files in dir.
+func Detect(dir string) ([]Format, error) {
+	var found []Format
+	for _, f := range formats {
+		rules, err := f.Read(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) > 0 {
+			found = append(found, f)
+		}
+	}
+	return found, nil
+}
+
+// Convert reads the rules of the format from in dir and renders them in the
+// format to.
+func Convert(dir string, from, to Format) ([]File, []Loss, error) {
+	rules, err := from.Read(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil, fmt.Errorf("no %s files found", from.Name())
+	}
+	files, losses := to.Write(rules)
+	return files, losses, nil
+}
+
+// readFile reads the file at the slash-separated path rel in dir. It returns
+// false if the file does not exist.
+func readFile(dir, rel string) (string, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// readDir returns the slash-separated paths of the files in the directory
+// rel in dir whose names end with suffix, sorted by name.
+func readDir(dir, rel, suffix string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), suffix) {
+			paths = append(paths, rel+"/"+entry.Name())
+		}
+	}
+	return paths, nil
+}
+
+// normalizeBody trims blank lines around a rule body and ends it with a
+// single newline.
+func normalizeBody(body string) string {
+	body = strings.Trim(body, "\n")
+	if body == "" {
+		return ""
+	}
+	return body + "\n"
+}
@@ -0,0 +1,262 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func convert(t *testing.T, files map[string]string, from, to string) (map[string]string, []Loss) {
+	t.Helper()
+	fromFormat, err := Lookup(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toFormat, err := Lookup(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, losses, err := Convert(writeFiles(t, files), fromFormat, toFormat)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range out {
+		got[f.Path] = string(f.Content)
+	}
+	return got, losses
+}
+
+var copilotFiles = map[string]string{
+	".github/copilot-instructions.md":             "# Project\n\nUse tabs.\n",
+	".github/instructions/go.instructions.md":     "---\ndescription: \"Go style\"\napplyTo: \"**/*.go,**/go.mod\"\n---\n\nWrap errors.\n",
+	".github/instructions/review.instructions.md": "---\ndescription: Reviews\n---\nBe kind.\n",
+	".github/instructions/all.instructions.md":    "---\napplyTo: '**'\n---\nBe brief.\n",
+}
+
+func TestCopilotToCursor(t *testing.T) {
+	got, losses := convert(t, copilotFiles, "copilot", "cursor")
+	want := map[string]string{
+		".cursor/rules/main.mdc":   "---\nalwaysApply: true\n---\n\n# Project\n\nUse tabs.\n",
+		".cursor/rules/all.mdc":    "---\nalwaysApply: true\n---\n\nBe brief.\n",
+		".cursor/rules/go.mdc":     "---\ndescription: Go style\nglobs: **/*.go,**/go.mod\nalwaysApply: false\n---\n\nWrap errors.\n",
+		".cursor/rules/review.mdc": "---\ndescription: Reviews\nalwaysApply: false\n---\n\nBe kind.\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %#v, want %#v", got, want)
+	}
+	if len(losses) != 0 {
+		t.Errorf("losses = %v, want none", losses)
+	}
+}
+
+func TestCursorToCopilot(t *testing.T) {
+	got, losses := convert(t, map[string]string{
+		".cursor/rules/main.mdc": "---\ndescription:\nglobs:\nalwaysApply: true\n---\nUse tabs.\n",
+		".cursor/rules/go.mdc":   "---\ndescription: Go style\nglobs: *.go, cmd/**\nalwaysApply: false\n---\nWrap errors.\n",
+		".cursor/rules/list.mdc": "---\nglobs:\n  - \"*.ts\"\n  - \"*.tsx\"\n---\nUse strict mode.\n",
+		".cursorrules":           "Ignored, as main.mdc exists.\n",
+	}, "cursor", "copilot")
+	want := map[string]string{
+		".github/copilot-instructions.md":           "Use tabs.\n",
+		".github/instructions/go.instructions.md":   "---\ndescription: \"Go style\"\napplyTo: \"*.go,cmd/**\"\n---\n\nWrap errors.\n",
+		".github/instructions/list.instructions.md": "---\napplyTo: \"*.ts,*.tsx\"\n---\n\nUse strict mode.\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %#v, want %#v", got, want)
+	}
+	if len(losses) != 0 {
+		t.Errorf("losses = %v, want none", losses)
+	}
+}
+
+func TestCursorLegacyRules(t *testing.T) {
+	got, _ := convert(t, map[string]string{".cursorrules": "Use tabs.\n"}, "cursor", "claude")
+	if got["CLAUDE.md"] != "Use tabs.\n" {
+		t.Errorf("CLAUDE.md = %q, want %q", got["CLAUDE.md"], "Use tabs.\n")
+	}
+}
+
+func TestCopilotToClaude(t *testing.T) {
+	got, losses := convert(t, copilotFiles, "copilot", "claude")
+	want := "# Project\n\nUse tabs.\n\n" +
+		"## all\n\nBe brief.\n\n" +
+		"## Go style\n\nApplies to: `**/*.go`, `**/go.mod`\n\nWrap errors.\n\n" +
+		"## Reviews\n\nBe kind.\n"
+	if got["CLAUDE.md"] != want {
+		t.Errorf("CLAUDE.md = %q, want %q", got["CLAUDE.md"], want)
+	}
+	var messages []string
+	for _, loss := range losses {
+		messages = append(messages, loss.Source+": "+loss.Message)
+	}
+	wantLosses := []string{
+		".github/instructions/go.instructions.md: applies to **/*.go, **/go.mod only, but CLAUDE.md applies it to every request",
+		".github/instructions/review.instructions.md: is applied on demand, but CLAUDE.md applies it to every request",
+	}
+	if !reflect.DeepEqual(messages, wantLosses) {
+		t.Errorf("losses = %q, want %q", messages, wantLosses)
+	}
+}
+
+func TestClaudeToGemini(t *testing.T) {
+	got, losses := convert(t, map[string]string{"CLAUDE.md": "\n# Rules\n\nUse tabs.\n\n"}, "claude", "gemini")
+	if got["GEMINI.md"] != "# Rules\n\nUse tabs.\n" {
+		t.Errorf("GEMINI.md = %q", got["GEMINI.md"])
+	}
+	if len(losses) != 0 {
+		t.Errorf("losses = %v, want none", losses)
+	}
+}
+
+func TestConvertNoFiles(t *testing.T) {
+	from, _ := Lookup("cursor")
+	to, _ := Lookup("claude")
+	if _, _, err := Convert(t.TempDir(), from, to); err == nil || !strings.Contains(err.Error(), "no cursor files found") {
+		t.Errorf("Convert() error = %v, want no cursor files found", err)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	if _, err := Lookup("vim"); err == nil || !strings.Contains(err.Error(), "copilot, cursor, claude, gemini") {
+		t.Errorf("Lookup() error = %v, want the list of formats", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"CLAUDE.md":              "Use tabs.\n",
+		".cursor/rules/go.mdc":   "Wrap errors.\n",
+		".cursor/rules/notes.md": "Not a rule.\n",
+	})
+	formats, err := Detect(dir)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	var names []string
+	for _, f := range formats {
+		names = append(names, f.Name())
+	}
+	if want := []string{"cursor", "claude"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Detect() = %v, want %v", names, want)
+	}
+}
+
+func TestParseFrontmatter(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantFields map[string]string
+		wantBody   string
+	}{
+		{
+			name:     "no frontmatter",
+			content:  "# Title\n",
+			wantBody: "# Title\n",
+		},
+		{
+			name:     "unclosed",
+			content:  "---\nglobs: *.go\n",
+			wantBody: "---\nglobs: *.go\n",
+		},
+		{
+			name:       "crlf and quotes",
+			content:    "---\r\ndescription: 'It''s Go'\r\nglobs: \"*.go\"\r\n---\r\nBody\r\n",
+			wantFields: map[string]string{"description": "It's Go", "globs": "*.go"},
+			wantBody:   "Body\n",
+		},
+		{
+			name:       "list items and comments",
+			content:    "---\n# rules\nglobs:\n  - a\n  - 'b'\n---",
+			wantFields: map[string]string{"globs": "a,b"},
+			wantBody:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, body := parseFrontmatter(tt.content)
+			if len(fields) != 0 || len(tt.wantFields) != 0 {
+				if !reflect.DeepEqual(fields, tt.wantFields) {
+					t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+				}
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestCopilotToRules(t *testing.T) {
+	got, losses := convert(t, copilotFiles, "copilot", "rules")
+	want := map[string]string{
+		".dotgh/rules/main.md":   "# Project\n\nUse tabs.\n",
+		".dotgh/rules/all.md":    "---\nalwaysApply: true\n---\n\nBe brief.\n",
+		".dotgh/rules/go.md":     "---\ndescription: \"Go style\"\nglobs: [\"**/*.go\", \"**/go.mod\"]\n---\n\nWrap errors.\n",
+		".dotgh/rules/review.md": "---\ndescription: \"Reviews\"\n---\n\nBe kind.\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %#v, want %#v", got, want)
+	}
+	if len(losses) != 0 {
+		t.Errorf("losses = %v, want none", losses)
+	}
+
+	// Converting back gives the same files
+	back, _ := convert(t, got, "rules", "copilot")
+	for path, content := range copilotFiles {
+		fields, body := parseFrontmatter(content)
+		backFields, backBody := parseFrontmatter(back[path])
+		if normalizeBody(body) != normalizeBody(backBody) || fields["applyTo"] != backFields["applyTo"] {
+			t.Errorf("%s = %q, want the same rule as %q", path, back[path], content)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		".dotgh/rules/main.md": "Use tabs.\n",
+		".dotgh/rules/go.md":   "---\nglobs: *.go\n---\nWrap errors.\n",
+	})
+	files, err := Generate(dir)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{
+		".github/copilot-instructions.md",
+		".github/instructions/go.instructions.md",
+		".cursor/rules/main.mdc",
+		".cursor/rules/go.mdc",
+		"CLAUDE.md",
+		"GEMINI.md",
+		"AGENTS.md",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Generate() paths = %v, want %v", paths, want)
+	}
+
+	if files, err := Generate(t.TempDir()); err != nil || len(files) != 0 {
+		t.Errorf("Generate() without a rules source = %v, %v; want none", files, err)
+	}
+}
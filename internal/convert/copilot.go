@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	copilotMainPath   = ".github/copilot-instructions.md"
+	copilotRulesDir   = ".github/instructions"
+	copilotRuleExt    = ".instructions.md"
+	copilotApplyToAll = "**"
+)
+
+// copilotFormat is GitHub Copilot: .github/copilot-instructions.md applies to
+// every request, and .github/instructions/*.instructions.md apply to the
+// files matching their applyTo globs.
+type copilotFormat struct{}
+
+func (copilotFormat) Name() string { return "copilot" }
+
+func (copilotFormat) Read(dir string) ([]Rule, error) {
+	var rules []Rule
+	content, ok, err := readFile(dir, copilotMainPath)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		_, body := parseFrontmatter(content)
+		rules = append(rules, Rule{Always: true, Body: normalizeBody(body), Source: copilotMainPath})
+	}
+
+	paths, err := readDir(dir, copilotRulesDir, copilotRuleExt)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		content, _, err := readFile(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		fields, body := parseFrontmatter(content)
+		rule := Rule{
+			Name:        strings.TrimSuffix(path[len(copilotRulesDir)+1:], copilotRuleExt),
+			Description: fields["description"],
+			Body:        normalizeBody(body),
+			Source:      path,
+		}
+		// Without applyTo, the instructions are only used when attached by hand.
+		for _, glob := range splitList(fields["applyTo"]) {
+			if glob == copilotApplyToAll {
+				rule.Always = true
+				rule.Globs = nil
+				break
+			}
+			rule.Globs = append(rule.Globs, glob)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (copilotFormat) Write(rules []Rule) ([]File, []Loss) {
+	var files []File
+	var losses []Loss
+	for _, rule := range rules {
+		if rule.Name == "" {
+			if rule.Description != "" {
+				losses = append(losses, Loss{Source: rule.Source, Message: "description dropped, " + copilotMainPath + " has no frontmatter"})
+			}
+			files = append(files, File{Path: copilotMainPath, Content: []byte(rule.Body)})
+			continue
+		}
+		applyTo := strings.Join(rule.Globs, ",")
+		if rule.Always {
+			applyTo = copilotApplyToAll
+		}
+		var fields [][2]string
+		if rule.Description != "" {
+			fields = append(fields, [2]string{"description", strconv.Quote(rule.Description)})
+		}
+		if applyTo != "" {
+			fields = append(fields, [2]string{"applyTo", strconv.Quote(applyTo)})
+		}
+		files = append(files, File{
+			Path:    copilotRulesDir + "/" + rule.Name + copilotRuleExt,
+			Content: renderFrontmatter(fields, rule.Body),
+		})
+	}
+	return files, losses
+}
@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	cursorLegacyPath = ".cursorrules"
+	cursorRulesDir   = ".cursor/rules"
+	cursorRuleExt    = ".mdc"
+)
+
+// cursorFormat is Cursor: .cursor/rules/*.mdc apply to every request with
+// alwaysApply, to the files matching their globs, or when the agent finds
+// their description relevant. The legacy .cursorrules file applies to every
+// request.
+type cursorFormat struct{}
+
+func (cursorFormat) Name() string { return "cursor" }
+
+func (cursorFormat) Read(dir string) ([]Rule, error) {
+	var rules []Rule
+	paths, err := readDir(dir, cursorRulesDir, cursorRuleExt)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		content, _, err := readFile(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		fields, body := parseFrontmatter(content)
+		rule := Rule{
+			Name:        strings.TrimSuffix(path[len(cursorRulesDir)+1:], cursorRuleExt),
+			Description: fields["description"],
+			Globs:       splitList(fields["globs"]),
+			Always:      fields["alwaysApply"] == "true",
+			Body:        normalizeBody(body),
+			Source:      path,
+		}
+		if rule.Always {
+			rule.Globs = nil
+			// The main rule written by this format becomes the main file
+			// of the others again.
+			if rule.Name == MainRuleName {
+				rule.Name = ""
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	content, ok, err := readFile(dir, cursorLegacyPath)
+	if err != nil {
+		return nil, err
+	}
+	if ok && !containsMain(rules) {
+		rules = append([]Rule{{Always: true, Body: normalizeBody(content), Source: cursorLegacyPath}}, rules...)
+	}
+	return rules, nil
+}
+
+func (cursorFormat) Write(rules []Rule) ([]File, []Loss) {
+	var files []File
+	for _, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = MainRuleName
+		}
+		description := rule.Description
+		if strings.ContainsAny(description, ":#\"'\n") {
+			description = strconv.Quote(description)
+		}
+		fields := [][2]string{
+			{"description", description},
+			{"globs", strings.Join(rule.Globs, ",")},
+			{"alwaysApply", strconv.FormatBool(rule.Always)},
+		}
+		files = append(files, File{
+			Path:    cursorRulesDir + "/" + name + cursorRuleExt,
+			Content: renderFrontmatter(fields, rule.Body),
+		})
+	}
+	return files, nil
+}
+
+// containsMain reports whether rules has a main rule.
+func containsMain(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package convert
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseFrontmatter splits content into the fields of its frontmatter and the
+// body that follows. The frontmatter is parsed line by line rather than as
+// YAML, since Cursor writes globs such as *.go unquoted, which YAML rejects.
+// List items ("- x") are joined to the preceding key with commas.
+func parseFrontmatter(content string) (map[string]string, string) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return nil, content
+	}
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, content
+	}
+	header, body := rest[:end], rest[end+len("\n---"):]
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	} else {
+		body = ""
+	}
+
+	fields := make(map[string]string)
+	var last string
+	for _, line := range strings.Split(header, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok && last != "" {
+			if fields[last] != "" {
+				fields[last] += ","
+			}
+			fields[last] += unquote(strings.TrimSpace(item))
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		last = strings.TrimSpace(key)
+		fields[last] = unquote(strings.TrimSpace(value))
+	}
+	return fields, body
+}
+
+// renderFrontmatter renders fields, given as key and value pairs, as
+// frontmatter in front of body. Pairs with an empty value are left out.
+func renderFrontmatter(fields [][2]string, body string) []byte {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, field := range fields {
+		if field[1] != "" {
+			b.WriteString(field[0] + ": " + field[1] + "\n")
+		}
+	}
+	b.WriteString("---\n")
+	if body != "" {
+		b.WriteString("\n" + body)
+	}
+	return []byte(b.String())
+}
+
+// splitList splits a comma-separated or flow-style ([a, b]) list of globs.
+func splitList(value string) []string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		value = value[1 : len(value)-1]
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = unquote(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// unquote removes the quotes around a YAML scalar, if any.
+func unquote(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+		return value[1 : len(value)-1]
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
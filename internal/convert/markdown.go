@@ -0,0 +1,59 @@
+package convert
+
+import (
+	"strings"
+)
+
+// singleFileFormat is a tool that reads all its instructions from one
+// Markdown file applied to every request, such as CLAUDE.md or GEMINI.md.
+// Other rules are appended to the main rule as sections.
+type singleFileFormat struct {
+	name string
+	path string
+}
+
+func (f singleFileFormat) Name() string { return f.name }
+
+func (f singleFileFormat) Read(dir string) ([]Rule, error) {
+	content, ok, err := readFile(dir, f.path)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []Rule{{Always: true, Body: normalizeBody(content), Source: f.path}}, nil
+}
+
+func (f singleFileFormat) Write(rules []Rule) ([]File, []Loss) {
+	var sections []string
+	var losses []Loss
+	for _, rule := range rules {
+		if rule.Name == "" {
+			// The main rule goes first, without a heading.
+			sections = append([]string{rule.Body}, sections...)
+			continue
+		}
+		title := rule.Description
+		if title == "" {
+			title = rule.Name
+		}
+		section := "## " + title + "\n\n"
+		switch {
+		case len(rule.Globs) > 0:
+			section += "Applies to: `" + strings.Join(rule.Globs, "`, `") + "`\n\n"
+			losses = append(losses, Loss{Source: rule.Source, Message: "applies to " + strings.Join(rule.Globs, ", ") + " only, but " + f.path + " applies it to every request"})
+		case !rule.Always:
+			losses = append(losses, Loss{Source: rule.Source, Message: "is applied on demand, but " + f.path + " applies it to every request"})
+		}
+		sections = append(sections, section+rule.Body)
+	}
+	var content string
+	for _, section := range sections {
+		if section = strings.TrimRight(section, "\n"); section == "" {
+			continue
+		}
+		if content != "" {
+			content += "\n"
+		}
+		content += section + "\n"
+	}
+	return []File{{Path: f.path, Content: []byte(content)}}, losses
+}
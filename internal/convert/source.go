@@ -0,0 +1,111 @@
+package convert
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SourceDir is the directory of a template holding its rules source, the
+// single source from which the instruction files of every tool are
+// generated. main.md holds the main instructions; the other *.md files are
+// rules with description, globs and alwaysApply frontmatter.
+const SourceDir = ".dotgh/rules"
+
+// SourceFormatName is the name of the format of the rules source.
+const SourceFormatName = "rules"
+
+const (
+	sourceMainPath = SourceDir + "/" + MainRuleName + sourceRuleExt
+	sourceRuleExt  = ".md"
+)
+
+// sourceFormat is the rules source of a template. It can express every rule,
+// so converting to it is never lossy.
+type sourceFormat struct{}
+
+func (sourceFormat) Name() string { return SourceFormatName }
+
+func (sourceFormat) Read(dir string) ([]Rule, error) {
+	paths, err := readDir(dir, SourceDir, sourceRuleExt)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	for _, path := range paths {
+		content, _, err := readFile(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		fields, body := parseFrontmatter(content)
+		rule := Rule{
+			Name:        strings.TrimSuffix(path[len(SourceDir)+1:], sourceRuleExt),
+			Description: fields["description"],
+			Globs:       splitList(fields["globs"]),
+			Always:      fields["alwaysApply"] == "true",
+			Body:        normalizeBody(body),
+			Source:      path,
+		}
+		if path == sourceMainPath {
+			rule.Name, rule.Always, rule.Globs = "", true, nil
+			// The main rule goes first, as in the other formats.
+			rules = append([]Rule{rule}, rules...)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (sourceFormat) Write(rules []Rule) ([]File, []Loss) {
+	var files []File
+	for _, rule := range rules {
+		path := SourceDir + "/" + rule.Name + sourceRuleExt
+		if rule.Name == "" {
+			path = sourceMainPath
+		}
+		var fields [][2]string
+		if rule.Description != "" {
+			fields = append(fields, [2]string{"description", strconv.Quote(rule.Description)})
+		}
+		if rule.Name != "" && len(rule.Globs) > 0 {
+			globs := make([]string, len(rule.Globs))
+			for i, glob := range rule.Globs {
+				globs[i] = strconv.Quote(glob)
+			}
+			fields = append(fields, [2]string{"globs", "[" + strings.Join(globs, ", ") + "]"})
+		}
+		if rule.Name != "" && rule.Always {
+			fields = append(fields, [2]string{"alwaysApply", "true"})
+		}
+		content := []byte(rule.Body)
+		if len(fields) > 0 {
+			content = renderFrontmatter(fields, rule.Body)
+		}
+		files = append(files, File{Path: path, Content: content})
+	}
+	return files, nil
+}
+
+// HasSource reports whether dir has a rules source.
+func HasSource(dir string) (bool, error) {
+	rules, err := sourceFormat{}.Read(dir)
+	return len(rules) > 0, err
+}
+
+// Generate renders the rules source in dir in every other format. What the
+// formats cannot express is left out, as in Convert.
+func Generate(dir string) ([]File, error) {
+	rules, err := sourceFormat{}.Read(dir)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	var files []File
+	for _, f := range formats {
+		if f.Name() == SourceFormatName {
+			continue
+		}
+		generated, _ := f.Write(rules)
+		files = append(files, generated...)
+	}
+	return files, nil
+}
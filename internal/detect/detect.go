@@ -0,0 +1,151 @@
+// Package detect finds the config files of AI coding tools in a directory,
+// using the built-in presets as the list of known files.
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/glob"
+)
+
+// Tool is an AI coding tool whose files were found.
+type Tool struct {
+	Preset config.Preset
+	// Files are the files of the tool, as slash-separated relative paths.
+	// Files that all tools share, such as AGENTS.md, are left out.
+	Files []string
+}
+
+// Uncovered is a preset pattern matching files that the includes do not
+// cover.
+type Uncovered struct {
+	Pattern string
+	// Tools are the descriptions of the presets that have the pattern,
+	// unless Shared is set.
+	Tools []string
+	// Shared is set if every preset has the pattern, such as AGENTS.md.
+	Shared bool
+	// Files are the uncovered files matching the pattern.
+	Files []string
+}
+
+// Result is the outcome of Scan.
+type Result struct {
+	Tools     []Tool
+	Uncovered []Uncovered
+}
+
+// Patterns returns the patterns of the uncovered files.
+func (r *Result) Patterns() []string {
+	patterns := make([]string, len(r.Uncovered))
+	for i, u := range r.Uncovered {
+		patterns[i] = u.Pattern
+	}
+	return patterns
+}
+
+// UncoveredFiles returns the uncovered files.
+func (r *Result) UncoveredFiles() []string {
+	var files []string
+	for _, u := range r.Uncovered {
+		files = append(files, u.Files...)
+	}
+	return files
+}
+
+// Scan looks for the files of the built-in presets in dir and reports which
+// of them includes and excludes do not cover. Files matching excludes count
+// as covered, since they are left out on purpose.
+func Scan(dir string, includes, excludes []string) (*Result, error) {
+	included, err := glob.ExpandPatterns(dir, includes)
+	if err != nil {
+		return nil, err
+	}
+	covered := func(file string) bool {
+		if slices.Contains(included, file) {
+			return true
+		}
+		for _, pattern := range excludes {
+			if ok, _ := glob.MatchPattern(pattern, file); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	shared := sharedPatterns()
+	result := &Result{}
+	uncovered := make(map[string]*Uncovered)
+	var order []string
+	for _, preset := range config.Presets {
+		tool := Tool{Preset: preset}
+		for _, pattern := range preset.Includes {
+			files, err := findFiles(dir, pattern, preset.Excludes)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if !slices.Contains(shared, pattern) {
+					tool.Files = append(tool.Files, file)
+				}
+				if covered(file) {
+					continue
+				}
+				u, ok := uncovered[pattern]
+				if !ok {
+					u = &Uncovered{Pattern: pattern, Shared: slices.Contains(shared, pattern)}
+					uncovered[pattern] = u
+					order = append(order, pattern)
+				}
+				if !u.Shared && !slices.Contains(u.Tools, preset.Description) {
+					u.Tools = append(u.Tools, preset.Description)
+				}
+				if !slices.Contains(u.Files, file) {
+					u.Files = append(u.Files, file)
+				}
+			}
+		}
+		if len(tool.Files) > 0 {
+			result.Tools = append(result.Tools, tool)
+		}
+	}
+
+	for _, pattern := range order {
+		result.Uncovered = append(result.Uncovered, *uncovered[pattern])
+	}
+	return result, nil
+}
+
+// findFiles returns the regular files in dir that match pattern and none of
+// the excludes.
+func findFiles(dir, pattern string, excludes []string) ([]string, error) {
+	matches, err := glob.ExpandPatterns(dir, []string{pattern})
+	if err != nil {
+		return nil, err
+	}
+	matches, err = glob.FilterExcludes(matches, excludes)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(match))); err == nil && info.Mode().IsRegular() {
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
+
+// sharedPatterns returns the patterns that every preset has.
+func sharedPatterns() []string {
+	var shared []string
+	for _, pattern := range config.Presets[0].Includes {
+		if !slices.ContainsFunc(config.Presets, func(p config.Preset) bool { return !slices.Contains(p.Includes, pattern) }) {
+			shared = append(shared, pattern)
+		}
+	}
+	return shared
+}
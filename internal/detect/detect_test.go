@@ -0,0 +1,79 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openjny/dotgh/internal/config"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"AGENTS.md",
+		"CLAUDE.md",
+		"CLAUDE.local.md",
+		".cursor/rules/go.mdc",
+		".cursor/rules/web.mdc",
+		".github/copilot-instructions.md",
+		".github/prompts/local.prompt.md",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+	// A directory named like a rules file is not a tool file
+	if err := os.MkdirAll(filepath.Join(dir, ".clinerules"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	t.Run("copilot includes", func(t *testing.T) {
+		result, err := Scan(dir, config.DefaultIncludes, nil)
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+
+		var tools []string
+		for _, tool := range result.Tools {
+			tools = append(tools, tool.Preset.Name)
+		}
+		if want := []string{"copilot", "claude", "cursor"}; !reflect.DeepEqual(tools, want) {
+			t.Errorf("Tools = %v, want %v", tools, want)
+		}
+
+		want := []Uncovered{
+			{Pattern: "CLAUDE.md", Tools: []string{"Claude Code"}, Files: []string{"CLAUDE.md"}},
+			{Pattern: ".cursor/rules/*.mdc", Tools: []string{"Cursor"}, Files: []string{".cursor/rules/go.mdc", ".cursor/rules/web.mdc"}},
+		}
+		if !reflect.DeepEqual(result.Uncovered, want) {
+			t.Errorf("Uncovered = %+v, want %+v", result.Uncovered, want)
+		}
+	})
+
+	t.Run("excluded files are covered", func(t *testing.T) {
+		result, err := Scan(dir, []string{"CLAUDE.md"}, []string{"AGENTS.md", ".github/*", ".github/*/*", ".cursor/rules/*"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if len(result.Uncovered) != 0 {
+			t.Errorf("Uncovered = %+v, want none", result.Uncovered)
+		}
+	})
+
+	t.Run("shared patterns", func(t *testing.T) {
+		result, err := Scan(dir, []string{"CLAUDE.md", ".github/*", ".github/*/*", ".cursor/rules/*"}, nil)
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		want := []Uncovered{{Pattern: "AGENTS.md", Shared: true, Files: []string{"AGENTS.md"}}}
+		if !reflect.DeepEqual(result.Uncovered, want) {
+			t.Errorf("Uncovered = %+v, want %+v", result.Uncovered, want)
+		}
+	})
+}
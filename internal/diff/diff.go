@@ -59,17 +59,22 @@ func (r *DiffResult) AllChanges() []FileChange {
 	return result
 }
 
+// ChangedPaths returns the paths of files whose content will be written (add + modify).
+func (r *DiffResult) ChangedPaths() []string {
+	paths := make([]string, 0, len(r.Added)+len(r.Modified))
+	for _, change := range r.Added {
+		paths = append(paths, change.Path)
+	}
+	for _, change := range r.Modified {
+		paths = append(paths, change.Path)
+	}
+	return paths
+}
+
 // ComputeDiff calculates the difference between source and target directories.
 // If mergeMode is true, deletions are not computed (files only in target are ignored).
 // If mergeMode is false, it computes full sync (including deletions).
 func ComputeDiff(srcDir, dstDir string, includes, excludes []string, mergeMode bool) (*DiffResult, error) {
-	result := &DiffResult{
-		Added:     []FileChange{},
-		Modified:  []FileChange{},
-		Deleted:   []FileChange{},
-		Unchanged: []FileChange{},
-	}
-
 	// Get files from source directory
 	srcFiles, err := getFilteredFiles(srcDir, includes, excludes)
 	if err != nil {
@@ -82,6 +87,25 @@ func ComputeDiff(srcDir, dstDir string, includes, excludes []string, mergeMode b
 		return nil, fmt.Errorf("get destination files: %w", err)
 	}
 
+	return CompareFiles(srcFiles, dstFiles, mergeMode, func(file string) (bool, error) {
+		return filesAreEqual(filepath.Join(srcDir, file), filepath.Join(dstDir, file))
+	})
+}
+
+// EqualFunc reports whether a file present in both source and destination
+// has the same content on both sides.
+type EqualFunc func(relPath string) (bool, error)
+
+// CompareFiles classifies source and destination file lists into a DiffResult
+// using the same rules as ComputeDiff. equal is called for files present in both.
+func CompareFiles(srcFiles, dstFiles []string, mergeMode bool, equal EqualFunc) (*DiffResult, error) {
+	result := &DiffResult{
+		Added:     []FileChange{},
+		Modified:  []FileChange{},
+		Deleted:   []FileChange{},
+		Unchanged: []FileChange{},
+	}
+
 	// Create maps for quick lookup
 	srcFileSet := make(map[string]bool)
 	for _, f := range srcFiles {
@@ -100,10 +124,7 @@ func ComputeDiff(srcDir, dstDir string, includes, excludes []string, mergeMode b
 			result.Added = append(result.Added, FileChange{Path: file, ChangeType: ChangeAdd})
 		} else {
 			// File exists in both -> check if modified
-			srcPath := filepath.Join(srcDir, file)
-			dstPath := filepath.Join(dstDir, file)
-
-			same, err := filesAreEqual(srcPath, dstPath)
+			same, err := equal(file)
 			if err != nil {
 				return nil, fmt.Errorf("compare files %s: %w", file, err)
 			}
@@ -135,6 +156,36 @@ func ComputeDiff(srcDir, dstDir string, includes, excludes []string, mergeMode b
 	return result, nil
 }
 
+// ListFiles returns all regular files under dir, recursively, as relative
+// paths with forward slashes. It returns an empty list if dir does not exist.
+func ListFiles(dir string) ([]string, error) {
+	files := []string{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("get relative path: %w", err)
+		}
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
 // getFilteredFiles returns files in the directory matching includes and not matching excludes.
 func getFilteredFiles(dir string, includes, excludes []string) ([]string, error) {
 	// Check if directory exists
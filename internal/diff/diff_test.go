@@ -290,6 +290,16 @@ func TestDiffResult_AllChanges(t *testing.T) {
 	assert.Len(t, changes, 3)
 }
 
+func TestDiffResult_ChangedPaths(t *testing.T) {
+	diff := &DiffResult{
+		Added:    []FileChange{{Path: "a.md", ChangeType: ChangeAdd}},
+		Modified: []FileChange{{Path: "b.md", ChangeType: ChangeModify}},
+		Deleted:  []FileChange{{Path: "c.md", ChangeType: ChangeDelete}},
+	}
+
+	assert.Equal(t, []string{"a.md", "b.md"}, diff.ChangedPaths())
+}
+
 func TestFilesAreEqual(t *testing.T) {
 	dir := t.TempDir()
 
@@ -0,0 +1,644 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execClient implements Client by running the git binary.
+//
+// Failures are classified from repository state and exit codes where git
+// provides them (conflicts, upstream tracking, empty remotes). Only
+// authentication and network failures are recognized from git's output:
+// from the known prefixes of the lines that git, ssh and curl print when
+// git dies with status 128. The output is forced to the C locale so that it
+// does not depend on the user's language.
+type execClient struct {
+	dir string
+}
+
+// IsRepo returns true if the directory is a Git repository.
+func (c *execClient) IsRepo() bool {
+	return isRepo(c.dir)
+}
+
+// Init initializes a new Git repository.
+func (c *execClient) Init(ctx context.Context) error {
+	return c.run(ctx, "init")
+}
+
+// Clone clones a repository to the client's directory.
+// Returns ErrEmptyRepository if the remote repository is empty or does not
+// have the requested branch yet.
+func (c *execClient) Clone(ctx context.Context, repo, branch string) error {
+	// Clone into current directory
+	args := []string{"clone"}
+	if branch != "" {
+		args = append(args, "-b", branch)
+	}
+	args = append(args, repo, ".")
+
+	output, err := c.remoteCommand(ctx, args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	e := opError(ctx, args, output, err)
+	if ctx.Err() != nil {
+		return e
+	}
+
+	// Tell an empty remote apart from other failures by listing its branches
+	heads, lsErr := c.remoteCommand(ctx, "ls-remote", "--heads", repo).Output()
+	if lsErr != nil {
+		classifyOutput(e, output, err)
+		return e
+	}
+	if !hasHead(string(heads), branch) {
+		e.Kind = ErrEmptyRepository
+	}
+	return e
+}
+
+// hasHead reports whether ls-remote --heads output lists branch, or any
+// branch if branch is empty.
+func hasHead(output, branch string) bool {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		_, ref, ok := strings.Cut(line, "\t")
+		if ok && (branch == "" || ref == "refs/heads/"+branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add stages files for commit.
+func (c *execClient) Add(ctx context.Context, paths ...string) error {
+	args := append([]string{"add"}, paths...)
+	return c.run(ctx, args...)
+}
+
+// Commit creates a commit with the given message.
+func (c *execClient) Commit(ctx context.Context, message string) error {
+	return c.run(ctx, "commit", "-m", message)
+}
+
+// Push pushes commits to the remote repository.
+func (c *execClient) Push(ctx context.Context) error {
+	return c.runRemote(ctx, "push")
+}
+
+// PushWithUpstream pushes commits and sets upstream branch.
+func (c *execClient) PushWithUpstream(ctx context.Context, remote, branch string) error {
+	return c.runRemote(ctx, "push", "-u", remote, branch)
+}
+
+// Pull pulls changes from the remote repository, merging them into the
+// current branch.
+func (c *execClient) Pull(ctx context.Context) error {
+	remote, merge, err := c.upstreamConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"pull", "--no-rebase"}
+	output, err := c.remoteCommand(ctx, args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	e := opError(ctx, args, output, err)
+
+	switch {
+	case ctx.Err() != nil:
+		// Interrupted; the merge was not started or is left for sync resolve
+	case c.IsMerging():
+		if files, cfErr := c.ConflictedFiles(ctx); cfErr == nil && len(files) > 0 {
+			e.Kind = ErrMergeConflict
+		}
+	case !c.remoteHasRef(ctx, remote, merge):
+		e.Kind = ErrNoUpstream
+	default:
+		classifyOutput(e, output, err)
+	}
+	return e
+}
+
+// remoteHasRef reports whether ref exists on remote. It returns true if that
+// cannot be determined, so that the original failure is reported.
+func (c *execClient) remoteHasRef(ctx context.Context, remote, ref string) bool {
+	_, err := c.remoteCommand(ctx, "ls-remote", "--exit-code", remote, ref).Output()
+	return exitCode(err) != 2
+}
+
+// IsMerging returns true if a merge is in progress.
+func (c *execClient) IsMerging() bool {
+	return isMerging(c.dir)
+}
+
+// ConflictedFiles returns the files with unresolved merge conflicts.
+func (c *execClient) ConflictedFiles(ctx context.Context) ([]ConflictedFile, error) {
+	output, err := c.runOutput(ctx, "ls-files", "--unmerged", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ConflictedFile
+	index := make(map[string]int)
+	for _, entry := range strings.Split(output, "\x00") {
+		// Format: <mode> <object> <stage>\t<path>
+		meta, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 {
+			continue
+		}
+
+		i, seen := index[path]
+		if !seen {
+			i = len(files)
+			index[path] = i
+			files = append(files, ConflictedFile{Path: path})
+		}
+		switch fields[2] {
+		case "2":
+			files[i].Ours = true
+		case "3":
+			files[i].Theirs = true
+		}
+	}
+	return files, nil
+}
+
+// ShowStage returns the content of path at the given index stage.
+func (c *execClient) ShowStage(ctx context.Context, stage int, path string) ([]byte, error) {
+	output, err := c.runOutput(ctx, "show", fmt.Sprintf(":%d:%s", stage, path))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// Remove removes files from the working tree and the index.
+func (c *execClient) Remove(ctx context.Context, paths ...string) error {
+	args := append([]string{"rm", "-q", "--"}, paths...)
+	return c.run(ctx, args...)
+}
+
+// AheadBehind returns how many commits the current branch is ahead of and
+// behind its upstream.
+func (c *execClient) AheadBehind(ctx context.Context) (ahead, behind int, err error) {
+	upstream, err := c.Upstream(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	output, err := c.runOutput(ctx, "rev-list", "--left-right", "--count", "HEAD...refs/remotes/"+upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(output, "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("parse rev-list output %q: %w", strings.TrimSpace(output), err)
+	}
+	return ahead, behind, nil
+}
+
+// RemoteAdd adds a remote repository.
+func (c *execClient) RemoteAdd(ctx context.Context, name, url string) error {
+	return c.run(ctx, "remote", "add", name, url)
+}
+
+// RemoteGetURL gets the URL of a remote repository.
+func (c *execClient) RemoteGetURL(ctx context.Context, name string) (string, error) {
+	output, err := c.runOutput(ctx, "remote", "get-url", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// HasRemote checks if a remote with the given name exists.
+func (c *execClient) HasRemote(ctx context.Context, name string) bool {
+	_, err := c.RemoteGetURL(ctx, name)
+	return err == nil
+}
+
+// Status returns the status of the repository.
+func (c *execClient) Status(ctx context.Context) (*Status, error) {
+	output, err := c.runOutput(ctx, "status", "--porcelain=v2", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(output)
+}
+
+// GetCurrentBranch returns the current branch name, or "HEAD" if HEAD is
+// detached. It works before the first commit.
+func (c *execClient) GetCurrentBranch(ctx context.Context) (string, error) {
+	output, err := c.command(ctx, "symbolic-ref", "--short", "-q", "HEAD").Output()
+	if err != nil {
+		if exitCode(err) == 1 {
+			return "HEAD", nil
+		}
+		return "", opError(ctx, []string{"symbolic-ref", "HEAD"}, stderrOf(err), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CheckoutBranch switches to or creates a branch.
+func (c *execClient) CheckoutBranch(ctx context.Context, branch string, create bool) error {
+	if create {
+		return c.run(ctx, "checkout", "-b", branch)
+	}
+	return c.run(ctx, "checkout", branch)
+}
+
+// BranchRename renames the current branch to the specified name.
+func (c *execClient) BranchRename(ctx context.Context, newName string) error {
+	return c.run(ctx, "branch", "-M", newName)
+}
+
+// ConfigSet sets a git configuration value.
+func (c *execClient) ConfigSet(ctx context.Context, key, value string) error {
+	return c.run(ctx, "config", key, value)
+}
+
+// ConfigGet gets a git configuration value.
+func (c *execClient) ConfigGet(ctx context.Context, key string) (string, error) {
+	output, err := c.runOutput(ctx, "config", key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// EnsureUserConfig ensures user.email and user.name are configured.
+func (c *execClient) EnsureUserConfig(ctx context.Context) error {
+	return ensureUserConfig(ctx, c)
+}
+
+// Fetch fetches changes from remote.
+func (c *execClient) Fetch(ctx context.Context) error {
+	return c.runRemote(ctx, "fetch")
+}
+
+// Upstream returns the name of the upstream branch of the current branch.
+// Returns ErrNoUpstream if none is configured or it has not been fetched.
+func (c *execClient) Upstream(ctx context.Context) (string, error) {
+	remote, merge, err := c.upstreamConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	name := remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+
+	_, err = c.command(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/"+name).Output()
+	if err != nil {
+		if exitCode(err) == 1 {
+			return "", &Error{Op: "upstream", Kind: ErrNoUpstream, Err: fmt.Errorf("%s has not been fetched", name)}
+		}
+		return "", opError(ctx, []string{"rev-parse", name}, stderrOf(err), err)
+	}
+	return name, nil
+}
+
+// upstreamConfig returns the remote and branch the current branch tracks.
+// Returns ErrNoUpstream if the branch does not track one.
+func (c *execClient) upstreamConfig(ctx context.Context) (remote, merge string, err error) {
+	branch, err := c.GetCurrentBranch(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	values := make([]string, 2)
+	for i, key := range []string{"remote", "merge"} {
+		output, err := c.command(ctx, "config", "branch."+branch+"."+key).Output()
+		if err != nil {
+			if exitCode(err) == 1 {
+				return "", "", &Error{Op: "upstream", Kind: ErrNoUpstream, Err: fmt.Errorf("branch %q does not track a remote branch", branch)}
+			}
+			return "", "", opError(ctx, []string{"config", "branch." + branch + "." + key}, stderrOf(err), err)
+		}
+		values[i] = strings.TrimSpace(string(output))
+	}
+	return values[0], values[1], nil
+}
+
+// DiffNames returns the paths of files that differ between two revisions.
+func (c *execClient) DiffNames(ctx context.Context, from, to string) ([]string, error) {
+	output, err := c.runOutput(ctx, "diff", "--name-only", "-z", from, to, "--")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(output), nil
+}
+
+// Log returns the commits reachable from rev that touch any of paths.
+func (c *execClient) Log(ctx context.Context, rev string, limit int, paths ...string) ([]LogEntry, error) {
+	args := []string{"log", "--format=%H%x1f%h%x1f%an%x1f%aI%x1f%s%x1e"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	args = append(args, "--")
+	args = append(args, paths...)
+
+	output, err := c.runOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("parse commit date %q: %w", fields[3], err)
+		}
+		entries = append(entries, LogEntry{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Date:      date,
+			Subject:   fields[4],
+		})
+	}
+	return entries, nil
+}
+
+// ResolveRevision returns the full commit hash that rev refers to.
+func (c *execClient) ResolveRevision(ctx context.Context, rev string) (string, error) {
+	args := []string{"rev-parse", "--verify", rev + "^{commit}"}
+	output, err := c.command(ctx, args...).Output()
+	if err != nil {
+		stderr := stderrOf(err)
+		if ctx.Err() == nil && exitCode(err) == fatalExitCode && isBadRevision(stderr) {
+			return "", fmt.Errorf("unknown revision %q", rev)
+		}
+		return "", opError(ctx, args, stderr, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// badRevisionMessages are parts of the messages git fails with when a
+// revision does not name a commit.
+var badRevisionMessages = []string{
+	"Needed a single revision",
+	"unknown revision",
+	"bad revision",
+}
+
+// isBadRevision reports whether output is that of git failing because a
+// revision does not name a commit.
+func isBadRevision(output []byte) bool {
+	for _, message := range badRevisionMessages {
+		if bytes.Contains(output, []byte(message)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Show returns the content of path at revision rev.
+func (c *execClient) Show(ctx context.Context, rev, path string) ([]byte, error) {
+	output, err := c.runOutput(ctx, "show", rev+":"+path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// ListTree returns the files under paths at revision rev, recursively.
+func (c *execClient) ListTree(ctx context.Context, rev string, paths ...string) ([]string, error) {
+	args := append([]string{"ls-tree", "-r", "-z", "--name-only", rev, "--"}, paths...)
+	output, err := c.runOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(output), nil
+}
+
+// GetDir returns the directory of the git client.
+func (c *execClient) GetDir() string {
+	return c.dir
+}
+
+// waitDelay bounds how long a cancelled git command may keep its output open,
+// e.g. through an ssh child process that outlives it.
+const waitDelay = 2 * time.Second
+
+// command builds a git command that runs in the client's directory with
+// output in the C locale. The command is killed when ctx is done.
+func (c *execClient) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = c.dir
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANGUAGE=C")
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// remoteCommand builds a git command that talks to a remote. Credential and
+// host key prompts are disabled so that the command fails instead of
+// waiting for input that never comes.
+func (c *execClient) remoteCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := c.command(ctx, args...)
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	if ssh := c.sshCommand(ctx); ssh != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+ssh)
+	}
+	return cmd
+}
+
+// sshCommand returns the ssh command configured for git with batch mode
+// enabled, or "" if a custom GIT_SSH program is used instead.
+func (c *execClient) sshCommand(ctx context.Context) string {
+	ssh := os.Getenv("GIT_SSH_COMMAND")
+	if ssh == "" {
+		if output, err := c.command(ctx, "config", "core.sshCommand").Output(); err == nil {
+			ssh = strings.TrimSpace(string(output))
+		}
+	}
+	if ssh == "" {
+		if os.Getenv("GIT_SSH") != "" {
+			return ""
+		}
+		ssh = "ssh"
+	}
+	return ssh + " -o BatchMode=yes"
+}
+
+// run executes a git command in the client's directory.
+func (c *execClient) run(ctx context.Context, args ...string) error {
+	output, err := c.command(ctx, args...).CombinedOutput()
+	if err != nil {
+		return opError(ctx, args, output, err)
+	}
+	return nil
+}
+
+// runRemote executes a git command that talks to a remote, classifying
+// authentication and network failures.
+func (c *execClient) runRemote(ctx context.Context, args ...string) error {
+	output, err := c.remoteCommand(ctx, args...).CombinedOutput()
+	if err != nil {
+		e := opError(ctx, args, output, err)
+		if e.Kind == nil {
+			classifyOutput(e, output, err)
+		}
+		return e
+	}
+	return nil
+}
+
+// runOutput executes a git command and returns its output.
+func (c *execClient) runOutput(ctx context.Context, args ...string) (string, error) {
+	output, err := c.command(ctx, args...).Output()
+	if err != nil {
+		return "", opError(ctx, args, stderrOf(err), err)
+	}
+	return string(output), nil
+}
+
+// opError wraps a failed git command, using git's output as the details.
+// A command killed because ctx is done is reported with the context's error.
+func opError(ctx context.Context, args []string, output []byte, err error) *Error {
+	e := &Error{Op: strings.Join(args, " "), Err: err}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		e.Kind, e.Err = contextKind(ctxErr), ctxErr
+		return e
+	}
+	if msg := strings.TrimSpace(string(output)); msg != "" {
+		e.Err = errors.New(msg)
+	}
+	return e
+}
+
+// fatalExitCode is the exit status of git when it dies with a "fatal:"
+// message, as it does when it cannot reach or authenticate to a remote.
+const fatalExitCode = 128
+
+// Known starts of the lines that git, ssh and the remote print, in the C
+// locale, when a remote operation fails.
+var (
+	// authPrefixes start lines reporting missing or rejected credentials.
+	authPrefixes = []string{
+		"fatal: Authentication failed for ",
+		"fatal: could not read Username for ",
+		"fatal: could not read Password for ",
+		"remote: Invalid username or password",
+		"Host key verification failed.",
+	}
+	// networkPrefixes start lines reporting that the remote could not be
+	// reached over ssh.
+	networkPrefixes = []string{
+		"ssh: Could not resolve hostname ",
+		"ssh: connect to host ",
+		"kex_exchange_identification: ",
+	}
+	// curlNetworkPrefixes start the reason of "fatal: unable to access" for
+	// HTTP remotes that could not be reached.
+	curlNetworkPrefixes = []string{
+		"Could not resolve host: ",
+		"Could not resolve proxy: ",
+		"Failed to connect to ",
+		"Connection timed out",
+		"Operation timed out",
+		"Recv failure: ",
+		"Send failure: ",
+	}
+	// curlAuthPrefixes start the reason of "fatal: unable to access" for
+	// HTTP remotes that rejected the credentials.
+	curlAuthPrefixes = []string{
+		"The requested URL returned error: 401",
+		"The requested URL returned error: 403",
+	}
+)
+
+// classifyOutput sets the kind of a failed remote operation from the exit
+// status of git and the first line of its output that starts with a known
+// prefix. Other failures are left unclassified.
+func classifyOutput(e *Error, output []byte, err error) {
+	if exitCode(err) != fatalExitCode {
+		return
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if kind := classifyLine(strings.TrimSpace(line)); kind != nil {
+			e.Kind = kind
+			return
+		}
+	}
+}
+
+// classifyLine returns the kind of failure that line reports, or nil.
+func classifyLine(line string) error {
+	if hasAnyPrefix(line, authPrefixes) {
+		return ErrAuthenticationFailed
+	}
+	if hasAnyPrefix(line, networkPrefixes) {
+		return ErrNetworkError
+	}
+	// ssh: "<user>@<host>: Permission denied (publickey)."
+	if _, reason, ok := strings.Cut(line, ": "); ok && strings.HasPrefix(reason, "Permission denied (") {
+		return ErrAuthenticationFailed
+	}
+	// HTTP: "fatal: unable to access '<url>': <reason>"
+	if rest, ok := strings.CutPrefix(line, "fatal: unable to access '"); ok {
+		_, reason, _ := strings.Cut(rest, "': ")
+		switch {
+		case hasAnyPrefix(reason, curlNetworkPrefixes):
+			return ErrNetworkError
+		case hasAnyPrefix(reason, curlAuthPrefixes):
+			return ErrAuthenticationFailed
+		}
+	}
+	return nil
+}
+
+// hasAnyPrefix reports whether s starts with one of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// exitCode returns the exit status of a failed git command, or -1 if it did
+// not run to completion.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// stderrOf returns the captured standard error of a failed command.
+func stderrOf(err error) []byte {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Stderr
+	}
+	return nil
+}
+
+// splitNul splits NUL-separated git output, dropping empty entries.
+func splitNul(output string) []string {
+	var result []string
+	for _, p := range strings.Split(output, "\x00") {
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
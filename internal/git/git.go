@@ -1,13 +1,19 @@
 // Package git provides Git operations wrapper for dotgh sync functionality.
+//
+// Two backends implement Client: the default one runs the git binary, and a
+// pure-Go one built on go-git works without git installed. Failures are
+// reported as *Error values whose Kind is one of the sentinel errors below,
+// so callers can classify them with errors.Is regardless of the backend.
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+	"time"
 )
 
 // ErrEmptyRepository indicates that the remote repository is empty (has no commits).
@@ -22,306 +28,209 @@ var ErrNetworkError = errors.New("network error")
 // ErrMergeConflict indicates a merge conflict occurred.
 var ErrMergeConflict = errors.New("merge conflict")
 
-// Client represents a Git client for a specific directory.
-type Client struct {
-	dir string
-}
-
-// Status represents the status of a Git repository.
-type Status struct {
-	Added     []string
-	Modified  []string
-	Deleted   []string
-	Untracked []string
-}
-
-// IsClean returns true if there are no uncommitted changes.
-func (s *Status) IsClean() bool {
-	return len(s.Added) == 0 && len(s.Modified) == 0 && len(s.Deleted) == 0 && len(s.Untracked) == 0
-}
+// ErrNoUpstream indicates the current branch has no upstream to pull from yet.
+var ErrNoUpstream = errors.New("no upstream branch")
 
-// New creates a new Git client for the specified directory.
-func New(dir string) *Client {
-	return &Client{dir: dir}
-}
+// ErrNotSupported indicates that the backend cannot perform the operation.
+var ErrNotSupported = errors.New("not supported by this git backend")
 
-// IsGitInstalled checks if git is available in the PATH.
-func IsGitInstalled() bool {
-	_, err := exec.LookPath("git")
-	return err == nil
-}
+// ErrTimeout indicates that the operation did not finish before its deadline.
+var ErrTimeout = errors.New("operation timed out")
 
-// IsRepo returns true if the directory is a Git repository.
-func (c *Client) IsRepo() bool {
-	gitDir := filepath.Join(c.dir, ".git")
-	_, err := os.Stat(gitDir)
-	return err == nil
-}
+// Backend names accepted by NewClient.
+const (
+	// BackendExec runs the git binary. It is the default.
+	BackendExec = "exec"
+	// BackendGoGit uses the pure-Go go-git library.
+	BackendGoGit = "go-git"
+)
 
-// Init initializes a new Git repository.
-func (c *Client) Init() error {
-	return c.run("init")
+// Error describes a failed git operation.
+type Error struct {
+	// Op is the operation that failed (e.g. "pull").
+	Op string
+	// Kind is the sentinel error classifying the failure, or nil.
+	Kind error
+	// Err is the underlying error reported by the backend.
+	Err error
 }
 
-// Clone clones a repository to the client's directory.
-// Returns ErrEmptyRepository if the remote repository is empty.
-func (c *Client) Clone(repo, branch string) error {
-	// Clone into current directory
-	args := []string{"clone"}
-	if branch != "" {
-		args = append(args, "-b", branch)
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Kind != nil {
+		return fmt.Sprintf("git %s: %v: %v", e.Op, e.Kind, e.Err)
 	}
-	args = append(args, repo, ".")
-
-	cmd := exec.Command("git", args...)
-	cmd.Dir = c.dir
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		outputStr := string(output)
-		// Check if the error is due to an empty repository
-		// Git outputs specific messages for empty repos
-		if strings.Contains(outputStr, "empty") ||
-			strings.Contains(outputStr, "You appear to have cloned an empty repository") ||
-			strings.Contains(outputStr, "warning: remote HEAD refers to nonexistent ref") ||
-			(strings.Contains(outputStr, "Remote branch") && strings.Contains(outputStr, "not found")) {
-			return ErrEmptyRepository
-		}
-		return fmt.Errorf("git clone: %s", strings.TrimSpace(outputStr))
-	}
-	return nil
-}
-
-// Add stages files for commit.
-func (c *Client) Add(paths ...string) error {
-	args := append([]string{"add"}, paths...)
-	return c.run(args...)
-}
-
-// Commit creates a commit with the given message.
-func (c *Client) Commit(message string) error {
-	return c.run("commit", "-m", message)
+	return fmt.Sprintf("git %s: %v", e.Op, e.Err)
 }
 
-// Push pushes commits to the remote repository.
-// Returns ErrAuthenticationFailed for auth issues.
-// Returns ErrNetworkError for network issues.
-func (c *Client) Push() error {
-	err := c.runWithStderr("push")
-	if err != nil {
-		errStr := err.Error()
-		if isAuthError(errStr) {
-			return fmt.Errorf("%w: %s", ErrAuthenticationFailed, errStr)
-		}
-		if isNetworkError(errStr) {
-			return fmt.Errorf("%w: %s", ErrNetworkError, errStr)
-		}
-		return err
+// Unwrap returns the classification and the underlying error.
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
 	}
-	return nil
-}
-
-// PushWithUpstream pushes commits and sets upstream branch.
-func (c *Client) PushWithUpstream(remote, branch string) error {
-	return c.run("push", "-u", remote, branch)
+	return []error{e.Kind, e.Err}
 }
 
-// Pull pulls changes from the remote repository.
-// Returns ErrMergeConflict if there are merge conflicts.
-// Returns ErrAuthenticationFailed for auth issues.
-// Returns ErrNetworkError for network issues.
-func (c *Client) Pull() error {
-	err := c.runWithStderr("pull")
-	if err != nil {
-		errStr := err.Error()
-		// Check for specific error types
-		if isAuthError(errStr) {
-			return fmt.Errorf("%w: %s", ErrAuthenticationFailed, errStr)
-		}
-		if isNetworkError(errStr) {
-			return fmt.Errorf("%w: %s", ErrNetworkError, errStr)
-		}
-		if isMergeConflict(errStr) {
-			return fmt.Errorf("%w: %s", ErrMergeConflict, errStr)
-		}
-		return err
+// Client represents a Git client for a specific directory.
+type Client interface {
+	// IsRepo returns true if the directory is a Git repository.
+	IsRepo() bool
+	// Init initializes a new Git repository.
+	Init(ctx context.Context) error
+	// Clone clones a repository to the client's directory.
+	// Returns ErrEmptyRepository if the remote repository is empty.
+	Clone(ctx context.Context, repo, branch string) error
+	// Add stages files for commit.
+	Add(ctx context.Context, paths ...string) error
+	// Commit creates a commit with the given message.
+	Commit(ctx context.Context, message string) error
+	// Push pushes commits to the remote repository.
+	// Returns ErrAuthenticationFailed for auth issues.
+	// Returns ErrNetworkError for network issues.
+	Push(ctx context.Context) error
+	// PushWithUpstream pushes commits and sets upstream branch.
+	PushWithUpstream(ctx context.Context, remote, branch string) error
+	// Pull pulls changes from the remote repository, merging them into the
+	// current branch.
+	// Returns ErrMergeConflict if there are merge conflicts.
+	// Returns ErrNoUpstream if the current branch has no upstream yet.
+	// Returns ErrAuthenticationFailed for auth issues.
+	// Returns ErrNetworkError for network issues.
+	Pull(ctx context.Context) error
+	// IsMerging returns true if a merge is in progress.
+	IsMerging() bool
+	// ConflictedFiles returns the files with unresolved merge conflicts.
+	ConflictedFiles(ctx context.Context) ([]ConflictedFile, error)
+	// ShowStage returns the content of path at the given index stage
+	// (1 = common ancestor, 2 = ours, 3 = theirs) during a merge.
+	ShowStage(ctx context.Context, stage int, path string) ([]byte, error)
+	// Remove removes files from the working tree and the index.
+	Remove(ctx context.Context, paths ...string) error
+	// AheadBehind returns how many commits the current branch is ahead of and
+	// behind its upstream. Returns ErrNoUpstream if no upstream is configured.
+	AheadBehind(ctx context.Context) (ahead, behind int, err error)
+	// RemoteAdd adds a remote repository.
+	RemoteAdd(ctx context.Context, name, url string) error
+	// RemoteGetURL gets the URL of a remote repository.
+	RemoteGetURL(ctx context.Context, name string) (string, error)
+	// HasRemote checks if a remote with the given name exists.
+	HasRemote(ctx context.Context, name string) bool
+	// Status returns the status of the repository.
+	Status(ctx context.Context) (*Status, error)
+	// GetCurrentBranch returns the current branch name.
+	GetCurrentBranch(ctx context.Context) (string, error)
+	// CheckoutBranch switches to or creates a branch.
+	CheckoutBranch(ctx context.Context, branch string, create bool) error
+	// BranchRename renames the current branch to the specified name.
+	BranchRename(ctx context.Context, newName string) error
+	// ConfigSet sets a git configuration value in the repository.
+	ConfigSet(ctx context.Context, key, value string) error
+	// ConfigGet gets a git configuration value.
+	ConfigGet(ctx context.Context, key string) (string, error)
+	// EnsureUserConfig ensures user.email and user.name are configured.
+	// This is needed for git commit to work in environments without global config.
+	EnsureUserConfig(ctx context.Context) error
+	// Fetch fetches changes from remote.
+	// Returns ErrAuthenticationFailed for auth issues.
+	// Returns ErrNetworkError for network issues.
+	Fetch(ctx context.Context) error
+	// Upstream returns the name of the upstream branch of the current branch
+	// (e.g. "origin/main"). Returns ErrNoUpstream if none is configured.
+	Upstream(ctx context.Context) (string, error)
+	// DiffNames returns the paths of files that differ between two revisions.
+	DiffNames(ctx context.Context, from, to string) ([]string, error)
+	// Log returns the commits reachable from rev (HEAD if empty) that touch any
+	// of paths, newest first. All commits are returned if paths is empty.
+	// limit <= 0 means no limit.
+	Log(ctx context.Context, rev string, limit int, paths ...string) ([]LogEntry, error)
+	// ResolveRevision returns the full commit hash that rev refers to.
+	ResolveRevision(ctx context.Context, rev string) (string, error)
+	// Show returns the content of path at revision rev.
+	Show(ctx context.Context, rev, path string) ([]byte, error)
+	// ListTree returns the files under paths at revision rev, recursively.
+	// All files are returned if paths is empty.
+	ListTree(ctx context.Context, rev string, paths ...string) ([]string, error)
+	// GetDir returns the directory of the git client.
+	GetDir() string
+}
+
+// LogEntry represents a commit in the repository history.
+type LogEntry struct {
+	Hash      string
+	ShortHash string
+	Author    string
+	Date      time.Time
+	Subject   string
+}
+
+// ConflictedFile represents a file with unresolved merge conflicts.
+type ConflictedFile struct {
+	Path   string
+	Ours   bool // The file exists on the current branch
+	Theirs bool // The file exists on the merged branch
+}
+
+// New creates a new Git client for the specified directory using the
+// default backend.
+func New(dir string) Client {
+	return &execClient{dir: dir}
+}
+
+// NewClient creates a Git client for the specified directory using the named
+// backend. An empty backend selects BackendExec.
+func NewClient(backend, dir string) (Client, error) {
+	switch backend {
+	case "", BackendExec:
+		return New(dir), nil
+	case BackendGoGit:
+		return &goGitClient{dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown git backend %q (expected %q or %q)", backend, BackendExec, BackendGoGit)
 	}
-	return nil
-}
-
-// RemoteAdd adds a remote repository.
-func (c *Client) RemoteAdd(name, url string) error {
-	return c.run("remote", "add", name, url)
 }
 
-// RemoteGetURL gets the URL of a remote repository.
-func (c *Client) RemoteGetURL(name string) (string, error) {
-	output, err := c.runOutput("remote", "get-url", name)
-	if err != nil {
-		return "", err
+// contextKind returns the kind of an operation interrupted with ctxErr:
+// ErrTimeout if its deadline passed, or nil if it was cancelled.
+func contextKind(ctxErr error) error {
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		return ErrTimeout
 	}
-	return strings.TrimSpace(output), nil
+	return nil
 }
 
-// HasRemote checks if a remote with the given name exists.
-func (c *Client) HasRemote(name string) bool {
-	_, err := c.RemoteGetURL(name)
+// IsGitInstalled checks if git is available in the PATH.
+func IsGitInstalled() bool {
+	_, err := exec.LookPath("git")
 	return err == nil
 }
 
-// Status returns the status of the repository.
-func (c *Client) Status() (*Status, error) {
-	output, err := c.runOutput("status", "--porcelain")
-	if err != nil {
-		return nil, err
-	}
-
-	status := &Status{}
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if len(line) < 3 {
-			continue
-		}
-		indicator := line[:2]
-		filename := strings.TrimSpace(line[3:])
-
-		switch {
-		case strings.Contains(indicator, "A"):
-			status.Added = append(status.Added, filename)
-		case strings.Contains(indicator, "M"):
-			status.Modified = append(status.Modified, filename)
-		case strings.Contains(indicator, "D"):
-			status.Deleted = append(status.Deleted, filename)
-		case strings.HasPrefix(indicator, "??"):
-			status.Untracked = append(status.Untracked, filename)
-		}
-	}
-
-	return status, nil
-}
-
-// GetCurrentBranch returns the current branch name.
-func (c *Client) GetCurrentBranch() (string, error) {
-	output, err := c.runOutput("rev-parse", "--abbrev-ref", "HEAD")
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(output), nil
-}
-
-// CheckoutBranch switches to or creates a branch.
-func (c *Client) CheckoutBranch(branch string, create bool) error {
-	if create {
-		return c.run("checkout", "-b", branch)
-	}
-	return c.run("checkout", branch)
-}
-
-// BranchRename renames the current branch to the specified name.
-func (c *Client) BranchRename(newName string) error {
-	return c.run("branch", "-M", newName)
-}
-
-// ConfigSet sets a git configuration value.
-func (c *Client) ConfigSet(key, value string) error {
-	return c.run("config", key, value)
+// isRepo returns true if dir contains a .git directory.
+func isRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
 }
 
-// ConfigGet gets a git configuration value.
-func (c *Client) ConfigGet(key string) (string, error) {
-	output, err := c.runOutput("config", key)
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(output), nil
+// isMerging returns true if the repository in dir has a merge in progress.
+func isMerging(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git", "MERGE_HEAD"))
+	return err == nil
 }
 
-// EnsureUserConfig ensures user.email and user.name are configured.
-// This is needed for git commit to work in environments without global config.
-func (c *Client) EnsureUserConfig() error {
+// ensureUserConfig sets a fallback commit identity if none is configured.
+func ensureUserConfig(ctx context.Context, c Client) error {
 	// Check if user.email is set
-	if _, err := c.ConfigGet("user.email"); err != nil {
-		if err := c.ConfigSet("user.email", "dotgh@local"); err != nil {
+	if _, err := c.ConfigGet(ctx, "user.email"); err != nil {
+		if err := c.ConfigSet(ctx, "user.email", "dotgh@local"); err != nil {
 			return fmt.Errorf("set user.email: %w", err)
 		}
 	}
 
 	// Check if user.name is set
-	if _, err := c.ConfigGet("user.name"); err != nil {
-		if err := c.ConfigSet("user.name", "dotgh"); err != nil {
+	if _, err := c.ConfigGet(ctx, "user.name"); err != nil {
+		if err := c.ConfigSet(ctx, "user.name", "dotgh"); err != nil {
 			return fmt.Errorf("set user.name: %w", err)
 		}
 	}
 
 	return nil
 }
-
-// Fetch fetches changes from remote.
-func (c *Client) Fetch() error {
-	return c.run("fetch")
-}
-
-// GetDir returns the directory of the git client.
-func (c *Client) GetDir() string {
-	return c.dir
-}
-
-// run executes a git command in the client's directory.
-func (c *Client) run(args ...string) error {
-	return c.runWithStderr(args...)
-}
-
-// runWithStderr executes a git command and returns stderr in error message.
-func (c *Client) runWithStderr(args ...string) error {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = c.dir
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		if len(output) > 0 {
-			return fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
-		}
-		return fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
-	}
-	return nil
-}
-
-// isAuthError checks if the error message indicates an authentication failure.
-func isAuthError(errStr string) bool {
-	lower := strings.ToLower(errStr)
-	return strings.Contains(lower, "authentication failed") ||
-		strings.Contains(lower, "permission denied") ||
-		strings.Contains(lower, "could not read from remote repository") ||
-		strings.Contains(lower, "invalid username or password") ||
-		strings.Contains(lower, "fatal: could not read password")
-}
-
-// isNetworkError checks if the error message indicates a network issue.
-func isNetworkError(errStr string) bool {
-	lower := strings.ToLower(errStr)
-	return strings.Contains(lower, "could not resolve host") ||
-		strings.Contains(lower, "unable to access") ||
-		strings.Contains(lower, "connection refused") ||
-		strings.Contains(lower, "network is unreachable") ||
-		strings.Contains(lower, "connection timed out")
-}
-
-// isMergeConflict checks if the error message indicates a merge conflict.
-func isMergeConflict(errStr string) bool {
-	lower := strings.ToLower(errStr)
-	return strings.Contains(lower, "conflict") ||
-		strings.Contains(lower, "merge failed") ||
-		strings.Contains(lower, "automatic merge failed")
-}
-
-// runOutput executes a git command and returns its output.
-func (c *Client) runOutput(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = c.dir
-	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), string(exitErr.Stderr))
-		}
-		return "", err
-	}
-	return string(output), nil
-}
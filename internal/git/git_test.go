@@ -1,11 +1,17 @@
 package git
 
 import (
+	"context"
 	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,7 +29,7 @@ func TestInit(t *testing.T) {
 		tmpDir := t.TempDir()
 
 		client := New(tmpDir)
-		err := client.Init()
+		err := client.Init(t.Context())
 		require.NoError(t, err)
 
 		// Verify .git directory exists
@@ -68,7 +74,7 @@ func TestClone(t *testing.T) {
 		// Clone to destination (use empty string for branch to use default)
 		dstDir := t.TempDir()
 		client := New(dstDir)
-		err := client.Clone(srcDir, "")
+		err := client.Clone(t.Context(), srcDir, "")
 		require.NoError(t, err)
 
 		// Verify cloned file exists
@@ -103,10 +109,10 @@ func TestAddAndCommit(t *testing.T) {
 		client := New(tmpDir)
 
 		// Add and commit
-		err := client.Add(".")
+		err := client.Add(t.Context(), ".")
 		require.NoError(t, err)
 
-		err = client.Commit("test commit")
+		err = client.Commit(t.Context(), "test commit")
 		require.NoError(t, err)
 
 		// Verify commit exists
@@ -149,9 +155,9 @@ func TestPushAndPull(t *testing.T) {
 		require.NoError(t, os.WriteFile(testFile, []byte("hello"), 0644))
 
 		client1 := New(local1Dir)
-		require.NoError(t, client1.Add("."))
-		require.NoError(t, client1.Commit("initial commit"))
-		require.NoError(t, client1.Push())
+		require.NoError(t, client1.Add(t.Context(), "."))
+		require.NoError(t, client1.Commit(t.Context(), "initial commit"))
+		require.NoError(t, client1.Push(t.Context()))
 
 		// Create local repo 2 and pull
 		local2Dir := t.TempDir()
@@ -163,12 +169,12 @@ func TestPushAndPull(t *testing.T) {
 
 		// Update file in local1 and push
 		require.NoError(t, os.WriteFile(testFile, []byte("updated"), 0644))
-		require.NoError(t, client1.Add("."))
-		require.NoError(t, client1.Commit("update commit"))
-		require.NoError(t, client1.Push())
+		require.NoError(t, client1.Add(t.Context(), "."))
+		require.NoError(t, client1.Commit(t.Context(), "update commit"))
+		require.NoError(t, client1.Push(t.Context()))
 
 		// Pull in local2
-		require.NoError(t, client2.Pull())
+		require.NoError(t, client2.Pull(t.Context()))
 
 		// Verify updated file in local2
 		content, err := os.ReadFile(filepath.Join(local2Dir, "test.txt"))
@@ -186,7 +192,7 @@ func TestRemote(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		err := client.RemoteAdd("origin", "https://github.com/test/repo.git")
+		err := client.RemoteAdd(t.Context(), "origin", "https://github.com/test/repo.git")
 		require.NoError(t, err)
 
 		// Verify remote was added
@@ -210,7 +216,7 @@ func TestRemote(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		url, err := client.RemoteGetURL("origin")
+		url, err := client.RemoteGetURL(t.Context(), "origin")
 		require.NoError(t, err)
 		assert.Equal(t, "https://github.com/test/repo.git", url)
 	})
@@ -245,7 +251,7 @@ func TestStatus(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		status, err := client.Status()
+		status, err := client.Status(t.Context())
 		require.NoError(t, err)
 		assert.True(t, status.IsClean())
 	})
@@ -281,7 +287,7 @@ func TestStatus(t *testing.T) {
 		require.NoError(t, os.WriteFile(testFile, []byte("modified"), 0644))
 
 		client := New(tmpDir)
-		status, err := client.Status()
+		status, err := client.Status(t.Context())
 		require.NoError(t, err)
 		assert.False(t, status.IsClean())
 		assert.Contains(t, status.Modified, "test.txt")
@@ -337,7 +343,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		branch, err := client.GetCurrentBranch()
+		branch, err := client.GetCurrentBranch(t.Context())
 		require.NoError(t, err)
 		// Could be "main" or "master" depending on git config
 		assert.NotEmpty(t, branch)
@@ -373,10 +379,10 @@ func TestCheckout(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		err := client.CheckoutBranch("test-branch", true)
+		err := client.CheckoutBranch(t.Context(), "test-branch", true)
 		require.NoError(t, err)
 
-		branch, err := client.GetCurrentBranch()
+		branch, err := client.GetCurrentBranch(t.Context())
 		require.NoError(t, err)
 		assert.Equal(t, "test-branch", branch)
 	})
@@ -401,7 +407,7 @@ func TestCloneError(t *testing.T) {
 		tmpDir := t.TempDir()
 		client := New(tmpDir)
 
-		err := client.Clone("https://invalid-url-that-does-not-exist-12345.example.com/repo.git", "")
+		err := client.Clone(t.Context(), "https://invalid-url-that-does-not-exist-12345.example.com/repo.git", "")
 		assert.Error(t, err)
 	})
 
@@ -409,7 +415,7 @@ func TestCloneError(t *testing.T) {
 		tmpDir := t.TempDir()
 		client := New(tmpDir)
 
-		err := client.Clone("/non/existent/path/to/repo", "")
+		err := client.Clone(t.Context(), "/non/existent/path/to/repo", "")
 		assert.Error(t, err)
 	})
 }
@@ -442,7 +448,7 @@ func TestPushError(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		err := client.Push()
+		err := client.Push(t.Context())
 		assert.Error(t, err)
 	})
 }
@@ -456,7 +462,7 @@ func TestPullError(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		client := New(tmpDir)
-		err := client.Pull()
+		err := client.Pull(t.Context())
 		assert.Error(t, err)
 	})
 }
@@ -491,7 +497,302 @@ func TestCommitError(t *testing.T) {
 
 		// Now try to commit with nothing staged
 		client := New(tmpDir)
-		err := client.Commit("empty commit")
+		err := client.Commit(t.Context(), "empty commit")
 		assert.Error(t, err)
 	})
 }
+
+func TestMergeConflict(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping merge conflict test in short mode")
+	}
+
+	bareDir := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	cmd.Dir = bareDir
+	require.NoError(t, cmd.Run())
+
+	clone := func() Client {
+		dir := t.TempDir()
+		cmd := exec.Command("git", "clone", bareDir, ".")
+		cmd.Dir = dir
+		require.NoError(t, cmd.Run())
+		client := New(dir)
+		require.NoError(t, client.EnsureUserConfig(t.Context()))
+		return client
+	}
+	commit := func(c Client, content, message string) {
+		require.NoError(t, os.WriteFile(filepath.Join(c.GetDir(), "test.txt"), []byte(content), 0644))
+		require.NoError(t, c.Add(t.Context(), "."))
+		require.NoError(t, c.Commit(t.Context(), message))
+	}
+
+	client1 := clone()
+	commit(client1, "base\n", "base")
+	require.NoError(t, client1.Push(t.Context()))
+
+	client2 := clone()
+	commit(client1, "remote\n", "remote change")
+	require.NoError(t, client1.Push(t.Context()))
+	commit(client2, "local\n", "local change")
+
+	ahead, behind, err := client2.AheadBehind(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, 0, behind, "remote changes are not known before fetching")
+
+	err = client2.Pull(t.Context())
+	require.ErrorIs(t, err, ErrMergeConflict)
+	assert.True(t, client2.IsMerging())
+
+	files, err := client2.ConflictedFiles(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []ConflictedFile{{Path: "test.txt", Ours: true, Theirs: true}}, files)
+
+	ours, err := client2.ShowStage(t.Context(), 2, "test.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "local\n", string(ours))
+	theirs, err := client2.ShowStage(t.Context(), 3, "test.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "remote\n", string(theirs))
+
+	require.NoError(t, os.WriteFile(filepath.Join(client2.GetDir(), "test.txt"), theirs, 0644))
+	require.NoError(t, client2.Add(t.Context(), "test.txt"))
+	require.NoError(t, client2.Commit(t.Context(), "merge"))
+	assert.False(t, client2.IsMerging())
+
+	ahead, behind, err = client2.AheadBehind(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 0, behind)
+}
+
+func TestAheadBehindNoUpstream(t *testing.T) {
+	tmpDir := t.TempDir()
+	client := New(tmpDir)
+	require.NoError(t, client.Init(t.Context()))
+	require.NoError(t, client.EnsureUserConfig(t.Context()))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("x"), 0644))
+	require.NoError(t, client.Add(t.Context(), "."))
+	require.NoError(t, client.Commit(t.Context(), "initial"))
+
+	_, _, err := client.AheadBehind(t.Context())
+	assert.ErrorIs(t, err, ErrNoUpstream)
+}
+
+func TestHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	client := New(tmpDir)
+	require.NoError(t, client.Init(t.Context()))
+	require.NoError(t, client.EnsureUserConfig(t.Context()))
+
+	commit := func(path, content, message string) {
+		full := filepath.Join(tmpDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+		require.NoError(t, client.Add(t.Context(), "."))
+		require.NoError(t, client.Commit(t.Context(), message))
+	}
+	commit("templates/a/AGENTS.md", "v1", "add a")
+	commit("config.yaml", "x", "add config")
+	commit("templates/a/AGENTS.md", "v2", "update a")
+
+	t.Run("Log filters by path", func(t *testing.T) {
+		entries, err := client.Log(t.Context(), "", 0, "templates/a")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "update a", entries[0].Subject)
+		assert.Equal(t, "add a", entries[1].Subject)
+		assert.NotEmpty(t, entries[0].ShortHash)
+		assert.False(t, entries[0].Date.IsZero())
+
+		entries, err = client.Log(t.Context(), "HEAD~1", 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "add config", entries[0].Subject)
+	})
+
+	t.Run("ResolveRevision", func(t *testing.T) {
+		hash, err := client.ResolveRevision(t.Context(), "HEAD~2")
+		require.NoError(t, err)
+		assert.Len(t, hash, 40)
+
+		_, err = client.ResolveRevision(t.Context(), "does-not-exist")
+		assert.EqualError(t, err, `unknown revision "does-not-exist"`)
+	})
+
+	t.Run("ResolveRevision reports other failures", func(t *testing.T) {
+		_, err := New(t.TempDir()).ResolveRevision(t.Context(), "HEAD")
+		var gitErr *Error
+		require.ErrorAs(t, err, &gitErr)
+		assert.NotContains(t, err.Error(), "unknown revision")
+		assert.Contains(t, err.Error(), "not a git repository")
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err = client.ResolveRevision(ctx, "HEAD")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Show and ListTree read old revisions", func(t *testing.T) {
+		data, err := client.Show(t.Context(), "HEAD~2", "templates/a/AGENTS.md")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", string(data))
+
+		files, err := client.ListTree(t.Context(), "HEAD~2")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"templates/a/AGENTS.md"}, files)
+
+		files, err = client.ListTree(t.Context(), "HEAD", "config.yaml", "templates")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config.yaml", "templates/a/AGENTS.md"}, files)
+	})
+}
+
+// silentRemote returns the URL of an HTTP server that accepts connections
+// but never responds.
+func silentRemote(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+	return "http://" + ln.Addr().String() + "/repo.git"
+}
+
+func TestClassifyRemoteErrors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	// A port that nothing listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="dotgh"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(unauthorized.Close)
+	withCredentials := strings.Replace(unauthorized.URL, "http://", "http://user:wrong@", 1)
+
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"unknown host", "https://nonexistent.invalid/repo.git", ErrNetworkError},
+		{"connection refused", "http://" + closed + "/repo.git", ErrNetworkError},
+		{"no credentials", unauthorized.URL + "/repo.git", ErrAuthenticationFailed},
+		{"wrong credentials", withCredentials + "/repo.git", ErrAuthenticationFailed},
+		{"ssh connection refused", "ssh://git@" + closed + "/repo.git", ErrNetworkError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.HasPrefix(tt.url, "ssh://") {
+				if _, err := exec.LookPath("ssh"); err != nil {
+					t.Skip("ssh is not installed")
+				}
+			}
+			client := New(t.TempDir())
+			require.NoError(t, client.Init(t.Context()))
+			require.NoError(t, client.RemoteAdd(t.Context(), "origin", tt.url))
+
+			err := client.Fetch(t.Context())
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestClassifyOutput(t *testing.T) {
+	// Output of git 2.39 and OpenSSH, in the C locale
+	tests := []struct {
+		output string
+		want   error
+	}{
+		{"git@github.com: Permission denied (publickey).\r\nfatal: Could not read from remote repository.\n\nPlease make sure you have the correct access rights\nand the repository exists.\n", ErrAuthenticationFailed},
+		{"ssh: Could not resolve hostname nonexistent.invalid: Name or service not known\r\nfatal: Could not read from remote repository.\n", ErrNetworkError},
+		{"fatal: unable to access 'https://github.com/o/r.git/': The requested URL returned error: 403\n", ErrAuthenticationFailed},
+		{"fatal: unable to access 'https://github.com/o/r.git/': SSL certificate problem: unable to get local issuer certificate\n", nil},
+		{"fatal: repository 'https://github.com/o/missing.git/' not found\n", nil},
+		{"fatal: Could not read from remote repository.\n", nil},
+	}
+	// A fatal error of a real git command, outside of a repository
+	fatalErr := exec.Command("git", "-C", t.TempDir(), "rev-parse", "HEAD").Run()
+	require.Equal(t, fatalExitCode, exitCode(fatalErr), "git exits with 128 on fatal errors")
+	for _, tt := range tests {
+		e := &Error{}
+		classifyOutput(e, []byte(tt.output), fatalErr)
+		assert.Equal(t, tt.want, e.Kind, tt.output)
+	}
+
+	// Only failures of git itself are classified
+	e := &Error{}
+	classifyOutput(e, []byte("fatal: Authentication failed for 'https://x/'\n"), errors.New("not an exit error"))
+	assert.Nil(t, e.Kind)
+}
+
+func TestRemoteTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping timeout test in short mode")
+	}
+
+	for _, backend := range []string{BackendExec, BackendGoGit} {
+		t.Run(backend, func(t *testing.T) {
+			client, err := NewClient(backend, t.TempDir())
+			require.NoError(t, err)
+			require.NoError(t, client.Init(t.Context()))
+			require.NoError(t, client.RemoteAdd(t.Context(), "origin", silentRemote(t)))
+
+			ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err = client.Fetch(ctx)
+			assert.ErrorIs(t, err, ErrTimeout)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), 10*time.Second)
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		client := New(t.TempDir())
+		require.NoError(t, client.Init(t.Context()))
+		require.NoError(t, client.RemoteAdd(t.Context(), "origin", silentRemote(t)))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		err := client.Fetch(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, ErrTimeout)
+	})
+}
+
+func TestRemoteCommandIsNonInteractive(t *testing.T) {
+	tmpDir := t.TempDir()
+	argsFile := filepath.Join(tmpDir, "ssh-args")
+	script := filepath.Join(tmpDir, "fake-ssh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$GIT_TERMINAL_PROMPT $*\" > "+argsFile+"\nexit 255\n"), 0755))
+	t.Setenv("GIT_SSH_COMMAND", script)
+
+	client := New(filepath.Join(tmpDir, "repo"))
+	require.NoError(t, os.MkdirAll(client.GetDir(), 0755))
+	require.NoError(t, client.Init(t.Context()))
+	require.NoError(t, client.RemoteAdd(t.Context(), "origin", "ssh://git@example.invalid/repo.git"))
+
+	assert.Error(t, client.Fetch(t.Context()))
+	data, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	args := strings.TrimSpace(string(data))
+	assert.True(t, strings.HasPrefix(args, "0 "), "GIT_TERMINAL_PROMPT is disabled: %s", args)
+	assert.Contains(t, args, "-o BatchMode=yes")
+}
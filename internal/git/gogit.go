@@ -0,0 +1,854 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// goGitClient implements Client with the pure-Go go-git library, so that
+// sync works without the git binary. go-git cannot merge diverged histories:
+// Pull only fast-forwards and reports ErrNotSupported otherwise. go-git
+// never prompts for credentials, so remote operations are non-interactive.
+type goGitClient struct {
+	dir string
+}
+
+// IsRepo returns true if the directory is a Git repository.
+func (c *goGitClient) IsRepo() bool {
+	return isRepo(c.dir)
+}
+
+// Init initializes a new Git repository.
+func (c *goGitClient) Init(ctx context.Context) error {
+	if _, err := gogit.PlainInit(c.dir, false); err != nil {
+		return &Error{Op: "init", Err: err}
+	}
+	return nil
+}
+
+// Clone clones a repository to the client's directory.
+// Returns ErrEmptyRepository if the remote repository is empty or does not
+// have the requested branch yet.
+func (c *goGitClient) Clone(ctx context.Context, repo, branch string) error {
+	opts := &gogit.CloneOptions{URL: repo}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+	}
+	if _, err := gogit.PlainCloneContext(ctx, c.dir, false, opts); err != nil {
+		e := remoteError(ctx, "clone", err)
+		var noMatch gogit.NoMatchingRefSpecError
+		if ctx.Err() == nil && (errors.Is(err, transport.ErrEmptyRemoteRepository) ||
+			errors.Is(err, plumbing.ErrReferenceNotFound) ||
+			errors.As(err, &noMatch)) {
+			e.Kind = ErrEmptyRepository
+		}
+		return e
+	}
+	return nil
+}
+
+// Add stages files for commit.
+func (c *goGitClient) Add(ctx context.Context, paths ...string) error {
+	w, err := c.worktree()
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if _, err := w.Add(p); err != nil {
+			return &Error{Op: "add", Err: err}
+		}
+	}
+	return nil
+}
+
+// Commit creates a commit with the given message.
+// Completing a merge started by the git binary is not supported.
+func (c *goGitClient) Commit(ctx context.Context, message string) error {
+	if c.IsMerging() {
+		return &Error{Op: "commit", Kind: ErrNotSupported, Err: errors.New("completing a merge requires the exec backend")}
+	}
+	w, err := c.worktree()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Commit(message, &gogit.CommitOptions{}); err != nil {
+		return &Error{Op: "commit", Err: err}
+	}
+	return nil
+}
+
+// Push pushes the current branch to its upstream.
+func (c *goGitClient) Push(ctx context.Context) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	branch, err := c.GetCurrentBranch(ctx)
+	if err != nil {
+		return err
+	}
+	remote, merge, err := c.upstreamConfig(repo, branch)
+	if err != nil {
+		return err
+	}
+	return c.push(ctx, repo, remote, plumbing.NewBranchReferenceName(branch), merge)
+}
+
+// PushWithUpstream pushes commits and sets upstream branch.
+func (c *goGitClient) PushWithUpstream(ctx context.Context, remote, branch string) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	ref := plumbing.NewBranchReferenceName(branch)
+	if err := c.push(ctx, repo, remote, ref, ref); err != nil {
+		return err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return &Error{Op: "config", Err: err}
+	}
+	cfg.Branches[branch] = &gitconfig.Branch{Name: branch, Remote: remote, Merge: ref}
+	if err := repo.SetConfig(cfg); err != nil {
+		return &Error{Op: "config", Err: err}
+	}
+	return nil
+}
+
+// push pushes local to the dst branch of remote.
+func (c *goGitClient) push(ctx context.Context, repo *gogit.Repository, remote string, local, dst plumbing.ReferenceName) error {
+	refSpec := gitconfig.RefSpec(local.String() + ":" + dst.String())
+	err := repo.PushContext(ctx, &gogit.PushOptions{RemoteName: remote, RefSpecs: []gitconfig.RefSpec{refSpec}})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return remoteError(ctx, "push", err)
+	}
+	return nil
+}
+
+// Pull fast-forwards the current branch to its upstream.
+// Returns ErrNotSupported if local and remote histories have diverged.
+func (c *goGitClient) Pull(ctx context.Context) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	branch, err := c.GetCurrentBranch(ctx)
+	if err != nil {
+		return err
+	}
+	remote, merge, err := c.upstreamConfig(repo, branch)
+	if err != nil {
+		return err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return &Error{Op: "pull", Err: err}
+	}
+
+	err = w.PullContext(ctx, &gogit.PullOptions{RemoteName: remote, ReferenceName: merge})
+	var noMatch gogit.NoMatchingRefSpecError
+	switch {
+	case err == nil, errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		return nil
+	case ctx.Err() != nil:
+		return remoteError(ctx, "pull", err)
+	case errors.Is(err, gogit.ErrNonFastForwardUpdate):
+		return &Error{Op: "pull", Kind: ErrNotSupported, Err: errors.New("local and remote changes have diverged; merging them requires the exec backend")}
+	case errors.Is(err, transport.ErrEmptyRemoteRepository),
+		errors.Is(err, plumbing.ErrReferenceNotFound),
+		errors.As(err, &noMatch):
+		return &Error{Op: "pull", Kind: ErrNoUpstream, Err: err}
+	default:
+		return remoteError(ctx, "pull", err)
+	}
+}
+
+// IsMerging returns true if a merge is in progress.
+func (c *goGitClient) IsMerging() bool {
+	return isMerging(c.dir)
+}
+
+// ConflictedFiles returns the files with unresolved merge conflicts.
+func (c *goGitClient) ConflictedFiles(ctx context.Context) ([]ConflictedFile, error) {
+	idx, err := c.index()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ConflictedFile
+	positions := make(map[string]int)
+	for _, e := range idx.Entries {
+		if e.Stage == index.Merged {
+			continue
+		}
+		i, seen := positions[e.Name]
+		if !seen {
+			i = len(files)
+			positions[e.Name] = i
+			files = append(files, ConflictedFile{Path: e.Name})
+		}
+		switch e.Stage {
+		case index.OurMode:
+			files[i].Ours = true
+		case index.TheirMode:
+			files[i].Theirs = true
+		}
+	}
+	return files, nil
+}
+
+// ShowStage returns the content of path at the given index stage.
+func (c *goGitClient) ShowStage(ctx context.Context, stage int, path string) ([]byte, error) {
+	idx, err := c.index()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range idx.Entries {
+		if e.Name == path && int(e.Stage) == stage {
+			repo, err := c.open()
+			if err != nil {
+				return nil, err
+			}
+			blob, err := repo.BlobObject(e.Hash)
+			if err != nil {
+				return nil, &Error{Op: "show", Err: err}
+			}
+			return readBlob(blob)
+		}
+	}
+	return nil, &Error{Op: "show", Err: fmt.Errorf("%s has no stage %d", path, stage)}
+}
+
+// Remove removes files from the working tree and the index.
+func (c *goGitClient) Remove(ctx context.Context, paths ...string) error {
+	w, err := c.worktree()
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if _, err := w.Remove(p); err != nil {
+			return &Error{Op: "rm", Err: err}
+		}
+	}
+	return nil
+}
+
+// AheadBehind returns how many commits the current branch is ahead of and
+// behind its upstream.
+func (c *goGitClient) AheadBehind(ctx context.Context) (ahead, behind int, err error) {
+	upstream, err := c.Upstream(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	repo, err := c.open()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return 0, 0, &Error{Op: "rev-list", Err: err}
+	}
+	remoteRef, err := repo.Reference(plumbing.ReferenceName("refs/remotes/"+upstream), true)
+	if err != nil {
+		return 0, 0, &Error{Op: "rev-list", Err: err}
+	}
+
+	local, err := ancestors(repo, head.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+	remote, err := ancestors(repo, remoteRef.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+	for h := range local {
+		if !remote[h] {
+			ahead++
+		}
+	}
+	for h := range remote {
+		if !local[h] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// ancestors returns the commits reachable from hash, including itself.
+func ancestors(repo *gogit.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, &Error{Op: "rev-list", Err: err}
+	}
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Op: "rev-list", Err: err}
+	}
+	return seen, nil
+}
+
+// RemoteAdd adds a remote repository.
+func (c *goGitClient) RemoteAdd(ctx context.Context, name, url string) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+		return &Error{Op: "remote add", Err: err}
+	}
+	return nil
+}
+
+// RemoteGetURL gets the URL of a remote repository.
+func (c *goGitClient) RemoteGetURL(ctx context.Context, name string) (string, error) {
+	repo, err := c.open()
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return "", &Error{Op: "remote get-url", Err: err}
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", &Error{Op: "remote get-url", Err: fmt.Errorf("remote %q has no URL", name)}
+	}
+	return urls[0], nil
+}
+
+// HasRemote checks if a remote with the given name exists.
+func (c *goGitClient) HasRemote(ctx context.Context, name string) bool {
+	_, err := c.RemoteGetURL(ctx, name)
+	return err == nil
+}
+
+// Status returns the status of the repository.
+func (c *goGitClient) Status(ctx context.Context) (*Status, error) {
+	w, err := c.worktree()
+	if err != nil {
+		return nil, err
+	}
+	st, err := w.Status()
+	if err != nil {
+		return nil, &Error{Op: "status", Err: err}
+	}
+
+	paths := make([]string, 0, len(st))
+	for p := range st {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	status := &Status{}
+	for _, p := range paths {
+		fs := st[p]
+		e := StatusEntry{Path: p, Index: fileState(fs.Staging), Worktree: fileState(fs.Worktree)}
+		if fs.Staging == gogit.Renamed || fs.Staging == gogit.Copied {
+			e.OrigPath = fs.Extra
+		}
+		status.add(e)
+	}
+	return status, nil
+}
+
+// fileState converts a go-git status code.
+func fileState(code gogit.StatusCode) FileState {
+	if code == gogit.Unmodified {
+		return StateUnmodified
+	}
+	return FileState(code)
+}
+
+// GetCurrentBranch returns the current branch name, or "HEAD" if HEAD is
+// detached. It works before the first commit.
+func (c *goGitClient) GetCurrentBranch(ctx context.Context) (string, error) {
+	repo, err := c.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", &Error{Op: "symbolic-ref", Err: err}
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "HEAD", nil
+	}
+	return head.Target().Short(), nil
+}
+
+// CheckoutBranch switches to or creates a branch.
+func (c *goGitClient) CheckoutBranch(ctx context.Context, branch string, create bool) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	ref := plumbing.NewBranchReferenceName(branch)
+
+	// Before the first commit there is nothing to check out
+	if _, err := repo.Head(); errors.Is(err, plumbing.ErrReferenceNotFound) && create {
+		if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref)); err != nil {
+			return &Error{Op: "checkout", Err: err}
+		}
+		return nil
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return &Error{Op: "checkout", Err: err}
+	}
+	if err := w.Checkout(&gogit.CheckoutOptions{Branch: ref, Create: create, Keep: true}); err != nil {
+		return &Error{Op: "checkout", Err: err}
+	}
+	return nil
+}
+
+// BranchRename renames the current branch to the specified name.
+func (c *goGitClient) BranchRename(ctx context.Context, newName string) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	oldName, err := c.GetCurrentBranch(ctx)
+	if err != nil {
+		return err
+	}
+	if oldName == "HEAD" {
+		return &Error{Op: "branch", Err: errors.New("HEAD is detached")}
+	}
+	oldRef, newRef := plumbing.NewBranchReferenceName(oldName), plumbing.NewBranchReferenceName(newName)
+
+	if ref, err := repo.Reference(oldRef, false); err == nil {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(newRef, ref.Hash())); err != nil {
+			return &Error{Op: "branch", Err: err}
+		}
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, newRef)); err != nil {
+		return &Error{Op: "branch", Err: err}
+	}
+	if oldRef != newRef {
+		if err := repo.Storer.RemoveReference(oldRef); err != nil {
+			return &Error{Op: "branch", Err: err}
+		}
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return &Error{Op: "branch", Err: err}
+	}
+	if b, ok := cfg.Branches[oldName]; ok && oldName != newName {
+		delete(cfg.Branches, oldName)
+		cfg.Branches[newName] = &gitconfig.Branch{Name: newName, Remote: b.Remote, Merge: b.Merge, Rebase: b.Rebase}
+		if err := repo.SetConfig(cfg); err != nil {
+			return &Error{Op: "branch", Err: err}
+		}
+	}
+	return nil
+}
+
+// ConfigSet sets a git configuration value in the repository.
+func (c *goGitClient) ConfigSet(ctx context.Context, key, value string) error {
+	section, subsection, option, err := splitConfigKey(key)
+	if err != nil {
+		return err
+	}
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return &Error{Op: "config", Err: err}
+	}
+
+	s := cfg.Raw.Section(section)
+	if subsection != "" {
+		s.Subsection(subsection).SetOption(option, value)
+	} else {
+		s.SetOption(option, value)
+	}
+	if err := repo.SetConfig(cfg); err != nil {
+		return &Error{Op: "config", Err: err}
+	}
+	return nil
+}
+
+// ConfigGet gets a git configuration value from the repository, global or
+// system configuration, in that order.
+func (c *goGitClient) ConfigGet(ctx context.Context, key string) (string, error) {
+	section, subsection, option, err := splitConfigKey(key)
+	if err != nil {
+		return "", err
+	}
+	repo, err := c.open()
+	if err != nil {
+		return "", err
+	}
+
+	local, err := repo.Config()
+	if err != nil {
+		return "", &Error{Op: "config", Err: err}
+	}
+	configs := []*gitconfig.Config{local}
+	for _, scope := range []gitconfig.Scope{gitconfig.GlobalScope, gitconfig.SystemScope} {
+		if cfg, err := gitconfig.LoadConfig(scope); err == nil {
+			configs = append(configs, cfg)
+		}
+	}
+
+	for _, cfg := range configs {
+		if !cfg.Raw.HasSection(section) {
+			continue
+		}
+		s := cfg.Raw.Section(section)
+		if subsection != "" {
+			if !s.HasSubsection(subsection) {
+				continue
+			}
+			if sub := s.Subsection(subsection); sub.HasOption(option) {
+				return sub.Option(option), nil
+			}
+		} else if s.HasOption(option) {
+			return s.Option(option), nil
+		}
+	}
+	return "", &Error{Op: "config", Err: fmt.Errorf("%s is not set", key)}
+}
+
+// splitConfigKey splits "section.option" or "section.subsection.option".
+func splitConfigKey(key string) (section, subsection, option string, err error) {
+	first := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if first <= 0 || last == len(key)-1 {
+		return "", "", "", &Error{Op: "config", Err: fmt.Errorf("invalid key %q", key)}
+	}
+	section, option = key[:first], key[last+1:]
+	if first != last {
+		subsection = key[first+1 : last]
+	}
+	return section, subsection, option, nil
+}
+
+// EnsureUserConfig ensures user.email and user.name are configured.
+func (c *goGitClient) EnsureUserConfig(ctx context.Context) error {
+	return ensureUserConfig(ctx, c)
+}
+
+// Fetch fetches changes from remote.
+func (c *goGitClient) Fetch(ctx context.Context) error {
+	repo, err := c.open()
+	if err != nil {
+		return err
+	}
+	err = repo.FetchContext(ctx, &gogit.FetchOptions{RemoteName: "origin"})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return remoteError(ctx, "fetch", err)
+	}
+	return nil
+}
+
+// Upstream returns the name of the upstream branch of the current branch.
+// Returns ErrNoUpstream if none is configured or it has not been fetched.
+func (c *goGitClient) Upstream(ctx context.Context) (string, error) {
+	repo, err := c.open()
+	if err != nil {
+		return "", err
+	}
+	branch, err := c.GetCurrentBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	remote, merge, err := c.upstreamConfig(repo, branch)
+	if err != nil {
+		return "", err
+	}
+
+	name := remote + "/" + merge.Short()
+	if _, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, merge.Short()), false); err != nil {
+		return "", &Error{Op: "upstream", Kind: ErrNoUpstream, Err: fmt.Errorf("%s has not been fetched", name)}
+	}
+	return name, nil
+}
+
+// upstreamConfig returns the remote and branch that branch tracks.
+// Returns ErrNoUpstream if it does not track one.
+func (c *goGitClient) upstreamConfig(repo *gogit.Repository, branch string) (string, plumbing.ReferenceName, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", "", &Error{Op: "config", Err: err}
+	}
+	b, ok := cfg.Branches[branch]
+	if !ok || b.Remote == "" || b.Merge == "" {
+		return "", "", &Error{Op: "upstream", Kind: ErrNoUpstream, Err: fmt.Errorf("branch %q does not track a remote branch", branch)}
+	}
+	return b.Remote, b.Merge, nil
+}
+
+// DiffNames returns the paths of files that differ between two revisions.
+func (c *goGitClient) DiffNames(ctx context.Context, from, to string) ([]string, error) {
+	fromTree, err := c.tree(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := c.tree(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, &Error{Op: "diff", Err: err}
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, ch := range changes {
+		for _, name := range []string{ch.From.Name, ch.To.Name} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				paths = append(paths, name)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Log returns the commits reachable from rev that touch any of paths.
+func (c *goGitClient) Log(ctx context.Context, rev string, limit int, paths ...string) ([]LogEntry, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	hash, err := c.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &gogit.LogOptions{From: plumbing.NewHash(hash), Order: gogit.LogOrderCommitterTime}
+	if len(paths) > 0 {
+		opts.PathFilter = func(p string) bool { return underAny(p, paths) }
+	}
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return nil, &Error{Op: "log", Err: err}
+	}
+	defer iter.Close()
+
+	var entries []LogEntry
+	for limit <= 0 || len(entries) < limit {
+		commit, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &Error{Op: "log", Err: err}
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		entries = append(entries, LogEntry{
+			Hash:      commit.Hash.String(),
+			ShortHash: commit.Hash.String()[:7],
+			Author:    commit.Author.Name,
+			Date:      commit.Author.When,
+			Subject:   strings.TrimSpace(subject),
+		})
+	}
+	return entries, nil
+}
+
+// ResolveRevision returns the full commit hash that rev refers to.
+func (c *goGitClient) ResolveRevision(ctx context.Context, rev string) (string, error) {
+	repo, err := c.open()
+	if err != nil {
+		return "", err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	if _, err := repo.CommitObject(*hash); err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return hash.String(), nil
+}
+
+// Show returns the content of path at revision rev.
+func (c *goGitClient) Show(ctx context.Context, rev, path string) ([]byte, error) {
+	tree, err := c.tree(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	file, err := tree.File(path)
+	if err != nil {
+		return nil, &Error{Op: "show", Err: fmt.Errorf("%s:%s: %w", rev, path, err)}
+	}
+	return readBlob(&file.Blob)
+}
+
+// ListTree returns the files under paths at revision rev, recursively.
+func (c *goGitClient) ListTree(ctx context.Context, rev string, paths ...string) ([]string, error) {
+	tree, err := c.tree(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if len(paths) == 0 || underAny(f.Name, paths) {
+			files = append(files, f.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Op: "ls-tree", Err: err}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// GetDir returns the directory of the git client.
+func (c *goGitClient) GetDir() string {
+	return c.dir
+}
+
+// open opens the repository.
+func (c *goGitClient) open() (*gogit.Repository, error) {
+	repo, err := gogit.PlainOpen(c.dir)
+	if err != nil {
+		return nil, &Error{Op: "open", Err: err}
+	}
+	return repo, nil
+}
+
+// worktree opens the repository's working tree.
+func (c *goGitClient) worktree() (*gogit.Worktree, error) {
+	repo, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, &Error{Op: "worktree", Err: err}
+	}
+	return w, nil
+}
+
+// index reads the repository's index.
+func (c *goGitClient) index() (*index.Index, error) {
+	repo, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, &Error{Op: "index", Err: err}
+	}
+	return idx, nil
+}
+
+// tree returns the tree of the commit that rev refers to.
+func (c *goGitClient) tree(ctx context.Context, rev string) (*object.Tree, error) {
+	hash, err := c.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, &Error{Op: "show", Err: err}
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, &Error{Op: "show", Err: err}
+	}
+	return tree, nil
+}
+
+// readBlob returns the content of a blob.
+func readBlob(blob *object.Blob) ([]byte, error) {
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, &Error{Op: "show", Err: err}
+	}
+	defer func() { _ = r.Close() }()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &Error{Op: "show", Err: err}
+	}
+	return data, nil
+}
+
+// underAny reports whether p is one of paths or inside one of them.
+func underAny(p string, paths []string) bool {
+	for _, prefix := range paths {
+		prefix = strings.TrimSuffix(filepath.ToSlash(prefix), "/")
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteError wraps a failed remote operation. An operation interrupted
+// because ctx is done is reported with the context's error.
+func remoteError(ctx context.Context, op string, err error) *Error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return &Error{Op: op, Kind: contextKind(ctxErr), Err: ctxErr}
+	}
+	return &Error{Op: op, Kind: classify(err), Err: err}
+}
+
+// classify maps go-git transport errors to the package's sentinel errors.
+func classify(err error) error {
+	err = unwrapTransport(err)
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod):
+		return ErrAuthenticationFailed
+	case errors.As(err, &netErr):
+		return ErrNetworkError
+	}
+	return nil
+}
+
+// unwrapTransport returns the cause of go-git's transport error wrappers,
+// which do not implement Unwrap.
+func unwrapTransport(err error) error {
+	for {
+		var unexpected *plumbing.UnexpectedError
+		var permanent *plumbing.PermanentError
+		switch {
+		case errors.As(err, &unexpected):
+			err = unexpected.Err
+		case errors.As(err, &permanent):
+			err = permanent.Err
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package git
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBareRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	cmd.Dir = dir
+	require.NoError(t, cmd.Run())
+	return dir
+}
+
+func TestNewClient(t *testing.T) {
+	for _, backend := range []string{"", BackendExec, BackendGoGit} {
+		client, err := NewClient(backend, t.TempDir())
+		require.NoError(t, err)
+		assert.NotNil(t, client)
+	}
+
+	_, err := NewClient("libgit2", t.TempDir())
+	assert.ErrorContains(t, err, "unknown git backend")
+}
+
+func TestCloneEmptyRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping clone test in short mode")
+	}
+
+	for _, backend := range []string{BackendExec, BackendGoGit} {
+		t.Run(backend, func(t *testing.T) {
+			bareDir := newBareRepo(t)
+			client, err := NewClient(backend, t.TempDir())
+			require.NoError(t, err)
+
+			err = client.Clone(t.Context(), bareDir, "main")
+			assert.ErrorIs(t, err, ErrEmptyRepository)
+
+			var gitErr *Error
+			require.ErrorAs(t, err, &gitErr)
+			assert.Equal(t, ErrEmptyRepository, gitErr.Kind)
+		})
+	}
+}
+
+func TestGoGitClient(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go-git test in short mode")
+	}
+
+	bareDir := newBareRepo(t)
+	newClient := func() Client {
+		client, err := NewClient(BackendGoGit, t.TempDir())
+		require.NoError(t, err)
+		return client
+	}
+	write := func(c Client, path, content string) {
+		full := filepath.Join(c.GetDir(), filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+	commit := func(c Client, path, content, message string) {
+		write(c, path, content)
+		require.NoError(t, c.Add(t.Context(), "."))
+		require.NoError(t, c.Commit(t.Context(), message))
+	}
+
+	client1 := newClient()
+	require.NoError(t, client1.Init(t.Context()))
+	assert.True(t, client1.IsRepo())
+	require.NoError(t, client1.EnsureUserConfig(t.Context()))
+	email, err := client1.ConfigGet(t.Context(), "user.email")
+	require.NoError(t, err)
+	assert.NotEmpty(t, email)
+
+	commit(client1, "test.txt", "v1\n", "initial")
+	require.NoError(t, client1.BranchRename(t.Context(), "main"))
+	branch, err := client1.GetCurrentBranch(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	require.NoError(t, client1.RemoteAdd(t.Context(), "origin", bareDir))
+	url, err := client1.RemoteGetURL(t.Context(), "origin")
+	require.NoError(t, err)
+	assert.Equal(t, bareDir, url)
+
+	t.Run("push without upstream reports ErrNoUpstream", func(t *testing.T) {
+		assert.ErrorIs(t, client1.Push(t.Context()), ErrNoUpstream)
+		_, err := client1.Upstream(t.Context())
+		assert.ErrorIs(t, err, ErrNoUpstream)
+	})
+
+	require.NoError(t, client1.PushWithUpstream(t.Context(), "origin", "main"))
+	upstream, err := client1.Upstream(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "origin/main", upstream)
+
+	client2 := newClient()
+	require.NoError(t, client2.Clone(t.Context(), bareDir, "main"))
+	require.NoError(t, client2.EnsureUserConfig(t.Context()))
+	data, err := os.ReadFile(filepath.Join(client2.GetDir(), "test.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1\n", string(data))
+
+	t.Run("fetch and fast-forward pull", func(t *testing.T) {
+		commit(client1, "templates/a/AGENTS.md", "# A\n", "add template")
+		require.NoError(t, client1.Push(t.Context()))
+
+		require.NoError(t, client2.Fetch(t.Context()))
+		ahead, behind, err := client2.AheadBehind(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 0, ahead)
+		assert.Equal(t, 1, behind)
+
+		paths, err := client2.DiffNames(t.Context(), "HEAD", "origin/main")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"templates/a/AGENTS.md"}, paths)
+
+		require.NoError(t, client2.Pull(t.Context()))
+		_, err = os.Stat(filepath.Join(client2.GetDir(), "templates", "a", "AGENTS.md"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		entries, err := client2.Log(t.Context(), "", 0, "templates/a")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "add template", entries[0].Subject)
+		assert.Len(t, entries[0].ShortHash, 7)
+
+		hash, err := client2.ResolveRevision(t.Context(), entries[0].ShortHash+"~1")
+		require.NoError(t, err)
+		assert.Len(t, hash, 40)
+
+		content, err := client2.Show(t.Context(), hash, "test.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "v1\n", string(content))
+
+		files, err := client2.ListTree(t.Context(), "HEAD", "templates")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"templates/a/AGENTS.md"}, files)
+
+		_, err = client2.ResolveRevision(t.Context(), "does-not-exist")
+		assert.Error(t, err)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		write(client2, "new.txt", "new")
+		write(client2, "test.txt", "changed\n")
+		require.NoError(t, os.Remove(filepath.Join(client2.GetDir(), "templates", "a", "AGENTS.md")))
+
+		status, err := client2.Status(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new.txt"}, status.Untracked)
+		assert.Equal(t, []string{"test.txt"}, status.Modified)
+		assert.Equal(t, []string{"templates/a/AGENTS.md"}, status.Deleted)
+
+		require.NoError(t, client2.Add(t.Context(), "."))
+		require.NoError(t, client2.Commit(t.Context(), "local changes"))
+		status, err = client2.Status(t.Context())
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+		assert.Error(t, client2.Commit(t.Context(), "nothing"), "empty commits are rejected")
+	})
+
+	t.Run("diverged pull is not supported", func(t *testing.T) {
+		commit(client1, "test.txt", "remote\n", "remote change")
+		require.NoError(t, client1.Push(t.Context()))
+
+		err := client2.Pull(t.Context())
+		assert.ErrorIs(t, err, ErrNotSupported)
+		assert.False(t, client2.IsMerging())
+	})
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, ErrAuthenticationFailed, classify(transport.ErrAuthenticationRequired))
+	assert.Equal(t, ErrNetworkError, classify(&plumbing.UnexpectedError{Err: &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}}))
+	assert.Nil(t, classify(os.ErrNotExist))
+}
@@ -0,0 +1,149 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileState is the state of a file on one side of a status entry, using the
+// letters of `git status --porcelain`.
+type FileState byte
+
+// File states.
+const (
+	StateUnmodified  FileState = '.'
+	StateModified    FileState = 'M'
+	StateTypeChanged FileState = 'T'
+	StateAdded       FileState = 'A'
+	StateDeleted     FileState = 'D'
+	StateRenamed     FileState = 'R'
+	StateCopied      FileState = 'C'
+	StateUnmerged    FileState = 'U'
+	StateUntracked   FileState = '?'
+)
+
+// StatusEntry is the state of a changed path.
+type StatusEntry struct {
+	// Path is the current path of the file.
+	Path string
+	// OrigPath is the path the file was renamed or copied from.
+	OrigPath string
+	// Index is the state of the index compared with HEAD (staged changes).
+	Index FileState
+	// Worktree is the state of the working tree compared with the index
+	// (unstaged changes).
+	Worktree FileState
+}
+
+// Staged returns true if the entry has changes in the index.
+func (e StatusEntry) Staged() bool {
+	return e.Index != StateUnmodified && e.Index != StateUntracked
+}
+
+// Unstaged returns true if the entry has changes in the working tree that
+// are not in the index.
+func (e StatusEntry) Unstaged() bool {
+	return e.Worktree != StateUnmodified
+}
+
+// Rename describes a renamed or copied file.
+type Rename struct {
+	From string
+	To   string
+}
+
+// Status represents the status of a Git repository.
+//
+// Each changed path is listed in exactly one of Added, Modified, Deleted,
+// Renamed, Conflicted or Untracked, by its combined staged and unstaged
+// change. Entries keeps the index and working tree states separately.
+type Status struct {
+	Added      []string
+	Modified   []string
+	Deleted    []string
+	Renamed    []Rename
+	Conflicted []string
+	Untracked  []string
+
+	Entries []StatusEntry
+}
+
+// IsClean returns true if there are no uncommitted changes.
+func (s *Status) IsClean() bool {
+	return len(s.Entries) == 0
+}
+
+// add records an entry and files it under its category.
+func (s *Status) add(e StatusEntry) {
+	s.Entries = append(s.Entries, e)
+
+	switch {
+	case e.Index == StateUntracked:
+		s.Untracked = append(s.Untracked, e.Path)
+	case e.Index == StateUnmerged || e.Worktree == StateUnmerged:
+		s.Conflicted = append(s.Conflicted, e.Path)
+	case e.Index == StateDeleted || e.Worktree == StateDeleted:
+		s.Deleted = append(s.Deleted, e.Path)
+	case e.OrigPath != "":
+		s.Renamed = append(s.Renamed, Rename{From: e.OrigPath, To: e.Path})
+	case e.Index == StateAdded || e.Worktree == StateAdded:
+		s.Added = append(s.Added, e.Path)
+	default:
+		s.Modified = append(s.Modified, e.Path)
+	}
+}
+
+// parseStatus parses the output of `git status --porcelain=v2 -z`.
+// Paths are not quoted in this format, so they may contain any character.
+func parseStatus(output string) (*Status, error) {
+	status := &Status{}
+	records := strings.Split(output, "\x00")
+
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+
+		switch record[0] {
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			fields := strings.SplitN(record, " ", 9)
+			if len(fields) != 9 || len(fields[1]) != 2 {
+				return nil, fmt.Errorf("malformed status entry %q", record)
+			}
+			status.add(StatusEntry{Path: fields[8], Index: FileState(fields[1][0]), Worktree: FileState(fields[1][1])})
+
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path>, then <origPath>
+			fields := strings.SplitN(record, " ", 10)
+			if len(fields) != 10 || len(fields[1]) != 2 || i+1 >= len(records) {
+				return nil, fmt.Errorf("malformed status entry %q", record)
+			}
+			i++
+			status.add(StatusEntry{Path: fields[9], OrigPath: records[i], Index: FileState(fields[1][0]), Worktree: FileState(fields[1][1])})
+
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) != 11 {
+				return nil, fmt.Errorf("malformed status entry %q", record)
+			}
+			status.add(StatusEntry{Path: fields[10], Index: StateUnmerged, Worktree: StateUnmerged})
+
+		case '?':
+			path, ok := strings.CutPrefix(record, "? ")
+			if !ok {
+				return nil, fmt.Errorf("malformed status entry %q", record)
+			}
+			status.add(StatusEntry{Path: path, Index: StateUntracked, Worktree: StateUntracked})
+
+		case '!', '#':
+			// Ignored files and headers
+		default:
+			return nil, fmt.Errorf("unknown status entry %q", record)
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatus(t *testing.T) {
+	const hashes = "100644 100644 100644 1111111111111111111111111111111111111111 2222222222222222222222222222222222222222"
+
+	t.Run("empty output is clean", func(t *testing.T) {
+		status, err := parseStatus("")
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+	})
+
+	t.Run("categorizes entries", func(t *testing.T) {
+		output := "# branch.oid 3333333333333333333333333333333333333333\x00" +
+			"1 .M N... " + hashes + " modified.txt\x00" +
+			"1 A. N... 000000 100644 100644 0000000000000000000000000000000000000000 2222222222222222222222222222222222222222 added.txt\x00" +
+			"1 .D N... " + hashes + " deleted.txt\x00" +
+			"2 R. N... " + hashes + " R100 new name.md\x00old name.md\x00" +
+			"u UU N... 100644 100644 100644 100644 1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 4444444444444444444444444444444444444444 conflict.txt\x00" +
+			"? templates/日本語.md\x00" +
+			"! ignored.log\x00"
+
+		status, err := parseStatus(output)
+		require.NoError(t, err)
+		assert.False(t, status.IsClean())
+		assert.Equal(t, []string{"modified.txt"}, status.Modified)
+		assert.Equal(t, []string{"added.txt"}, status.Added)
+		assert.Equal(t, []string{"deleted.txt"}, status.Deleted)
+		assert.Equal(t, []Rename{{From: "old name.md", To: "new name.md"}}, status.Renamed)
+		assert.Equal(t, []string{"conflict.txt"}, status.Conflicted)
+		assert.Equal(t, []string{"templates/日本語.md"}, status.Untracked)
+		assert.Len(t, status.Entries, 6)
+	})
+
+	t.Run("distinguishes staged and unstaged changes", func(t *testing.T) {
+		output := "1 M. N... " + hashes + " staged.txt\x00" +
+			"1 .M N... " + hashes + " unstaged.txt\x00" +
+			"1 MM N... " + hashes + " both.txt\x00"
+
+		status, err := parseStatus(output)
+		require.NoError(t, err)
+		require.Len(t, status.Entries, 3)
+
+		staged, unstaged, both := status.Entries[0], status.Entries[1], status.Entries[2]
+		assert.True(t, staged.Staged())
+		assert.False(t, staged.Unstaged())
+		assert.False(t, unstaged.Staged())
+		assert.True(t, unstaged.Unstaged())
+		assert.True(t, both.Staged())
+		assert.True(t, both.Unstaged())
+		assert.Equal(t, StateModified, both.Index)
+		assert.Equal(t, StateModified, both.Worktree)
+	})
+
+	t.Run("rejects malformed entries", func(t *testing.T) {
+		for _, output := range []string{
+			"1 .M N...\x00",
+			"2 R. N... " + hashes + " R100 new.md",
+			"u UU N...\x00",
+			"?missing-space\x00",
+			"x unknown\x00",
+		} {
+			_, err := parseStatus(output)
+			assert.Error(t, err, output)
+		}
+	})
+}
+
+func TestStatusRenamesAndSpaces(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	run("init")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "old name.md"), []byte("hello\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "keep.md"), []byte("keep\n"), 0644))
+	run("add", ".")
+	run("commit", "-m", "initial")
+
+	run("mv", "old name.md", "new name.md")
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "keep.md"), []byte("changed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "café notes.md"), []byte("new\n"), 0644))
+
+	status, err := New(tmpDir).Status(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []Rename{{From: "old name.md", To: "new name.md"}}, status.Renamed)
+	assert.Equal(t, []string{"keep.md"}, status.Modified)
+	assert.Equal(t, []string{"café notes.md"}, status.Untracked)
+	assert.Empty(t, status.Deleted)
+	assert.Empty(t, status.Added)
+}
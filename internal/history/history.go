@@ -0,0 +1,270 @@
+// Package history keeps a local, deduplicated version history of templates.
+//
+// Each snapshot is a manifest mapping file paths to content-addressed blobs.
+// Blobs are stored once by their SHA-256 hash, so unchanged files cost
+// nothing across snapshots.
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openjny/dotgh/internal/diff"
+)
+
+// DirName is the name of the history directory inside the templates directory.
+// Hidden directories are not treated as templates.
+const DirName = ".history"
+
+// Snapshot sources.
+const (
+	SourcePush    = "push"
+	SourcePrePush = "pre-push"
+	SourceEdit    = "edit"
+	SourceConvert = "convert"
+	SourceMCP     = "mcp"
+)
+
+// ErrRevisionNotFound is returned when a snapshot revision does not exist.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Snapshot is a recorded version of a template.
+type Snapshot struct {
+	Rev    int               `json:"rev"`
+	Time   time.Time         `json:"time"`
+	Source string            `json:"source"`
+	Files  map[string]string `json:"files"` // relative path -> blob hash
+}
+
+// Store is a history store rooted at a directory.
+type Store struct {
+	dir string
+}
+
+// New creates a store rooted at dir.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// ForTemplatesDir returns the store kept inside templatesDir.
+func ForTemplatesDir(templatesDir string) *Store {
+	return New(filepath.Join(templatesDir, DirName))
+}
+
+// Record snapshots the files in templateDir under name. If the content is the
+// same as the latest snapshot, nothing is recorded and the latest snapshot is
+// returned with created set to false. A missing templateDir records nothing.
+func (s *Store) Record(name, templateDir, source string) (snap *Snapshot, created bool, err error) {
+	if info, err := os.Stat(templateDir); err != nil || !info.IsDir() {
+		return nil, false, nil
+	}
+
+	paths, err := diff.ListFiles(templateDir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	files := make(map[string]string, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(filepath.Join(templateDir, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, false, fmt.Errorf("read %s: %w", p, err)
+		}
+		hash, err := s.writeBlob(data)
+		if err != nil {
+			return nil, false, err
+		}
+		files[p] = hash
+	}
+
+	snapshots, err := s.List(name)
+	if err != nil {
+		return nil, false, err
+	}
+	rev := 1
+	if len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		if maps.Equal(latest.Files, files) {
+			return &latest, false, nil
+		}
+		rev = latest.Rev + 1
+	}
+
+	snap = &Snapshot{Rev: rev, Time: time.Now().UTC(), Source: source, Files: files}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, false, fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := writeFileAtomic(s.manifestPath(name, rev), data); err != nil {
+		return nil, false, fmt.Errorf("write manifest: %w", err)
+	}
+	return snap, true, nil
+}
+
+// List returns the snapshots of a template, oldest first.
+func (s *Store) List(name string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.manifestDir(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		rev, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		snap, err := s.Get(name, rev)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Rev < snapshots[j].Rev })
+	return snapshots, nil
+}
+
+// Get returns a single snapshot. Returns ErrRevisionNotFound if it does not exist.
+func (s *Store) Get(name string, rev int) (*Snapshot, error) {
+	data, err := os.ReadFile(s.manifestPath(name, rev))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s@%d", ErrRevisionNotFound, name, rev)
+		}
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parse manifest %s@%d: %w", name, rev, err)
+	}
+	for p, hash := range snap.Files {
+		if !validPath(p) {
+			return nil, fmt.Errorf("parse manifest %s@%d: invalid path %q", name, rev, p)
+		}
+		if !validHash(hash) {
+			return nil, fmt.Errorf("parse manifest %s@%d: invalid blob hash %q for %s", name, rev, hash, p)
+		}
+	}
+	return &snap, nil
+}
+
+// Extract writes the files of a snapshot into dir.
+func (s *Store) Extract(snap *Snapshot, dir string) error {
+	for p, hash := range snap.Files {
+		if !validPath(p) {
+			return fmt.Errorf("invalid path %q", p)
+		}
+		if !validHash(hash) {
+			return fmt.Errorf("invalid blob hash %q for %s", hash, p)
+		}
+		data, err := os.ReadFile(s.blobPath(hash))
+		if err != nil {
+			return fmt.Errorf("read blob for %s: %w", p, err)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return fmt.Errorf("write %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// writeBlob stores data under its content hash and returns the hash.
+// Existing blobs are not rewritten.
+func (s *Store) writeBlob(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	path := s.blobPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	return hash, nil
+}
+
+// validHash reports whether hash is a hex-encoded SHA-256 as written by
+// writeBlob, so that a damaged manifest cannot point outside the store.
+func validHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// validPath reports whether p is a relative slash-separated path as written
+// by Record, so that a damaged manifest cannot write outside the directory
+// a snapshot is extracted into.
+func validPath(p string) bool {
+	local := filepath.FromSlash(p)
+	return !strings.Contains(p, "\\") && filepath.IsLocal(local) && filepath.Clean(local) == local
+}
+
+// blobPath returns the path of a blob, sharded by the first two hash characters.
+func (s *Store) blobPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash[2:])
+}
+
+// manifestDir returns the directory holding the manifests of a template.
+func (s *Store) manifestDir(name string) string {
+	return filepath.Join(s.dir, "templates", name)
+}
+
+// manifestPath returns the path of a manifest.
+func (s *Store) manifestPath(name string, rev int) string {
+	return filepath.Join(s.manifestDir(name), strconv.Itoa(rev)+".json")
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ParseRef splits a "<template>@<rev>" reference. rev is 0 if no revision is given.
+func ParseRef(ref string) (name string, rev int, err error) {
+	name, revStr, ok := strings.Cut(ref, "@")
+	if !ok {
+		return ref, 0, nil
+	}
+	rev, err = strconv.Atoi(revStr)
+	if err != nil || rev < 1 {
+		return "", 0, fmt.Errorf("invalid revision %q in %q: must be a positive number", revStr, ref)
+	}
+	return name, rev, nil
+}
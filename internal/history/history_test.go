@@ -0,0 +1,180 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func countBlobs(t *testing.T, storeDir string) int {
+	t.Helper()
+	count := 0
+	err := filepath.Walk(filepath.Join(storeDir, "objects"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			count++
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return count
+}
+
+func TestRecord(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := filepath.Join(templatesDir, "myproject")
+	store := ForTemplatesDir(templatesDir)
+	storeDir := filepath.Join(templatesDir, DirName)
+
+	writeFile(t, templateDir, "AGENTS.md", "v1")
+	writeFile(t, templateDir, ".github/copilot-instructions.md", "shared")
+
+	snap, created, err := store.Record("myproject", templateDir, SourcePush)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, 1, snap.Rev)
+	assert.Equal(t, SourcePush, snap.Source)
+	assert.Len(t, snap.Files, 2)
+
+	t.Run("unchanged content is not recorded again", func(t *testing.T) {
+		snap, created, err := store.Record("myproject", templateDir, SourceEdit)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, 1, snap.Rev)
+	})
+
+	t.Run("blobs are deduplicated", func(t *testing.T) {
+		writeFile(t, templateDir, "AGENTS.md", "v2")
+		snap, created, err := store.Record("myproject", templateDir, SourceEdit)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, 2, snap.Rev)
+
+		// v1, v2 and the shared file
+		assert.Equal(t, 3, countBlobs(t, storeDir))
+
+		// The same content in another template reuses existing blobs
+		other := filepath.Join(templatesDir, "other")
+		writeFile(t, other, "AGENTS.md", "v1")
+		_, _, err = store.Record("other", other, SourcePush)
+		require.NoError(t, err)
+		assert.Equal(t, 3, countBlobs(t, storeDir))
+	})
+
+	t.Run("missing template records nothing", func(t *testing.T) {
+		snap, created, err := store.Record("missing", filepath.Join(templatesDir, "missing"), SourcePush)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Nil(t, snap)
+	})
+}
+
+func TestListGetExtract(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := filepath.Join(templatesDir, "myproject")
+	store := ForTemplatesDir(templatesDir)
+
+	snapshots, err := store.List("myproject")
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	writeFile(t, templateDir, "AGENTS.md", "v1")
+	_, _, err = store.Record("myproject", templateDir, SourcePush)
+	require.NoError(t, err)
+	writeFile(t, templateDir, "AGENTS.md", "v2")
+	writeFile(t, templateDir, "extra.md", "extra")
+	_, _, err = store.Record("myproject", templateDir, SourceEdit)
+	require.NoError(t, err)
+
+	snapshots, err = store.List("myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, 1, snapshots[0].Rev)
+	assert.Equal(t, 2, snapshots[1].Rev)
+
+	snap, err := store.Get("myproject", 1)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, store.Extract(snap, dir))
+	content, err := os.ReadFile(filepath.Join(dir, "AGENTS.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+	_, err = os.Stat(filepath.Join(dir, "extra.md"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = store.Get("myproject", 9)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		wantName string
+		wantRev  int
+		wantErr  bool
+	}{
+		{ref: "myproject", wantName: "myproject"},
+		{ref: "myproject@3", wantName: "myproject", wantRev: 3},
+		{ref: "myproject@0", wantErr: true},
+		{ref: "myproject@abc", wantErr: true},
+		{ref: "myproject@", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			name, rev, err := ParseRef(tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantRev, rev)
+		})
+	}
+}
+
+func TestInvalidHash(t *testing.T) {
+	templatesDir := t.TempDir()
+	store := ForTemplatesDir(templatesDir)
+	manifest := filepath.Join(templatesDir, DirName, "templates", "myproject", "1.json")
+	writeFile(t, filepath.Dir(manifest), "1.json", `{"rev": 1, "source": "push", "files": {"AGENTS.md": "a"}}`)
+
+	_, err := store.Get("myproject", 1)
+	assert.ErrorContains(t, err, `invalid blob hash "a" for AGENTS.md`)
+	_, err = store.List("myproject")
+	assert.Error(t, err)
+
+	for _, hash := range []string{"", "a", "../../../../etc/passwd", strings.Repeat("z", 64)} {
+		snap := &Snapshot{Rev: 1, Files: map[string]string{"AGENTS.md": hash}}
+		assert.ErrorContains(t, store.Extract(snap, t.TempDir()), "invalid blob hash", hash)
+	}
+}
+
+func TestInvalidPath(t *testing.T) {
+	templatesDir := t.TempDir()
+	store := ForTemplatesDir(templatesDir)
+	hash := strings.Repeat("a", 64)
+	manifest := filepath.Join(templatesDir, DirName, "templates", "myproject")
+	writeFile(t, manifest, "1.json", `{"rev": 1, "source": "push", "files": {"../AGENTS.md": "`+hash+`"}}`)
+
+	_, err := store.Get("myproject", 1)
+	assert.ErrorContains(t, err, `invalid path "../AGENTS.md"`)
+
+	dest := filepath.Join(t.TempDir(), "dest")
+	for _, p := range []string{"", "../AGENTS.md", "a/../../AGENTS.md", "/etc/AGENTS.md", "./AGENTS.md", `..\AGENTS.md`} {
+		snap := &Snapshot{Rev: 1, Files: map[string]string{p: hash}}
+		assert.ErrorContains(t, store.Extract(snap, dest), "invalid path", p)
+	}
+	assert.NoDirExists(t, dest)
+}
@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// object is a JSON object that keeps the order of its keys, so files can be
+// rewritten without reordering the settings of the user.
+type object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]json.RawMessage)}
+}
+
+// parseObject parses data as a JSON object. Comments and trailing commas,
+// which VS Code allows in its settings files, are accepted, but comments are
+// not kept (see hasComments).
+func parseObject(data []byte) (*object, error) {
+	data, _ = stripJSONC(data)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return newObject(), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	o := newObject()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		o.set(key, value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// get returns the value of key.
+func (o *object) get(key string) (json.RawMessage, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// set sets the value of key, appending the key if it is new.
+func (o *object) set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// setValue sets key to the JSON encoding of value.
+func (o *object) setValue(key string, value any) {
+	data, _ := json.Marshal(value)
+	o.set(key, data)
+}
+
+// delete removes key. It reports whether the key was present.
+func (o *object) delete(key string) bool {
+	if _, ok := o.values[key]; !ok {
+		return false
+	}
+	delete(o.values, key)
+	o.keys = slices.DeleteFunc(o.keys, func(k string) bool { return k == key })
+	return true
+}
+
+// MarshalJSON encodes the object with its keys in order.
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(o.values[key])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// indent encodes the object indented by two spaces, ending with a newline.
+func (o *object) indent() ([]byte, error) {
+	data, err := o.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// hasComments reports whether data, JSON with comments, has comments.
+func hasComments(data []byte) bool {
+	_, comments := stripJSONC(data)
+	return comments
+}
+
+// stripJSONC removes comments and trailing commas from JSON with comments.
+// It reports whether there were comments.
+func stripJSONC(data []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(data))
+	inString := false
+	comments := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			comments = true
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			comments = true
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out, comments
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// Drop a comma before the closing bracket
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, comments
+}
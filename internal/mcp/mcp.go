@@ -0,0 +1,459 @@
+// Package mcp reads and writes the MCP server configuration of AI coding
+// tools. Each tool declares servers in its own file and dialect; Server is
+// the definition they have in common.
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/openjny/dotgh/internal/secret"
+)
+
+// Server transports.
+const (
+	TypeStdio = "stdio"
+	TypeHTTP  = "http"
+	TypeSSE   = "sse"
+)
+
+// Server is an MCP server definition. Env and header values refer to
+// environment variables as ${NAME}, whatever the form of the dialect.
+type Server struct {
+	Name string `json:"name"`
+	// Type is the transport: stdio, http or sse.
+	Type    string            `json:"type"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Validate reports whether the server can be written.
+func (s Server) Validate() error {
+	switch s.Type {
+	case TypeStdio:
+		if s.Command == "" {
+			return fmt.Errorf("server %q: a stdio server needs a command", s.Name)
+		}
+		if s.URL != "" || len(s.Headers) > 0 {
+			return fmt.Errorf("server %q: a stdio server has no url or headers", s.Name)
+		}
+	case TypeHTTP, TypeSSE:
+		if s.URL == "" {
+			return fmt.Errorf("server %q: an %s server needs a url", s.Name, s.Type)
+		}
+		if s.Command != "" || len(s.Args) > 0 || len(s.Env) > 0 {
+			return fmt.Errorf("server %q: an %s server has no command, args or env", s.Name, s.Type)
+		}
+	default:
+		return fmt.Errorf("server %q: unknown type %q (stdio, http or sse)", s.Name, s.Type)
+	}
+	return nil
+}
+
+// Equal reports whether s and other define the same server.
+func (s Server) Equal(other Server) bool {
+	return s.Name == other.Name && s.Type == other.Type && s.Command == other.Command &&
+		slices.Equal(s.Args, other.Args) && maps.Equal(s.Env, other.Env) &&
+		s.URL == other.URL && maps.Equal(s.Headers, other.Headers)
+}
+
+// typeField says when a dialect writes the type of a server.
+type typeField int
+
+const (
+	typeNever typeField = iota
+	typeRemote
+	typeAlways
+)
+
+// Dialect is the MCP configuration file of a tool.
+type Dialect struct {
+	// Tool is the name of the tool, matching its preset.
+	Tool string
+	Path string
+	// Key is the key of the object holding the servers.
+	Key string
+	// Secondary dialects are only written if the file declares servers
+	// already, as the tool has another file for them.
+	Secondary bool
+
+	types typeField
+	// httpURLKey is set if http servers have their URL in httpUrl, and url
+	// is left for sse servers.
+	httpURLKey bool
+}
+
+// Dialects lists the supported MCP configuration files.
+var Dialects = []Dialect{
+	{Tool: "copilot", Path: ".vscode/mcp.json", Key: "servers", types: typeAlways},
+	{Tool: "cursor", Path: ".cursor/mcp.json", Key: "mcpServers"},
+	{Tool: "claude", Path: ".mcp.json", Key: "mcpServers", types: typeRemote},
+	{Tool: "claude", Path: ".claude/settings.json", Key: "mcpServers", types: typeRemote, Secondary: true},
+	{Tool: "gemini", Path: ".gemini/settings.json", Key: "mcpServers", httpURLKey: true},
+}
+
+// Tools returns the names of the tools with a dialect.
+func Tools() []string {
+	var tools []string
+	for _, d := range Dialects {
+		if !slices.Contains(tools, d.Tool) {
+			tools = append(tools, d.Tool)
+		}
+	}
+	return tools
+}
+
+// decode converts the definition of a server in the dialect.
+func (d Dialect) decode(name string, raw json.RawMessage) (Server, error) {
+	var def struct {
+		Type    string            `json:"type"`
+		Command string            `json:"command"`
+		Args    []string          `json:"args"`
+		Env     map[string]string `json:"env"`
+		URL     string            `json:"url"`
+		HTTPURL string            `json:"httpUrl"`
+		Headers map[string]string `json:"headers"`
+	}
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return Server{}, fmt.Errorf("server %q: %w", name, err)
+	}
+	s := Server{Name: name, Type: def.Type, Command: def.Command, Args: def.Args, Env: readRefs(def.Env), URL: def.URL, Headers: readRefs(def.Headers)}
+	if def.HTTPURL != "" {
+		s.Type, s.URL = TypeHTTP, def.HTTPURL
+	}
+	if s.Type == "" {
+		switch {
+		case s.Command != "":
+			s.Type = TypeStdio
+		case d.httpURLKey:
+			s.Type = TypeSSE
+		default:
+			s.Type = TypeHTTP
+		}
+	}
+	// Some tools call the streamable HTTP transport by its full name
+	if s.Type == "streamable-http" || s.Type == "streamableHttp" {
+		s.Type = TypeHTTP
+	}
+	return s, nil
+}
+
+// encode writes s into def, the definition of the server in the dialect.
+// Fields that Server does not model are kept, and so is the order of the
+// fields.
+func (d Dialect) encode(def *object, s Server) {
+	fields := make(map[string]any)
+	if d.types == typeAlways || (d.types == typeRemote && s.Type != TypeStdio) {
+		fields["type"] = s.Type
+	}
+	switch {
+	case s.Type == TypeStdio:
+		fields["command"] = s.Command
+		if len(s.Args) > 0 {
+			fields["args"] = s.Args
+		}
+		if len(s.Env) > 0 {
+			fields["env"] = d.writeRefs(s.Env)
+		}
+	case d.httpURLKey && s.Type == TypeHTTP:
+		fields["httpUrl"] = s.URL
+	default:
+		fields["url"] = s.URL
+	}
+	if len(s.Headers) > 0 {
+		fields["headers"] = d.writeRefs(s.Headers)
+	}
+	for _, key := range []string{"type", "command", "args", "env", "url", "httpUrl", "headers"} {
+		if value, ok := fields[key]; ok {
+			def.setValue(key, value)
+		} else {
+			def.delete(key)
+		}
+	}
+}
+
+// reference matches the references to variables in env and header values.
+var reference = regexp.MustCompile(`\$\{[^${}]+\}`)
+
+// readRefs returns values with the references to environment variables of
+// any dialect in the neutral ${NAME} form.
+func readRefs(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	read := make(map[string]string, len(values))
+	for key, value := range values {
+		read[key] = reference.ReplaceAllStringFunc(value, func(ref string) string {
+			if name, ok := secret.ReferenceName(ref); ok {
+				return "${" + name + "}"
+			}
+			return ref
+		})
+	}
+	return read
+}
+
+// writeRefs returns values with the ${NAME} references to environment
+// variables in the form of the dialect.
+func (d Dialect) writeRefs(values map[string]string) map[string]string {
+	written := make(map[string]string, len(values))
+	for key, value := range values {
+		written[key] = reference.ReplaceAllStringFunc(value, func(ref string) string {
+			if name, ok := secret.ReferenceName(ref); ok {
+				return secret.Reference(d.Path, name)
+			}
+			return ref
+		})
+	}
+	return written
+}
+
+// refNames returns the names of the environment variables that s refers to,
+// sorted.
+func refNames(s Server) []string {
+	var names []string
+	for _, values := range []map[string]string{s.Env, s.Headers} {
+		for _, value := range values {
+			for _, ref := range reference.FindAllString(value, -1) {
+				if name, ok := secret.ReferenceName(ref); ok && !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ErrComments is returned when changing a file with comments, which
+// rewriting the file would drop.
+var ErrComments = errors.New("the file has comments, which rewriting it would drop; remove them or edit the file by hand")
+
+// File is an MCP configuration file.
+type File struct {
+	Dialect Dialect
+	doc     *object
+	servers *object
+	// comments is set if the file has comments, so it is not rewritten.
+	comments bool
+}
+
+// NewFile returns an empty file of the dialect.
+func NewFile(d Dialect) *File {
+	return &File{Dialect: d, doc: newObject(), servers: newObject()}
+}
+
+// ParseFile parses the content of a file of the dialect.
+func ParseFile(d Dialect, data []byte) (*File, error) {
+	doc, err := parseObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", d.Path, err)
+	}
+	f := &File{Dialect: d, doc: doc, servers: newObject(), comments: hasComments(data)}
+	if raw, ok := doc.get(d.Key); ok && string(raw) != "null" {
+		if f.servers, err = parseObject(raw); err != nil {
+			return nil, fmt.Errorf("parse %s: %s: %w", d.Path, d.Key, err)
+		}
+	}
+	return f, nil
+}
+
+// ReadFile reads the file of the dialect in dir. It returns nil if the file
+// does not exist.
+func ReadFile(dir string, d Dialect) (*File, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(d.Path)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseFile(d, data)
+}
+
+// ReadFiles reads the files of every dialect that exist in dir.
+func ReadFiles(dir string) ([]*File, error) {
+	var files []*File
+	for _, d := range Dialects {
+		f, err := ReadFile(dir, d)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// HasServers reports whether the file declares servers.
+func (f *File) HasServers() bool {
+	_, ok := f.doc.get(f.Dialect.Key)
+	return ok
+}
+
+// Servers returns the servers of the file, in order.
+func (f *File) Servers() ([]Server, error) {
+	var servers []Server
+	for _, name := range f.servers.keys {
+		s, err := f.Dialect.decode(name, f.servers.values[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Dialect.Path, err)
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// Set adds s to the file, or replaces the server of the same name. It
+// reports whether the file changed. A file with comments is not changed;
+// ErrComments is returned instead.
+func (f *File) Set(s Server) (bool, error) {
+	if err := s.Validate(); err != nil {
+		return false, err
+	}
+	def := newObject()
+	if raw, ok := f.servers.get(s.Name); ok {
+		if existing, err := f.Dialect.decode(s.Name, raw); err == nil && existing.Equal(s) {
+			return false, nil
+		}
+		if parsed, err := parseObject(raw); err == nil {
+			def = parsed
+		}
+	}
+	if f.comments {
+		return false, fmt.Errorf("%s: %w", f.Dialect.Path, ErrComments)
+	}
+	f.Dialect.encode(def, s)
+	data, err := def.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+	f.servers.set(s.Name, data)
+	if err := f.addInputs(refNames(s)); err != nil {
+		return false, err
+	}
+	return true, f.update()
+}
+
+// input is a VS Code input, as written for ${input:...} references.
+type input struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Password    bool   `json:"password"`
+}
+
+// addInputs declares the inputs that the references to the environment
+// variables names are written as in the dialect, if it uses inputs, so the
+// tool asks for the values. Inputs declared already are kept.
+func (f *File) addInputs(names []string) error {
+	var inputs []json.RawMessage
+	if raw, ok := f.doc.get("inputs"); ok {
+		if err := json.Unmarshal(raw, &inputs); err != nil {
+			return fmt.Errorf("%s: inputs: %w", f.Dialect.Path, err)
+		}
+	}
+	declared := make(map[string]bool)
+	for _, raw := range inputs {
+		var in input
+		if json.Unmarshal(raw, &in) == nil {
+			declared[in.ID] = true
+		}
+	}
+	added := false
+	for _, name := range names {
+		id, ok := strings.CutPrefix(secret.Reference(f.Dialect.Path, name), "${input:")
+		if !ok {
+			return nil
+		}
+		id = strings.TrimSuffix(id, "}")
+		if declared[id] {
+			continue
+		}
+		data, err := json.Marshal(input{Type: "promptString", ID: id, Description: name, Password: true})
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, data)
+		declared[id] = true
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return err
+	}
+	f.doc.set("inputs", data)
+	return nil
+}
+
+// Remove removes the server with the given name. It reports whether the
+// file had it. A file with comments is not changed; ErrComments is returned
+// instead.
+func (f *File) Remove(name string) (bool, error) {
+	if _, ok := f.servers.get(name); !ok {
+		return false, nil
+	}
+	if f.comments {
+		return false, fmt.Errorf("%s: %w", f.Dialect.Path, ErrComments)
+	}
+	f.servers.delete(name)
+	return true, f.update()
+}
+
+// update writes the servers back into the document.
+func (f *File) update() error {
+	data, err := f.servers.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	f.doc.set(f.Dialect.Key, data)
+	return nil
+}
+
+// Bytes returns the content of the file, indented by two spaces.
+func (f *File) Bytes() ([]byte, error) {
+	return f.doc.indent()
+}
+
+// Write writes the file into dir.
+func (f *File) Write(dir string) error {
+	data, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, filepath.FromSlash(f.Dialect.Path))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ParseAssignments parses KEY=VALUE pairs, as given for env and headers.
+func ParseAssignments(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid %q: expected KEY=VALUE", pair)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
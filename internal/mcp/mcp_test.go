@@ -0,0 +1,318 @@
+package mcp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func dialect(t *testing.T, path string) Dialect {
+	t.Helper()
+	for _, d := range Dialects {
+		if d.Path == path {
+			return d
+		}
+	}
+	t.Fatalf("no dialect for %s", path)
+	return Dialect{}
+}
+
+func TestServers(t *testing.T) {
+	github := Server{Name: "github", Type: TypeStdio, Command: "npx", Args: []string{"-y", "server-github"}, Env: map[string]string{"TOKEN": "${TOKEN}"}}
+	docs := Server{Name: "docs", Type: TypeHTTP, URL: "https://example.com/mcp", Headers: map[string]string{"X-Key": "k"}}
+	events := Server{Name: "events", Type: TypeSSE, URL: "https://example.com/sse"}
+
+	tests := []struct {
+		path    string
+		content string
+		want    []Server
+	}{
+		{
+			path: ".vscode/mcp.json",
+			content: `{
+  // Servers for this project
+  "servers": {
+    "github": {"type": "stdio", "command": "npx", "args": ["-y", "server-github"], "env": {"TOKEN": "${env:TOKEN}"}},
+    "docs": {"type": "http", "url": "https://example.com/mcp", "headers": {"X-Key": "k"},},
+  },
+  "inputs": []
+}`,
+			want: []Server{github, docs},
+		},
+		{
+			path:    ".cursor/mcp.json",
+			content: `{"mcpServers": {"github": {"command": "npx", "args": ["-y", "server-github"], "env": {"TOKEN": "${env:TOKEN}"}}, "docs": {"url": "https://example.com/mcp", "headers": {"X-Key": "k"}}}}`,
+			want:    []Server{github, docs},
+		},
+		{
+			path:    ".mcp.json",
+			content: `{"mcpServers": {"events": {"type": "sse", "url": "https://example.com/sse"}, "docs": {"type": "streamable-http", "url": "https://example.com/mcp", "headers": {"X-Key": "k"}}}}`,
+			want:    []Server{events, docs},
+		},
+		{
+			path:    ".gemini/settings.json",
+			content: `{"theme": "Default", "mcpServers": {"docs": {"httpUrl": "https://example.com/mcp", "headers": {"X-Key": "k"}}, "events": {"url": "https://example.com/sse", "trust": true}}}`,
+			want:    []Server{docs, events},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			f, err := ParseFile(dialect(t, tt.path), []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			got, err := f.Servers()
+			if err != nil {
+				t.Fatalf("Servers() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Servers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	github := Server{Name: "github", Type: TypeStdio, Command: "npx", Args: []string{"server-github"}}
+	docs := Server{Name: "docs", Type: TypeHTTP, URL: "https://example.com/mcp"}
+
+	tests := []struct {
+		path    string
+		content string
+		servers []Server
+		want    string
+	}{
+		{
+			path:    ".vscode/mcp.json",
+			servers: []Server{github, docs},
+			want: `{
+  "servers": {
+    "github": {
+      "type": "stdio",
+      "command": "npx",
+      "args": [
+        "server-github"
+      ]
+    },
+    "docs": {
+      "type": "http",
+      "url": "https://example.com/mcp"
+    }
+  }
+}
+`,
+		},
+		{
+			path:    ".mcp.json",
+			servers: []Server{github, docs},
+			want:    `{"mcpServers":{"github":{"command":"npx","args":["server-github"]},"docs":{"type":"http","url":"https://example.com/mcp"}}}`,
+		},
+		{
+			path:    ".gemini/settings.json",
+			content: `{"theme": "Default", "mcpServers": {"docs": {"url": "https://old.example.com/sse", "timeout": 5000}}, "checkpointing": {"enabled": true}}`,
+			servers: []Server{docs},
+			want:    `{"theme":"Default","mcpServers":{"docs":{"timeout":5000,"httpUrl":"https://example.com/mcp"}},"checkpointing":{"enabled":true}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			f, err := ParseFile(dialect(t, tt.path), []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			for _, s := range tt.servers {
+				if changed, err := f.Set(s); err != nil || !changed {
+					t.Fatalf("Set(%s) = %v, %v; want a change", s.Name, changed, err)
+				}
+			}
+			got, err := f.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			want := tt.want
+			if !strings.Contains(want, "\n") {
+				want = string(mustIndent(t, want))
+			}
+			if string(got) != want {
+				t.Errorf("Bytes() = %s, want %s", got, want)
+			}
+
+			// Setting the same server again changes nothing
+			if changed, err := f.Set(tt.servers[0]); err != nil || changed {
+				t.Errorf("Set() again = %v, %v; want no change", changed, err)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	local := Server{Name: "github", Type: TypeStdio, Command: "npx", Env: map[string]string{"GITHUB_TOKEN": "${GITHUB_TOKEN}", "ROOT": "${workspaceFolder}"}}
+	remote := Server{Name: "docs", Type: TypeHTTP, URL: "https://example.com/mcp", Headers: map[string]string{"Authorization": "Bearer ${API_KEY}"}}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{".vscode/mcp.json", []string{`"${input:github-token}"`, `"Bearer ${input:api-key}"`, `"id": "api-key"`, `"id": "github-token"`}},
+		{".cursor/mcp.json", []string{`"${env:GITHUB_TOKEN}"`, `"Bearer ${env:API_KEY}"`}},
+		{".mcp.json", []string{`"${GITHUB_TOKEN}"`, `"Bearer ${API_KEY}"`}},
+		{".claude/settings.json", []string{`"${GITHUB_TOKEN}"`, `"Bearer ${API_KEY}"`}},
+		{".gemini/settings.json", []string{`"${GITHUB_TOKEN}"`, `"Bearer ${API_KEY}"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			f := NewFile(dialect(t, tt.path))
+			for _, s := range []Server{local, remote} {
+				if _, err := f.Set(s); err != nil {
+					t.Fatalf("Set(%s) error = %v", s.Name, err)
+				}
+			}
+			data, err := f.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			for _, want := range append(tt.want, `"${workspaceFolder}"`) {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("Bytes() = %s, want %s in it", data, want)
+				}
+			}
+
+			parsed, err := ParseFile(f.Dialect, data)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			got, err := parsed.Servers()
+			if err != nil {
+				t.Fatalf("Servers() error = %v", err)
+			}
+			if want := []Server{local, remote}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Servers() = %+v, want %+v", got, want)
+			}
+			if changed, err := parsed.Set(local); err != nil || changed {
+				t.Errorf("Set() again = %v, %v; want no change", changed, err)
+			}
+		})
+	}
+
+	t.Run("inputs declared already are kept", func(t *testing.T) {
+		content := `{"servers": {}, "inputs": [{"type": "promptString", "id": "github-token", "description": "GitHub token"}]}`
+		f, err := ParseFile(dialect(t, ".vscode/mcp.json"), []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Set(local); err != nil {
+			t.Fatal(err)
+		}
+		data, err := f.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(data), `"id"`); n != 1 || !strings.Contains(string(data), "GitHub token") {
+			t.Errorf("Bytes() = %s, want the declared input only", data)
+		}
+	})
+}
+
+func mustIndent(t *testing.T, compact string) []byte {
+	t.Helper()
+	o, err := parseObject([]byte(compact))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := o.indent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRemove(t *testing.T) {
+	f, err := ParseFile(dialect(t, ".cursor/mcp.json"), []byte(`{"mcpServers": {"a": {"command": "a"}, "b": {"command": "b"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := f.Remove("a"); err != nil || !removed {
+		t.Fatalf("Remove(a) = %v, %v", removed, err)
+	}
+	if removed, _ := f.Remove("missing"); removed {
+		t.Error("Remove(missing) = true, want false")
+	}
+	got, _ := f.Bytes()
+	if want := mustIndent(t, `{"mcpServers":{"b":{"command":"b"}}}`); string(got) != string(want) {
+		t.Errorf("Bytes() = %s, want %s", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		server  Server
+		wantErr string
+	}{
+		{Server{Name: "a", Type: TypeStdio}, "needs a command"},
+		{Server{Name: "a", Type: TypeStdio, Command: "x", URL: "u"}, "has no url"},
+		{Server{Name: "a", Type: TypeHTTP}, "needs a url"},
+		{Server{Name: "a", Type: TypeSSE, URL: "u", Command: "x"}, "has no command"},
+		{Server{Name: "a", Type: "ws"}, `unknown type "ws"`},
+	}
+	for _, tt := range tests {
+		if err := tt.server.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.server, err, tt.wantErr)
+		}
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	input := "{\n  // comment\n  \"a\": \"http://x\", /* block */\n  \"b\": [1, 2,],\n}"
+	want := "{\n  \n  \"a\": \"http://x\", \n  \"b\": [1, 2]\n}"
+	got, comments := stripJSONC([]byte(input))
+	if string(got) != want || !comments {
+		t.Errorf("stripJSONC() = %q, %v, want %q, true", got, comments, want)
+	}
+	if _, comments := stripJSONC([]byte(`{"a": "// not a comment", "b": [1,],}`)); comments {
+		t.Error("stripJSONC() found comments in a file without them")
+	}
+}
+
+func TestCommentedFile(t *testing.T) {
+	content := `{
+  // Servers for this project
+  "servers": {"docs": {"type": "http", "url": "https://example.com/mcp"}}
+}`
+	f, err := ParseFile(dialect(t, ".vscode/mcp.json"), []byte(content))
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if servers, err := f.Servers(); err != nil || len(servers) != 1 {
+		t.Errorf("Servers() = %v, %v, want the docs server", servers, err)
+	}
+
+	// Rewriting the file would drop the comment
+	if _, err := f.Set(Server{Name: "events", Type: TypeSSE, URL: "https://example.com/sse"}); !errors.Is(err, ErrComments) {
+		t.Errorf("Set() error = %v, want ErrComments", err)
+	}
+	if _, err := f.Remove("docs"); !errors.Is(err, ErrComments) {
+		t.Errorf("Remove() error = %v, want ErrComments", err)
+	}
+
+	// Nothing to change is not an error
+	if changed, err := f.Set(Server{Name: "docs", Type: TypeHTTP, URL: "https://example.com/mcp"}); changed || err != nil {
+		t.Errorf("Set() of an unchanged server = %v, %v", changed, err)
+	}
+	if changed, err := f.Remove("missing"); changed || err != nil {
+		t.Errorf("Remove() of a missing server = %v, %v", changed, err)
+	}
+	if servers, _ := f.Servers(); len(servers) != 1 || servers[0].Name != "docs" {
+		t.Errorf("the file should be unchanged, got %v", servers)
+	}
+}
+
+func TestParseAssignments(t *testing.T) {
+	got, err := ParseAssignments([]string{"A=1", "B=x=y"})
+	if err != nil || !reflect.DeepEqual(got, map[string]string{"A": "1", "B": "x=y"}) {
+		t.Errorf("ParseAssignments() = %v, %v", got, err)
+	}
+	if _, err := ParseAssignments([]string{"A"}); err == nil {
+		t.Error("expected an error for a pair without =")
+	}
+}
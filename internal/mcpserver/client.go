@@ -0,0 +1,174 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// ErrClosed is returned by a client whose connection is closed.
+var ErrClosed = errors.New("MCP connection closed")
+
+// Client is an MCP client. Calls are made one at a time.
+type Client struct {
+	mu      sync.Mutex
+	w       io.Writer
+	scanner *bufio.Scanner
+	closer  io.Closer
+	nextID  int
+	done    <-chan error
+}
+
+// NewClient returns a client that writes requests to w and reads responses
+// from r.
+func NewClient(r io.Reader, w io.Writer) *Client {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Client{w: w, scanner: scanner}
+}
+
+// Connect runs s in the background and returns a client connected to it
+// in-process. Closing the client stops the server.
+func Connect(ctx context.Context, s *Server) *Client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := s.Serve(ctx, serverIn, serverOut)
+		_ = serverOut.CloseWithError(ErrClosed)
+		done <- err
+	}()
+	c := NewClient(clientIn, clientOut)
+	c.closer, c.done = clientOut, done
+	return c
+}
+
+// Close closes the connection, and waits for an in-process server to stop.
+func (c *Client) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+	if err := c.closer.Close(); err != nil {
+		return err
+	}
+	return <-c.done
+}
+
+// Call sends a request and decodes its result into result, if not nil.
+func (c *Client) Call(method string, params, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	if err := c.send(request{JSONRPC: "2.0", ID: id, Method: method, Params: mustMarshal(params)}); err != nil {
+		return err
+	}
+	for c.scanner.Scan() {
+		var resp struct {
+			ID     json.RawMessage `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *Error          `json:"error"`
+		}
+		if err := json.Unmarshal(c.scanner.Bytes(), &resp); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		// Skip notifications and responses to other requests
+		if string(resp.ID) != string(id) {
+			continue
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+	if err := c.scanner.Err(); err != nil && !errors.Is(err, ErrClosed) {
+		return err
+	}
+	return ErrClosed
+}
+
+// Notify sends a notification, which gets no response.
+func (c *Client) Notify(method string, params any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.send(request{JSONRPC: "2.0", Method: method, Params: mustMarshal(params)})
+}
+
+func (c *Client) send(req request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := c.w.Write(append(data, '\n')); err != nil {
+		if errors.Is(err, io.ErrClosedPipe) {
+			return ErrClosed
+		}
+		return err
+	}
+	return nil
+}
+
+// mustMarshal encodes params, which are built by the caller and always
+// encodable.
+func mustMarshal(params any) json.RawMessage {
+	if params == nil {
+		return nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		panic(fmt.Sprintf("encode params: %v", err))
+	}
+	return data
+}
+
+// Initialize starts the MCP session, and returns the name and version of the
+// server.
+func (c *Client) Initialize() (name, version string, err error) {
+	var result struct {
+		ServerInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]string{"name": "dotgh", "version": "dev"},
+	}
+	if err := c.Call("initialize", params, &result); err != nil {
+		return "", "", err
+	}
+	if err := c.Notify("notifications/initialized", nil); err != nil {
+		return "", "", err
+	}
+	return result.ServerInfo.Name, result.ServerInfo.Version, nil
+}
+
+// ListTools returns the tools of the server.
+func (c *Client) ListTools() ([]Tool, error) {
+	var result struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := c.Call("tools/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Tools, nil
+}
+
+// CallTool calls a tool with the given arguments.
+func (c *Client) CallTool(name string, args any) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.Call("tools/call", map[string]any{"name": name, "arguments": args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
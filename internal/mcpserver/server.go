@@ -0,0 +1,219 @@
+// Package mcpserver implements the server side of the Model Context Protocol
+// (MCP), so that AI agents can call the tools of dotgh. Messages are
+// JSON-RPC 2.0, one per line, as in the stdio transport of MCP.
+package mcpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ProtocolVersion is the latest MCP version the server speaks.
+const ProtocolVersion = "2025-06-18"
+
+// protocolVersions lists the MCP versions the server speaks, newest first.
+var protocolVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a tool that agents can call.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the JSON schema of the arguments.
+	InputSchema json.RawMessage `json:"inputSchema"`
+	// Handler runs the tool with the arguments given by the agent. The
+	// result is returned to the agent as JSON. An error is returned to the
+	// agent as a failed call, so it can correct the arguments.
+	Handler func(ctx context.Context, args json.RawMessage) (any, error) `json:"-"`
+}
+
+// Server is an MCP server exposing tools.
+type Server struct {
+	name         string
+	version      string
+	instructions string
+	tools        []Tool
+}
+
+// New returns a server with the given name and version, which it reports to
+// clients. instructions tells agents what the server is for.
+func New(name, version, instructions string) *Server {
+	return &Server{name: name, version: version, instructions: instructions}
+}
+
+// AddTool adds a tool to the server.
+func (s *Server) AddTool(tool Tool) {
+	s.tools = append(s.tools, tool)
+}
+
+// request is a JSON-RPC request or notification. Notifications have no ID.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Content is a content block of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallToolResult is the result of a tool call.
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	// StructuredContent is the result of the tool as a JSON object.
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Serve reads requests from r and writes responses to w until r ends or ctx
+// is canceled. Requests are handled in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	write := func(resp response) error {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: codeParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		result, rpcErr := s.handle(ctx, req)
+		// Notifications get no response
+		if req.ID == nil {
+			continue
+		}
+		if err := write(response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle runs a request and returns its result.
+func (s *Server) handle(ctx context.Context, req request) (any, *Error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &Error{Code: codeInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}
+	}
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if slices.Contains(protocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+			"instructions":    s.instructions,
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		tools := s.tools
+		if tools == nil {
+			tools = []Tool{}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	default:
+		if req.ID == nil {
+			// Notifications such as notifications/initialized need no handling
+			return nil, nil
+		}
+		return nil, &Error{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// callTool runs the tool named in params.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, *Error) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &Error{Code: codeInvalidParams, Message: err.Error()}
+	}
+	i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == call.Name })
+	if i < 0 {
+		return nil, &Error{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", call.Name)}
+	}
+	if len(call.Arguments) == 0 || string(call.Arguments) == "null" {
+		call.Arguments = json.RawMessage("{}")
+	}
+
+	value, err := s.tools[i].Handler(ctx, call.Arguments)
+	if err != nil {
+		return CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return CallToolResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("encode result: %v", err)}}, IsError: true}, nil
+	}
+	return CallToolResult{Content: []Content{{Type: "text", Text: string(data)}}, StructuredContent: data}, nil
+}
+
+// DecodeArgs decodes the arguments of a tool call into v, rejecting
+// arguments that v has no field for.
+func DecodeArgs(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestServer() *Server {
+	s := New("test", "1.0", "Test server")
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echo a message",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"message":{"type":"string"}}}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (any, error) {
+			var in struct {
+				Message string `json:"message"`
+			}
+			if err := DecodeArgs(args, &in); err != nil {
+				return nil, err
+			}
+			if in.Message == "" {
+				return nil, errors.New("message is required")
+			}
+			return map[string]string{"message": in.Message}, nil
+		},
+	})
+	return s
+}
+
+func TestClient(t *testing.T) {
+	c := Connect(context.Background(), newTestServer())
+
+	name, version, err := c.Initialize()
+	if err != nil || name != "test" || version != "1.0" {
+		t.Fatalf("Initialize() = %q, %q, %v", name, version, err)
+	}
+
+	tools, err := c.ListTools()
+	if err != nil || len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("ListTools() = %+v, %v", tools, err)
+	}
+
+	result, err := c.CallTool("echo", map[string]string{"message": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if result.IsError || string(result.StructuredContent) != `{"message":"hi"}` {
+		t.Errorf("CallTool() = %+v", result)
+	}
+
+	// Tool errors are results, so the agent sees them
+	result, err = c.CallTool("echo", map[string]string{})
+	if err != nil || !result.IsError || result.Content[0].Text != "message is required" {
+		t.Errorf("CallTool() without message = %+v, %v", result, err)
+	}
+	result, err = c.CallTool("echo", map[string]string{"msg": "hi"})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, `unknown field "msg"`) {
+		t.Errorf("CallTool() with unknown argument = %+v, %v", result, err)
+	}
+
+	// Protocol errors are errors
+	var rpcErr *Error
+	if _, err := c.CallTool("missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != codeInvalidParams {
+		t.Errorf("CallTool(missing) error = %v", err)
+	}
+	if err := c.Call("resources/list", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != codeMethodNotFound {
+		t.Errorf("Call(resources/list) error = %v", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := c.ListTools(); !errors.Is(err, ErrClosed) {
+		t.Errorf("ListTools() after Close() error = %v, want ErrClosed", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":"a","method":"ping"}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := newTestServer().Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d responses, want 3:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], `"protocolVersion":"2024-11-05"`) {
+		t.Errorf("initialize response = %s, want the requested version", lines[0])
+	}
+	if !strings.Contains(lines[1], `"code":-32700`) {
+		t.Errorf("response to invalid JSON = %s, want a parse error", lines[1])
+	}
+	if lines[2] != `{"jsonrpc":"2.0","id":"a","result":{}}` {
+		t.Errorf("ping response = %s", lines[2])
+	}
+}
@@ -52,3 +52,41 @@ func Confirm(message string, defaultNo bool, w io.Writer, r io.Reader) (bool, er
 func ConfirmWithDefault(message string, w io.Writer, r io.Reader) (bool, error) {
 	return Confirm(message, true, w, r)
 }
+
+// Select asks the user to choose one of options.
+// An option may be entered by its full name or its first letter (case-insensitive).
+// Invalid input repeats the question. Returns an error if input ends before
+// a valid option is entered.
+func Select(message string, options []string, w io.Writer, r io.Reader) (string, error) {
+	labels := make([]string, len(options))
+	for i, opt := range options {
+		labels[i] = "[" + opt[:1] + "]" + opt[1:]
+	}
+	prompt := fmt.Sprintf("%s (%s): ", message, strings.Join(labels, ", "))
+
+	reader := bufio.NewReader(r)
+	for {
+		if _, err := fmt.Fprint(w, prompt); err != nil {
+			return "", fmt.Errorf("write prompt: %w", err)
+		}
+
+		input, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("read input: %w", err)
+		}
+		answer := strings.TrimSpace(strings.ToLower(input))
+
+		if answer != "" {
+			for _, opt := range options {
+				if answer == strings.ToLower(opt) || answer == strings.ToLower(opt[:1]) {
+					return opt, nil
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return "", fmt.Errorf("no option selected")
+		}
+		_, _ = fmt.Fprintf(w, "Please enter one of: %s\n", strings.Join(options, ", "))
+	}
+}
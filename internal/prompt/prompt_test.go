@@ -186,3 +186,36 @@ func TestConfirmWithDefault(t *testing.T) {
 	assert.False(t, got)
 	assert.Contains(t, out.String(), "[y/N]")
 }
+
+func TestSelect(t *testing.T) {
+	options := []string{"local", "remote", "edit"}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "full name", input: "remote\n", want: "remote"},
+		{name: "first letter", input: "e\n", want: "edit"},
+		{name: "uppercase", input: "L\n", want: "local"},
+		{name: "invalid input asks again", input: "x\nr\n", want: "remote"},
+		{name: "EOF without newline", input: "l", want: "local"},
+		{name: "EOF without selection", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := Select("Keep which version?", options, &out, strings.NewReader(tt.input))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, out.String(), "Keep which version? ([l]ocal, [r]emote, [e]dit): ")
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Placeholder returns the placeholder that replaces the finding's secret.
+func Placeholder(f Finding) string {
+	return Reference(f.Path, envName(f))
+}
+
+// Reference returns the reference to the environment variable name in the
+// file relPath. VS Code MCP configs use ${input:...} prompts, Cursor uses
+// ${env:...}, and all other files use ${NAME}.
+func Reference(relPath, name string) string {
+	switch mcpConfigName(relPath) {
+	case ".vscode/mcp.json":
+		return "${input:" + InputID(name) + "}"
+	case ".cursor/mcp.json":
+		return "${env:" + name + "}"
+	}
+	return "${" + name + "}"
+}
+
+var (
+	// envVarName matches the environment variable names that references
+	// are written for. Lower case names, like VS Code's ${workspaceFolder},
+	// are variables of the tools.
+	envVarName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+	// inputIDPattern matches the input ids that InputID returns.
+	inputIDPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+)
+
+// ReferenceName returns the name of the environment variable that ref, in
+// any of the forms Reference returns, refers to. It returns false if ref is
+// not such a reference.
+func ReferenceName(ref string) (string, bool) {
+	inner, ok := strings.CutPrefix(ref, "${")
+	if !ok {
+		return "", false
+	}
+	inner, ok = strings.CutSuffix(inner, "}")
+	if !ok {
+		return "", false
+	}
+	if id, ok := strings.CutPrefix(inner, "input:"); ok {
+		if !inputIDPattern.MatchString(id) {
+			return "", false
+		}
+		inner = strings.ToUpper(strings.ReplaceAll(id, "-", "_"))
+	} else {
+		inner = strings.TrimPrefix(inner, "env:")
+	}
+	return inner, envVarName.MatchString(inner)
+}
+
+// Redact replaces each finding's secret in content with its placeholder.
+// For .vscode/mcp.json, matching promptString inputs are added so VS Code
+// asks for the values when the server starts.
+func Redact(relPath string, content []byte, findings []Finding) []byte {
+	result := string(content)
+	var inputs []string
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if f.Path != filepath.ToSlash(relPath) || f.Secret == "" {
+			continue
+		}
+		ph := Placeholder(f)
+		if f.Key != "" {
+			quoted, _ := json.Marshal(f.Secret)
+			result = strings.ReplaceAll(result, string(quoted), `"`+ph+`"`)
+		} else {
+			result = strings.ReplaceAll(result, f.Secret, ph)
+		}
+		if mcpConfigName(f.Path) == ".vscode/mcp.json" {
+			id := InputID(envName(f))
+			if !seen[id] {
+				seen[id] = true
+				inputs = append(inputs, id)
+			}
+		}
+	}
+	if len(inputs) > 0 {
+		result = addVSCodeInputs(result, inputs)
+	}
+	return []byte(result)
+}
+
+// RedactFiles rewrites the files under dir that contain findings.
+func RedactFiles(dir string, findings []Finding) error {
+	byPath := make(map[string][]Finding)
+	var order []string
+	for _, f := range findings {
+		if _, ok := byPath[f.Path]; !ok {
+			order = append(order, f.Path)
+		}
+		byPath[f.Path] = append(byPath[f.Path], f)
+	}
+
+	for _, relPath := range order {
+		fullPath := filepath.Join(dir, filepath.FromSlash(relPath))
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", relPath, err)
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", relPath, err)
+		}
+		if err := os.WriteFile(fullPath, Redact(relPath, data, byPath[relPath]), info.Mode()); err != nil {
+			return fmt.Errorf("write %s: %w", relPath, err)
+		}
+	}
+	return nil
+}
+
+// envName returns the environment variable name used for a finding's placeholder.
+func envName(f Finding) string {
+	if f.Key != "" {
+		return f.Key
+	}
+	for _, r := range rules {
+		if r.id == f.Rule {
+			return r.envName
+		}
+	}
+	return "SECRET"
+}
+
+// InputID converts an environment variable name into a VS Code input id.
+func InputID(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// inputsArray matches the opening of an "inputs" array in a JSON document.
+var inputsArray = regexp.MustCompile(`"inputs"\s*:\s*\[`)
+
+// addVSCodeInputs adds promptString inputs for ids not yet declared.
+// The document is edited textually so the user's formatting is preserved.
+func addVSCodeInputs(content string, ids []string) string {
+	var entries []string
+	for _, id := range ids {
+		if strings.Contains(content, `"id": "`+id+`"`) || strings.Contains(content, `"id":"`+id+`"`) {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf(
+			`    {"type": "promptString", "id": %q, "description": %q, "password": true}`,
+			id, strings.ToUpper(strings.ReplaceAll(id, "-", "_"))))
+	}
+	if len(entries) == 0 {
+		return content
+	}
+	joined := strings.Join(entries, ",\n")
+
+	if loc := inputsArray.FindStringIndex(content); loc != nil {
+		rest := strings.TrimLeft(content[loc[1]:], " \t\r\n")
+		sep := ",\n"
+		if strings.HasPrefix(rest, "]") {
+			sep = "\n  "
+		}
+		return content[:loc[1]] + "\n" + joined + sep + content[loc[1]:]
+	}
+
+	open := strings.Index(content, "{")
+	if open < 0 {
+		return content
+	}
+	rest := strings.TrimLeft(content[open+1:], " \t\r\n")
+	sep := ","
+	if strings.HasPrefix(rest, "}") {
+		sep = ""
+	}
+	return content[:open+1] + "\n  \"inputs\": [\n" + joined + "\n  ]" + sep + content[open+1:]
+}
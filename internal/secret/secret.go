@@ -0,0 +1,334 @@
+// Package secret provides secret detection and redaction for dotgh templates.
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AllowMarker is an inline marker that suppresses findings on the same line.
+const AllowMarker = "dotgh:allow-secret"
+
+// Rule identifiers for detected secrets.
+const (
+	RuleMCPEnv      = "mcp-env"
+	RuleHighEntropy = "high-entropy"
+	RuleGeneric     = "generic-secret"
+)
+
+// minEntropyLength is the minimum token length considered by the entropy check.
+const minEntropyLength = 32
+
+// entropyThreshold is the Shannon entropy (bits per character) above which a
+// token is reported as a likely secret.
+const entropyThreshold = 4.3
+
+// Finding represents a single detected secret.
+type Finding struct {
+	Path   string // Relative path of the file (forward slashes)
+	Line   int    // 1-based line number
+	Rule   string // Rule identifier
+	Secret string // The detected secret value
+	Key    string // Environment variable name for MCP env findings
+}
+
+// Masked returns the secret with all but the first four characters hidden.
+func (f Finding) Masked() string {
+	if len(f.Secret) <= 4 {
+		return strings.Repeat("*", len(f.Secret))
+	}
+	return f.Secret[:4] + strings.Repeat("*", min(len(f.Secret)-4, 8))
+}
+
+// String returns a human-readable description of the finding.
+func (f Finding) String() string {
+	if f.Key != "" {
+		return fmt.Sprintf("%s:%d: %s %s=%s", f.Path, f.Line, f.Rule, f.Key, f.Masked())
+	}
+	return fmt.Sprintf("%s:%d: %s %s", f.Path, f.Line, f.Rule, f.Masked())
+}
+
+// rule describes a pattern-based secret detector.
+type rule struct {
+	id      string
+	envName string
+	re      *regexp.Regexp
+	group   int // capture group holding the secret, 0 for the whole match
+}
+
+// rules are checked in order; earlier rules win when matches overlap.
+var rules = []rule{
+	{id: "private-key", envName: "PRIVATE_KEY", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{id: "github-token", envName: "GITHUB_TOKEN", re: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`)},
+	{id: "github-pat", envName: "GITHUB_TOKEN", re: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,255}\b`)},
+	{id: "aws-access-key", envName: "AWS_ACCESS_KEY_ID", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{id: "anthropic-api-key", envName: "ANTHROPIC_API_KEY", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{id: "openai-api-key", envName: "OPENAI_API_KEY", re: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{id: "slack-token", envName: "SLACK_TOKEN", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9\-]{10,}`)},
+	{id: "google-api-key", envName: "GOOGLE_API_KEY", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{id: RuleGeneric, envName: "SECRET", group: 1, re: regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|password|passwd)["']?\s*[:=]\s*["']([^"'\s]{12,})["']`)},
+}
+
+// entropyToken matches candidate tokens for the high-entropy check.
+var entropyToken = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{32,}`)
+
+// placeholder matches values that already reference a variable or input.
+var placeholder = regexp.MustCompile(`\$\{[^}]+\}|^\$[A-Za-z_][A-Za-z0-9_]*$|^<[^>]+>$`)
+
+// Scanner detects secrets in file contents.
+type Scanner struct {
+	allowlist  []*regexp.Regexp
+	allowPaths []string
+}
+
+// NewScanner creates a scanner with the given allowlist.
+// allowlist entries are regular expressions matched against secret values.
+// allowPaths entries are glob patterns matched against relative file paths.
+func NewScanner(allowlist, allowPaths []string) (*Scanner, error) {
+	s := &Scanner{allowPaths: allowPaths}
+	for _, expr := range allowlist {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern %q: %w", expr, err)
+		}
+		s.allowlist = append(s.allowlist, re)
+	}
+	for _, pattern := range allowPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid allow path %q: %w", pattern, err)
+		}
+	}
+	return s, nil
+}
+
+// ScanFile reads the file at fullPath and scans it, reporting findings under
+// relPath. A missing file yields no findings.
+func (s *Scanner) ScanFile(fullPath, relPath string) ([]Finding, error) {
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", relPath, err)
+	}
+	return s.Scan(relPath, data), nil
+}
+
+// Scan returns the secrets found in content. relPath is used for reporting,
+// for the path allowlist, and to recognize MCP configuration files.
+func (s *Scanner) Scan(relPath string, content []byte) []Finding {
+	relPath = filepath.ToSlash(relPath)
+	if s.pathAllowed(relPath) || bytes.IndexByte(content, 0) >= 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	seen := make(map[string]bool)
+	var findings []Finding
+	add := func(f Finding) {
+		key := fmt.Sprintf("%d\x00%s", f.Line, f.Secret)
+		if seen[key] || s.valueAllowed(f.Secret) || placeholder.MatchString(f.Secret) {
+			return
+		}
+		if f.Line > 0 && f.Line <= len(lines) && strings.Contains(lines[f.Line-1], AllowMarker) {
+			return
+		}
+		seen[key] = true
+		findings = append(findings, f)
+	}
+
+	for _, f := range scanMCPEnv(relPath, content, lines) {
+		add(f)
+	}
+
+	for i, line := range lines {
+		var spans [][2]int
+		overlaps := func(start, end int) bool {
+			for _, sp := range spans {
+				if start < sp[1] && end > sp[0] {
+					return true
+				}
+			}
+			return false
+		}
+		for _, r := range rules {
+			for _, m := range r.re.FindAllStringSubmatchIndex(line, -1) {
+				start, end := m[2*r.group], m[2*r.group+1]
+				if start < 0 || overlaps(start, end) {
+					continue
+				}
+				value := line[start:end]
+				if r.id == RuleGeneric && shannonEntropy(value) < 3.0 {
+					continue
+				}
+				spans = append(spans, [2]int{start, end})
+				add(Finding{Path: relPath, Line: i + 1, Rule: r.id, Secret: value})
+			}
+		}
+		for _, m := range entropyToken.FindAllStringIndex(line, -1) {
+			if overlaps(m[0], m[1]) {
+				continue
+			}
+			value := line[m[0]:m[1]]
+			if len(value) >= minEntropyLength && shannonEntropy(value) >= entropyThreshold {
+				add(Finding{Path: relPath, Line: i + 1, Rule: RuleHighEntropy, Secret: value})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// pathAllowed reports whether relPath, or any trailing portion of it, matches
+// an allowed path pattern. This lets the same patterns apply to files inside
+// a template and to their copies in the sync directory.
+func (s *Scanner) pathAllowed(relPath string) bool {
+	for _, pattern := range s.allowPaths {
+		for p := relPath; ; {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+			i := strings.Index(p, "/")
+			if i < 0 {
+				break
+			}
+			p = p[i+1:]
+		}
+	}
+	return false
+}
+
+// valueAllowed reports whether value matches an allowlist expression.
+func (s *Scanner) valueAllowed(value string) bool {
+	for _, re := range s.allowlist {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// mcpConfigFiles lists the known MCP server configuration files.
+var mcpConfigFiles = []string{
+	".vscode/mcp.json",
+	".cursor/mcp.json",
+	".mcp.json",
+	".claude/settings.json",
+	".gemini/settings.json",
+}
+
+// mcpConfigName returns the MCP configuration file name that relPath refers to,
+// or "" if it is not one. Paths nested inside a template or the sync
+// directory (e.g. "templates/x/.vscode/mcp.json") are recognized as well.
+func mcpConfigName(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	for _, name := range mcpConfigFiles {
+		if relPath == name || strings.HasSuffix(relPath, "/"+name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// IsMCPConfig reports whether relPath is a known MCP server configuration file.
+func IsMCPConfig(relPath string) bool {
+	return mcpConfigName(relPath) != ""
+}
+
+// scanMCPEnv reports literal values in the env blocks of MCP server definitions.
+func scanMCPEnv(relPath string, content []byte, lines []string) []Finding {
+	if !IsMCPConfig(relPath) {
+		return nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, section := range []string{"servers", "mcpServers"} {
+		raw, ok := doc[section]
+		if !ok {
+			continue
+		}
+		var servers map[string]struct {
+			Env map[string]string `json:"env"`
+		}
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			continue
+		}
+		names := make([]string, 0, len(servers))
+		for name := range servers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			keys := make([]string, 0, len(servers[name].Env))
+			for key := range servers[name].Env {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				value := servers[name].Env[key]
+				if value == "" {
+					continue
+				}
+				findings = append(findings, Finding{
+					Path:   relPath,
+					Line:   findLine(lines, key, value),
+					Rule:   RuleMCPEnv,
+					Secret: value,
+					Key:    key,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// findLine returns the 1-based line on which a JSON key/value pair appears.
+func findLine(lines []string, key, value string) int {
+	quotedKey, _ := json.Marshal(key)
+	quotedValue, _ := json.Marshal(value)
+	for i, line := range lines {
+		if strings.Contains(line, string(quotedKey)) && strings.Contains(line, string(quotedValue)) {
+			return i + 1
+		}
+	}
+	for i, line := range lines {
+		if strings.Contains(line, string(quotedValue)) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,140 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrUnresolvedConflicts indicates the sync repository has a merge in progress
+// with conflicts that must be resolved before syncing again.
+var ErrUnresolvedConflicts = errors.New("sync repository has unresolved conflicts")
+
+// ErrConflictMarkers indicates a file still contains conflict markers.
+var ErrConflictMarkers = errors.New("file still contains conflict markers")
+
+// Resolution selects which side of a conflict to keep.
+type Resolution string
+
+const (
+	// ResolveLocal keeps the version from this machine.
+	ResolveLocal Resolution = "local"
+	// ResolveRemote keeps the version from the remote repository.
+	ResolveRemote Resolution = "remote"
+)
+
+// Conflict represents a file in the sync repository with merge conflicts.
+type Conflict struct {
+	Path          string // Path relative to the sync directory
+	LocalDeleted  bool   // The file was deleted on this machine
+	RemoteDeleted bool   // The file was deleted in the remote repository
+}
+
+// IsMerging returns true if a merge is in progress in the sync repository.
+func (m *Manager) IsMerging() bool {
+	return m.git.IsMerging()
+}
+
+// Conflicts returns the files with unresolved merge conflicts.
+func (m *Manager) Conflicts(ctx context.Context) ([]Conflict, error) {
+	files, err := m.git.ConflictedFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list conflicts: %w", err)
+	}
+	conflicts := make([]Conflict, 0, len(files))
+	for _, f := range files {
+		conflicts = append(conflicts, Conflict{
+			Path:          f.Path,
+			LocalDeleted:  !f.Ours,
+			RemoteDeleted: !f.Theirs,
+		})
+	}
+	return conflicts, nil
+}
+
+// ConflictPath returns the full path of a conflicted file in the sync directory.
+func (m *Manager) ConflictPath(c Conflict) string {
+	return filepath.Join(m.SyncDirPath(), filepath.FromSlash(c.Path))
+}
+
+// ResolveConflict resolves c by keeping the local or remote version.
+// If the chosen side deleted the file, the file is deleted.
+func (m *Manager) ResolveConflict(ctx context.Context, c Conflict, res Resolution) error {
+	stage, deleted := 2, c.LocalDeleted
+	if res == ResolveRemote {
+		stage, deleted = 3, c.RemoteDeleted
+	}
+
+	if deleted {
+		if err := m.git.Remove(ctx, c.Path); err != nil {
+			return fmt.Errorf("resolve %s: %w", c.Path, err)
+		}
+		return nil
+	}
+
+	data, err := m.git.ShowStage(ctx, stage, c.Path)
+	if err != nil {
+		return fmt.Errorf("read %s version of %s: %w", res, c.Path, err)
+	}
+	if err := writeFile(m.ConflictPath(c), data); err != nil {
+		return fmt.Errorf("resolve %s: %w", c.Path, err)
+	}
+	if err := m.git.Add(ctx, c.Path); err != nil {
+		return fmt.Errorf("resolve %s: %w", c.Path, err)
+	}
+	return nil
+}
+
+// MarkResolved marks a manually edited conflict as resolved.
+// Returns ErrConflictMarkers if the file still contains conflict markers.
+// A file that was removed while editing is resolved as deleted.
+func (m *Manager) MarkResolved(ctx context.Context, c Conflict) error {
+	data, err := os.ReadFile(m.ConflictPath(c))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("read %s: %w", c.Path, err)
+		}
+		if err := m.git.Remove(ctx, c.Path); err != nil {
+			return fmt.Errorf("resolve %s: %w", c.Path, err)
+		}
+		return nil
+	}
+
+	if HasConflictMarkers(data) {
+		return fmt.Errorf("%w: %s", ErrConflictMarkers, c.Path)
+	}
+	if err := m.git.Add(ctx, c.Path); err != nil {
+		return fmt.Errorf("resolve %s: %w", c.Path, err)
+	}
+	return nil
+}
+
+// CompleteMerge commits the merge once all conflicts are resolved.
+func (m *Manager) CompleteMerge(ctx context.Context, message string) error {
+	conflicts, err := m.Conflicts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %d file(s) remaining", ErrUnresolvedConflicts, len(conflicts))
+	}
+	if err := m.git.Commit(ctx, message); err != nil {
+		return fmt.Errorf("commit merge: %w", err)
+	}
+	return nil
+}
+
+// HasConflictMarkers returns true if data contains Git conflict markers.
+func HasConflictMarkers(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("<<<<<<< ")) ||
+			bytes.HasPrefix(line, []byte(">>>>>>> ")) ||
+			bytes.Equal(bytes.TrimRight(line, "\r"), []byte("=======")) {
+			return true
+		}
+	}
+	return false
+}
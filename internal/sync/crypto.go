@@ -0,0 +1,190 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KeyFileName is the name of the file holding the sync encryption key.
+	// It lives in the config directory, outside the sync repository.
+	KeyFileName = "sync.key"
+
+	// KeyEnvVar overrides the key file when set.
+	KeyEnvVar = "DOTGH_SYNC_KEY"
+
+	encryptedHeader = "-----BEGIN DOTGH ENCRYPTED FILE-----"
+	encryptedFooter = "-----END DOTGH ENCRYPTED FILE-----"
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// scrypt cost parameters (see golang.org/x/crypto/scrypt).
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// placeholderPrefix starts the content written in place of encrypted files
+// that cannot be decrypted on this machine.
+var placeholderPrefix = []byte("dotgh: encrypted file")
+
+// ErrDecrypt indicates that an encrypted file could not be decrypted,
+// usually because the key is wrong.
+var ErrDecrypt = errors.New("decryption failed")
+
+// Encrypt encrypts plaintext with a key derived from passphrase using
+// scrypt and AES-256-GCM, and returns it in an armored text format.
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	payload := make([]byte, 0, saltSize+len(nonce)+len(plaintext)+gcm.Overhead())
+	payload = append(payload, salt...)
+	payload = append(payload, nonce...)
+	payload = gcm.Seal(payload, nonce, plaintext, nil)
+
+	var buf bytes.Buffer
+	buf.WriteString(encryptedHeader + "\n")
+	encoded := base64.StdEncoding.EncodeToString(payload)
+	for len(encoded) > 64 {
+		buf.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	buf.WriteString(encoded + "\n")
+	buf.WriteString(encryptedFooter + "\n")
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts data produced by Encrypt.
+// Returns ErrDecrypt if the data is malformed or the passphrase is wrong.
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, fmt.Errorf("%w: not an encrypted file", ErrDecrypt)
+	}
+
+	body := strings.TrimSpace(string(data))
+	body = strings.TrimPrefix(body, encryptedHeader)
+	body = strings.TrimSuffix(body, encryptedFooter)
+	body = strings.Join(strings.Fields(body), "")
+
+	payload, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	if len(payload) < saltSize {
+		return nil, fmt.Errorf("%w: payload too short", ErrDecrypt)
+	}
+
+	gcm, err := newGCM(passphrase, payload[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	rest := payload[saltSize:]
+	if len(rest) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: payload too short", ErrDecrypt)
+	}
+
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong key or corrupted file", ErrDecrypt)
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted returns true if data is in the encrypted file format.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedHeader))
+}
+
+// IsPlaceholder returns true if data is a placeholder for an encrypted file.
+func IsPlaceholder(data []byte) bool {
+	return bytes.HasPrefix(data, placeholderPrefix)
+}
+
+// placeholderContent returns the content written for an encrypted file
+// that cannot be decrypted on this machine.
+func placeholderContent(relPath string) []byte {
+	return []byte(fmt.Sprintf("%s (%s)\n"+
+		"This file is encrypted in the sync repository and no key is available on this machine.\n"+
+		"Copy %s from another machine or set %s, then run 'dotgh sync pull' again.\n",
+		placeholderPrefix, relPath, KeyFileName, KeyEnvVar))
+}
+
+// newGCM derives a key from passphrase and salt and returns an AES-GCM cipher.
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+// GenerateKey returns a new random passphrase suitable for sync encryption.
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate key: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// LoadKey returns the sync encryption key from the DOTGH_SYNC_KEY environment
+// variable or the key file in configDir. It returns "" if neither is set.
+func LoadKey(configDir string) (string, error) {
+	if key := os.Getenv(KeyEnvVar); key != "" {
+		return key, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, KeyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("read key file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// WriteKey writes key to the key file in configDir with owner-only permissions.
+func WriteKey(configDir, key string) error {
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, KeyFileName), []byte(key+"\n"), 0600); err != nil {
+		return fmt.Errorf("write key file: %w", err)
+	}
+	return nil
+}
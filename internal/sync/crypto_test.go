@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte(`{"servers": {"x": {"env": {"TOKEN": "secret"}}}}`)
+
+	t.Run("round trips with the same key", func(t *testing.T) {
+		ciphertext, err := Encrypt(plaintext, "passphrase")
+		require.NoError(t, err)
+		assert.True(t, IsEncrypted(ciphertext))
+		assert.NotContains(t, string(ciphertext), "secret")
+
+		decrypted, err := Decrypt(ciphertext, "passphrase")
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("fails with the wrong key", func(t *testing.T) {
+		ciphertext, err := Encrypt(plaintext, "passphrase")
+		require.NoError(t, err)
+
+		_, err = Decrypt(ciphertext, "other")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("fails on plaintext input", func(t *testing.T) {
+		_, err := Decrypt(plaintext, "passphrase")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+}
+
+func TestLoadKey(t *testing.T) {
+	t.Run("returns empty when no key is configured", func(t *testing.T) {
+		t.Setenv(KeyEnvVar, "")
+		key, err := LoadKey(t.TempDir())
+		require.NoError(t, err)
+		assert.Empty(t, key)
+	})
+
+	t.Run("reads the key file", func(t *testing.T) {
+		t.Setenv(KeyEnvVar, "")
+		dir := t.TempDir()
+		require.NoError(t, WriteKey(dir, "from-file"))
+
+		info, err := os.Stat(filepath.Join(dir, KeyFileName))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		key, err := LoadKey(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "from-file", key)
+	})
+
+	t.Run("environment variable takes precedence", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, WriteKey(dir, "from-file"))
+		t.Setenv(KeyEnvVar, "from-env")
+
+		key, err := LoadKey(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", key)
+	})
+}
+
+func TestGenerateKey(t *testing.T) {
+	k1, err := GenerateKey()
+	require.NoError(t, err)
+	k2, err := GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, k1, k2)
+	assert.GreaterOrEqual(t, len(k1), 40)
+}
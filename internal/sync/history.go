@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/git"
+)
+
+// TargetConfig selects the config file in history operations.
+// Any other non-empty target is a template name; an empty target selects
+// the config file and all templates.
+const TargetConfig = "config"
+
+// targetPaths returns the sync repository paths covered by target.
+func targetPaths(target string) []string {
+	switch target {
+	case "":
+		return []string{configFileName, templatesDirName}
+	case TargetConfig:
+		return []string{configFileName}
+	default:
+		return []string{templatesDirName + "/" + target}
+	}
+}
+
+// targetScope returns the mirror scope covered by target.
+func targetScope(target string) scope {
+	switch target {
+	case "":
+		return everything
+	case TargetConfig:
+		return scope{config: true}
+	default:
+		return scope{templates: true, template: target}
+	}
+}
+
+// Log returns the sync commits that changed target, newest first.
+// limit <= 0 means no limit.
+func (m *Manager) Log(ctx context.Context, target string, limit int) ([]git.LogEntry, error) {
+	entries, err := m.git.Log(ctx, "", limit, targetPaths(target)...)
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Snapshot is a read-only copy of the synced config and templates at a past
+// revision, extracted to a temporary directory. Call Close when done.
+type Snapshot struct {
+	// Commit is the revision the snapshot was taken from.
+	Commit git.LogEntry
+
+	dir  string
+	view *Manager
+}
+
+// Snapshot extracts the config file and templates at revision rev.
+func (m *Manager) Snapshot(ctx context.Context, rev string) (*Snapshot, error) {
+	hash, err := m.git.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.git.Log(ctx, hash, 1)
+	if err != nil {
+		return nil, fmt.Errorf("read commit %s: %w", rev, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("read commit %s: no such commit", rev)
+	}
+
+	files, err := m.git.ListTree(ctx, hash, configFileName, templatesDirName)
+	if err != nil {
+		return nil, fmt.Errorf("list files at %s: %w", rev, err)
+	}
+
+	dir, err := os.MkdirTemp("", "dotgh-snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot directory: %w", err)
+	}
+	s := &Snapshot{Commit: entries[0], dir: dir}
+
+	for _, f := range files {
+		data, err := m.git.Show(ctx, hash, f)
+		if err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("read %s at %s: %w", f, rev, err)
+		}
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(f)), data); err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("extract %s: %w", f, err)
+		}
+	}
+
+	// A view of the manager that reads from the snapshot instead of the sync directory
+	view := *m
+	view.syncRoot = dir
+	view.locked = nil
+	s.view = &view
+	return s, nil
+}
+
+// Close removes the extracted snapshot.
+func (s *Snapshot) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// Has returns true if target exists in the snapshot.
+func (s *Snapshot) Has(target string) bool {
+	for _, p := range targetPaths(target) {
+		if _, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(p))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanRestore computes the changes that ApplyRestore makes to the local
+// config file and templates so that target matches the snapshot.
+func (s *Snapshot) PlanRestore(target string) (*diff.DiffResult, error) {
+	return s.view.plan(fromSync, targetScope(target))
+}
+
+// ApplyRestore applies a plan computed by PlanRestore.
+func (s *Snapshot) ApplyRestore(plan *diff.DiffResult) error {
+	return s.view.apply(fromSync, plan)
+}
+
+// LockedFiles returns the encrypted files in the last restore plan that
+// cannot be decrypted because no key is available.
+func (s *Snapshot) LockedFiles() []string {
+	return s.view.locked
+}
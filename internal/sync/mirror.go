@@ -0,0 +1,497 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openjny/dotgh/internal/config"
+	"github.com/openjny/dotgh/internal/diff"
+)
+
+const (
+	// configFileName is the config file mirrored at the sync repository root.
+	configFileName = "config.yaml"
+	// templatesDirName is the sync repository directory holding templates.
+	templatesDirName = "templates"
+)
+
+// direction is the direction of a mirror operation.
+type direction int
+
+const (
+	toSync   direction = iota // local config directory -> sync directory
+	fromSync                  // sync directory -> local config directory
+)
+
+// scope selects the files included in a mirror operation.
+type scope struct {
+	config    bool   // config.yaml
+	templates bool   // templates directory
+	template  string // restrict templates to a single template
+}
+
+// everything mirrors the config file and all templates.
+var everything = scope{config: true, templates: true}
+
+// PlanPush computes the changes that ApplyPush makes to the sync directory so
+// that it mirrors the local config file and templates directory. Paths in the
+// result are relative to the sync directory (e.g. "templates/work/AGENTS.md").
+func (m *Manager) PlanPush() (*diff.DiffResult, error) {
+	return m.plan(toSync, everything)
+}
+
+// PlanPushConfig computes the changes of PlanPush to the config file only.
+func (m *Manager) PlanPushConfig() (*diff.DiffResult, error) {
+	return m.plan(toSync, scope{config: true})
+}
+
+// PlanPushTemplate computes the changes of PlanPush to template name only.
+// A template that no longer exists locally is deleted from the sync
+// directory. A template that this manager does not sync has no changes.
+func (m *Manager) PlanPushTemplate(name string) (*diff.DiffResult, error) {
+	if !m.IsSyncedTemplate(name) {
+		return &diff.DiffResult{}, nil
+	}
+	return m.plan(toSync, scope{templates: true, template: name})
+}
+
+// PlanPull computes the changes that ApplyPull makes to the local config file
+// and templates directory so that they mirror the sync directory.
+// Encrypted files that cannot be decrypted are reported by LockedFiles.
+func (m *Manager) PlanPull() (*diff.DiffResult, error) {
+	return m.plan(fromSync, everything)
+}
+
+// templateOverride stands in for the local copy of a template.
+type templateOverride struct {
+	name string
+	dir  string
+}
+
+// PlanPushAs computes the changes that PlanPushTemplate would find if the
+// local template name held the files in dir, so that the sync of changes not
+// yet made can be planned. A dir that does not exist stands for a deleted
+// template.
+func (m *Manager) PlanPushAs(name, dir string) (*diff.DiffResult, error) {
+	view := *m
+	view.override = &templateOverride{name: name, dir: dir}
+	return view.PlanPushTemplate(name)
+}
+
+// ApplyPush applies a plan computed by PlanPush.
+func (m *Manager) ApplyPush(plan *diff.DiffResult) error {
+	return m.apply(toSync, plan)
+}
+
+// ApplyPull applies a plan computed by PlanPull.
+func (m *Manager) ApplyPull(plan *diff.DiffResult) error {
+	return m.apply(fromSync, plan)
+}
+
+// CopyConfigToSync copies the config file to the sync directory.
+func (m *Manager) CopyConfigToSync() error {
+	return m.mirror(toSync, scope{config: true})
+}
+
+// CopyTemplatesToSync mirrors the templates directory to the sync directory,
+// deleting templates that no longer exist locally.
+func (m *Manager) CopyTemplatesToSync() error {
+	return m.mirror(toSync, scope{templates: true})
+}
+
+// CopyConfigFromSync copies the config file from the sync directory.
+func (m *Manager) CopyConfigFromSync() error {
+	return m.mirror(fromSync, scope{config: true})
+}
+
+// CopyTemplatesFromSync mirrors the templates from the sync directory,
+// deleting local templates that no longer exist in the sync directory.
+func (m *Manager) CopyTemplatesFromSync() error {
+	return m.mirror(fromSync, scope{templates: true})
+}
+
+// mirror plans and applies a copy in one step.
+func (m *Manager) mirror(dir direction, sc scope) error {
+	plan, err := m.plan(dir, sc)
+	if err != nil {
+		return err
+	}
+	return m.apply(dir, plan)
+}
+
+// LocalPath returns the live path of a file given its path relative to the
+// sync directory.
+func (m *Manager) LocalPath(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	if rest, ok := strings.CutPrefix(relPath, templatesDirName+"/"); ok {
+		if m.override != nil {
+			if rest == m.override.name {
+				return m.override.dir
+			}
+			if inner, ok := strings.CutPrefix(rest, m.override.name+"/"); ok {
+				return filepath.Join(m.override.dir, filepath.FromSlash(inner))
+			}
+		}
+		return filepath.Join(m.templatesDir, filepath.FromSlash(rest))
+	}
+	if relPath == configFileName {
+		return m.configFile
+	}
+	return filepath.Join(m.configDir, filepath.FromSlash(relPath))
+}
+
+// syncPath returns the path of a file in the sync directory, or in the
+// snapshot being restored.
+func (m *Manager) syncPath(relPath string) string {
+	root := m.SyncDirPath()
+	if m.syncRoot != "" {
+		root = m.syncRoot
+	}
+	return filepath.Join(root, filepath.FromSlash(relPath))
+}
+
+// plan computes the mirror changes in the given direction.
+// A missing source (config file or templates directory) produces no changes,
+// so an unconfigured machine never wipes the other side.
+func (m *Manager) plan(dir direction, sc scope) (*diff.DiffResult, error) {
+	if dir == fromSync {
+		m.locked = nil
+	}
+	if m.profile != "" {
+		// Named profiles sync templates only
+		sc.config = false
+	}
+
+	var srcFiles, dstFiles []string
+
+	if sc.config {
+		srcPath, dstPath := m.LocalPath(configFileName), m.syncPath(configFileName)
+		if dir == fromSync {
+			srcPath, dstPath = dstPath, srcPath
+		}
+		if fileExists(srcPath) {
+			srcFiles = append(srcFiles, configFileName)
+			if fileExists(dstPath) {
+				dstFiles = append(dstFiles, configFileName)
+			}
+		}
+	}
+
+	if sc.templates {
+		prefix, srcDir := templatesDirName, m.templatesDir
+		if sc.template != "" {
+			prefix = templatesDirName + "/" + sc.template
+			srcDir = m.LocalPath(prefix)
+		}
+		dstDir := m.syncPath(prefix)
+		if dir == fromSync {
+			srcDir, dstDir = dstDir, srcDir
+		}
+		// A single template missing locally was deleted, unless the whole
+		// templates directory is missing
+		deleted := dir == toSync && sc.template != "" && isDir(m.templatesDir)
+		if isDir(srcDir) || deleted {
+			src, err := diff.ListFiles(srcDir)
+			if err != nil {
+				return nil, fmt.Errorf("list source templates: %w", err)
+			}
+			dst, err := diff.ListFiles(dstDir)
+			if err != nil {
+				return nil, fmt.Errorf("list destination templates: %w", err)
+			}
+			if sc.template == "" {
+				src, dst = m.syncedTemplates(src), m.syncedTemplates(dst)
+			}
+			srcFiles = append(srcFiles, prefixPaths(prefix, src)...)
+			dstFiles = append(dstFiles, prefixPaths(prefix, dst)...)
+		}
+	}
+
+	result, err := diff.CompareFiles(srcFiles, dstFiles, false, func(relPath string) (bool, error) {
+		return m.sameContent(dir, relPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Placeholders for locked files are local stand-ins, never content to push
+	if dir == toSync {
+		var added []diff.FileChange
+		for _, change := range result.Added {
+			if data, err := os.ReadFile(m.LocalPath(change.Path)); err == nil && IsPlaceholder(data) {
+				continue
+			}
+			added = append(added, change)
+		}
+		result.Added = added
+	}
+
+	if dir == fromSync && m.key == "" {
+		for _, relPath := range srcFiles {
+			if data, err := os.ReadFile(m.syncPath(relPath)); err == nil && IsEncrypted(data) {
+				m.locked = append(m.locked, relPath)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// sameContent reports whether the local and synced copies of relPath hold the
+// same plaintext.
+func (m *Manager) sameContent(dir direction, relPath string) (bool, error) {
+	local, err := os.ReadFile(m.LocalPath(relPath))
+	if err != nil {
+		return false, err
+	}
+	synced, err := os.ReadFile(m.syncPath(relPath))
+	if err != nil {
+		return false, err
+	}
+
+	if IsPlaceholder(local) && dir == toSync {
+		return true, nil
+	}
+	if m.isRedactedCopy(dir, relPath, local, synced) {
+		return true, nil
+	}
+	if IsEncrypted(synced) {
+		if m.key == "" {
+			// Cannot compare; keep whatever exists locally
+			return true, nil
+		}
+		plaintext, err := Decrypt(synced, m.key)
+		if err != nil {
+			return false, fmt.Errorf("decrypt %s: %w", relPath, err)
+		}
+		synced = plaintext
+	}
+
+	// Compare what the copy would write with what is already there
+	if dir == toSync {
+		local, err = m.outgoing(relPath, local)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(local, synced), nil
+	}
+	synced, err = m.incoming(relPath, synced)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(local, synced), nil
+}
+
+// outgoing returns the content written to the sync directory for a local
+// file: the config file without its local-only keys, or data as is.
+func (m *Manager) outgoing(relPath string, data []byte) ([]byte, error) {
+	if relPath != configFileName || len(m.localKeys) == 0 {
+		return data, nil
+	}
+	stripped, err := config.StripKeys(data, m.localKeys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", relPath, err)
+	}
+	return stripped, nil
+}
+
+// incoming returns the content written locally for a synced file: the
+// synced config file with the local values of local-only keys, or data as is.
+func (m *Manager) incoming(relPath string, data []byte) ([]byte, error) {
+	if relPath != configFileName || len(m.localKeys) == 0 {
+		return data, nil
+	}
+	local, err := os.ReadFile(m.LocalPath(relPath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read %s: %w", relPath, err)
+	}
+	merged, err := config.MergeKeys(data, local, m.localKeys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", relPath, err)
+	}
+	return merged, nil
+}
+
+// apply applies a plan in the given direction.
+func (m *Manager) apply(dir direction, plan *diff.DiffResult) error {
+	for _, change := range append(append([]diff.FileChange{}, plan.Added...), plan.Modified...) {
+		var err error
+		if dir == toSync {
+			err = m.copyToSync(m.LocalPath(change.Path), m.syncPath(change.Path), change.Path)
+		} else {
+			err = m.copyFromSync(m.syncPath(change.Path), m.LocalPath(change.Path), change.Path)
+		}
+		if err != nil {
+			return fmt.Errorf("copy %s: %w", change.Path, err)
+		}
+	}
+
+	for _, change := range plan.Deleted {
+		target, root := m.syncPath(change.Path), m.syncPath(templatesDirName)
+		if dir == fromSync {
+			target, root = m.LocalPath(change.Path), m.templatesDir
+		}
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete %s: %w", change.Path, err)
+		}
+		removeEmptyParents(filepath.Dir(target), root)
+	}
+
+	return nil
+}
+
+// copyToSync copies a local file into the sync directory, encrypting it if
+// it matches an encryption pattern. Placeholders are never copied, and an
+// encrypted file whose plaintext is unchanged is left as is so that
+// re-encryption does not produce spurious commits.
+func (m *Manager) copyToSync(src, dst, relPath string) error {
+	if !m.IsEncryptedPath(relPath) && relPath != configFileName {
+		return copyFile(src, dst)
+	}
+
+	plaintext, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("read source: %w", err)
+	}
+	if IsPlaceholder(plaintext) {
+		return nil
+	}
+	plaintext, err = m.outgoing(relPath, plaintext)
+	if err != nil {
+		return err
+	}
+	if !m.IsEncryptedPath(relPath) {
+		return writeFile(dst, plaintext)
+	}
+	if m.key == "" {
+		return fmt.Errorf("%s must be encrypted but no sync key is available (run 'dotgh sync keygen' or set %s)", relPath, KeyEnvVar)
+	}
+
+	if existing, err := os.ReadFile(dst); err == nil && IsEncrypted(existing) {
+		if current, err := Decrypt(existing, m.key); err == nil && bytes.Equal(current, plaintext) {
+			return nil
+		}
+	}
+
+	ciphertext, err := Encrypt(plaintext, m.key)
+	if err != nil {
+		return fmt.Errorf("encrypt %s: %w", relPath, err)
+	}
+	return writeFile(dst, ciphertext)
+}
+
+// copyFromSync copies a file from the sync directory to the local config
+// directory, decrypting it if needed. Without a key, an encrypted file is
+// replaced by a placeholder only if no local copy exists.
+func (m *Manager) copyFromSync(src, dst, relPath string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("read source: %w", err)
+	}
+	if IsEncrypted(data) {
+		if m.key == "" {
+			if fileExists(dst) {
+				return nil
+			}
+			return writeFile(dst, placeholderContent(relPath))
+		}
+		if data, err = Decrypt(data, m.key); err != nil {
+			return fmt.Errorf("decrypt %s: %w", relPath, err)
+		}
+	}
+
+	data, err = m.incoming(relPath, data)
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, data)
+}
+
+// syncedTemplates keeps the paths, relative to the templates directory, of
+// synced templates. Hidden top-level directories (such as the local history
+// store) are not templates and are always dropped.
+func (m *Manager) syncedTemplates(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		name, _, _ := strings.Cut(p, "/")
+		if !strings.HasPrefix(name, ".") && m.IsSyncedTemplate(name) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// prefixPaths joins prefix to each path with a forward slash.
+func prefixPaths(prefix string, paths []string) []string {
+	result := make([]string, len(paths))
+	for i, p := range paths {
+		result[i] = prefix + "/" + p
+	}
+	return result
+}
+
+// isDir returns true if path exists and is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// fileExists returns true if path exists and is a regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// removeEmptyParents removes dir and its parents while they are empty,
+// stopping at (and never removing) root.
+func removeEmptyParents(dir, root string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// copyFile copies a single file.
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	// Create destination directory if needed
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("create destination directory: %w", err)
+	}
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create destination: %w", err)
+	}
+	defer func() { _ = dstFile.Close() }()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return fmt.Errorf("copy content: %w", err)
+	}
+
+	return nil
+}
+
+// writeFile writes data to dst, creating parent directories as needed.
+func writeFile(dst string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("create destination directory: %w", err)
+	}
+
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("write destination: %w", err)
+	}
+
+	return nil
+}
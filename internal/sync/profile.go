@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/openjny/dotgh/internal/git"
+)
+
+// ProfilesDirName is the directory, inside the config directory, holding the
+// sync directories of named profiles.
+const ProfilesDirName = ".sync-profiles"
+
+// profileNamePattern matches valid profile names.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateProfileName returns an error if name cannot be used as a sync
+// profile name. Names are used as directory names and template namespaces,
+// so they are limited to letters, digits, '-' and '_'.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// Profiles returns the names of the initialized sync profiles in configDir,
+// sorted by name. The default profile is not included.
+func Profiles(configDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(configDir, ProfilesDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read sync profiles: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() || ValidateProfileName(entry.Name()) != nil {
+			continue
+		}
+		// Skip leftovers of an init that failed before the clone
+		if _, err := os.Stat(filepath.Join(configDir, ProfilesDirName, entry.Name(), ".git")); err != nil {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// NewProfileManager creates a sync manager for the named profile. A profile
+// has its own sync directory, and therefore its own repository and branch,
+// and syncs only its own templates: those in the "<profile>" directory of
+// the templates directory, listed as "<profile>/<template>". The config file
+// is synced by the default profile only. An empty profile selects the
+// default profile.
+func NewProfileManager(configDir, profile string) *Manager {
+	m := NewManager(configDir)
+	if profile == "" {
+		return m
+	}
+	m.profile = profile
+	m.profiles = nil
+	m.syncDir = filepath.Join(configDir, ProfilesDirName, profile)
+	m.git = git.New(m.syncDir)
+	m.templatesDir = filepath.Join(m.templatesDir, profile)
+	return m
+}
+
+// Profile returns the name of the sync profile, or "" for the default profile.
+func (m *Manager) Profile() string {
+	return m.profile
+}
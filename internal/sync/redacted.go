@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// redactedFile records the files whose secrets were replaced by placeholders
+// in the sync directory. It is kept in the .git directory so it is never
+// committed.
+const redactedFile = "dotgh-redacted.json"
+
+// redaction identifies a local file and its redacted sync copy by the
+// SHA-256 of their content.
+type redaction struct {
+	Local  string `json:"local"`
+	Synced string `json:"synced"`
+}
+
+// RecordRedacted records that the sync copies of paths, relative to the sync
+// directory, had their secrets replaced by placeholders. As long as a local
+// file holds the content it had then, it counts as in sync with its copy:
+// push does not report it again, and pull does not overwrite the secrets
+// with the placeholders.
+func (m *Manager) RecordRedacted(paths []string) error {
+	records := m.redactions()
+	for _, relPath := range paths {
+		local, err := os.ReadFile(m.LocalPath(relPath))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", relPath, err)
+		}
+		synced, err := os.ReadFile(m.syncPath(relPath))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", relPath, err)
+		}
+		records[filepath.ToSlash(relPath)] = redaction{Local: contentHash(local), Synced: contentHash(synced)}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.redactedPath(), data, 0600); err != nil {
+		return fmt.Errorf("record redacted files: %w", err)
+	}
+	return nil
+}
+
+// isRedactedCopy reports whether local is the content of relPath whose
+// secrets were redacted, and synced (for a push) its redacted copy.
+func (m *Manager) isRedactedCopy(dir direction, relPath string, local, synced []byte) bool {
+	r, ok := m.redactions()[relPath]
+	if !ok || r.Local != contentHash(local) {
+		return false
+	}
+	// Pull keeps the local file even if the copy was changed elsewhere, as
+	// the copy has placeholders instead of the secrets; push replaces it
+	return dir == fromSync || r.Synced == contentHash(synced)
+}
+
+// redactions returns the recorded redactions. A missing or unreadable
+// record means there are none.
+func (m *Manager) redactions() map[string]redaction {
+	records := make(map[string]redaction)
+	if data, err := os.ReadFile(m.redactedPath()); err == nil {
+		_ = json.Unmarshal(data, &records)
+	}
+	return records
+}
+
+// redactedPath returns the path of the redaction record.
+func (m *Manager) redactedPath() string {
+	return filepath.Join(m.SyncDirPath(), ".git", redactedFile)
+}
+
+// contentHash returns the hex SHA-256 of data.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -2,18 +2,30 @@
 package sync
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/openjny/dotgh/internal/diff"
 	"github.com/openjny/dotgh/internal/git"
 )
 
 const (
 	// SyncDirName is the name of the sync directory.
 	SyncDirName = ".sync"
+
+	// DefaultTimeout bounds operations that talk to the remote repository.
+	DefaultTimeout = 2 * time.Minute
+
+	// lastPullFile records when the sync repository was last updated from
+	// the remote. It is kept in the .git directory so it is never committed.
+	lastPullFile = "dotgh-last-pull"
 )
 
 // SyncState represents the current sync state.
@@ -35,26 +47,204 @@ type SyncStatus struct {
 	Branch     string
 	HasChanges bool
 	Changes    []string
+
+	// Upstream is the remote branch being tracked (e.g. "origin/main").
+	// It is empty if the branch has not been pushed yet.
+	Upstream string
+	// Ahead and Behind count the commits that differ from Upstream,
+	// as of the last fetch.
+	Ahead  int
+	Behind int
 }
 
 // Manager handles sync operations.
 type Manager struct {
 	configDir string
-	git       *git.Client
+	syncDir   string
+	git       git.Client
+	// backend is the git implementation of git.
+	backend string
+
+	// profile is the name of the sync profile; empty for the default profile.
+	profile string
+	// profiles lists the named profiles, whose templates the default profile
+	// leaves alone.
+	profiles []string
+
+	// encrypt lists glob patterns for files stored encrypted in the sync directory.
+	encrypt []string
+	// key is the passphrase used for encryption; empty if unavailable.
+	key string
+	// locked collects encrypted files that could not be decrypted during a pull.
+	locked []string
+	// configFile is the live config file mirrored to "config.yaml".
+	configFile string
+	// templatesDir is the live templates directory mirrored to "templates/".
+	templatesDir string
+	// syncRoot replaces the sync directory as the source of a restore.
+	syncRoot string
+	// timeout bounds operations that talk to the remote; 0 disables it.
+	timeout time.Duration
+	// include and exclude select the synced templates by name.
+	include []string
+	exclude []string
+	// localKeys lists config keys that are never synced.
+	localKeys []string
+	// override replaces the local copy of a template when planning a push.
+	override *templateOverride
 }
 
 // NewManager creates a new sync manager.
 func NewManager(configDir string) *Manager {
 	syncDir := filepath.Join(configDir, SyncDirName)
+	profiles, _ := Profiles(configDir)
 	return &Manager{
-		configDir: configDir,
-		git:       git.New(syncDir),
+		configDir:    configDir,
+		syncDir:      syncDir,
+		git:          git.New(syncDir),
+		profiles:     profiles,
+		configFile:   filepath.Join(configDir, configFileName),
+		templatesDir: filepath.Join(configDir, "templates"),
+		timeout:      DefaultTimeout,
+	}
+}
+
+// SetGitBackend selects the git implementation used for the sync repository
+// (git.BackendExec or git.BackendGoGit). An empty backend selects the default.
+func (m *Manager) SetGitBackend(backend string) error {
+	client, err := git.NewClient(backend, m.SyncDirPath())
+	if err != nil {
+		return err
+	}
+	m.git = client
+	m.backend = backend
+	return nil
+}
+
+// GitBackend returns the git implementation used for the sync repository.
+func (m *Manager) GitBackend() string {
+	if m.backend == "" {
+		return git.BackendExec
+	}
+	return m.backend
+}
+
+// SetTimeout sets how long operations that talk to the remote repository
+// (clone, fetch, pull and push) may take. A timeout <= 0 disables the limit.
+func (m *Manager) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+// remote runs a git operation that talks to the remote repository, bounded
+// by the configured timeout.
+func (m *Manager) remote(ctx context.Context, op func(context.Context) error) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	err := op(ctx)
+	if errors.Is(err, git.ErrTimeout) {
+		return fmt.Errorf("%w (no response within %s; raise sync.timeout to allow more time)", err, m.timeout)
+	}
+	return err
+}
+
+// SetConfigFile sets the live config file that is mirrored to "config.yaml"
+// in the sync repository. It defaults to config.yaml in the config directory.
+func (m *Manager) SetConfigFile(path string) {
+	m.configFile = path
+}
+
+// SetTemplatesDir sets the live templates directory that is mirrored to the
+// "templates" directory of the sync repository. It defaults to the
+// "templates" directory inside the config directory.
+func (m *Manager) SetTemplatesDir(dir string) {
+	m.templatesDir = dir
+}
+
+// TemplatesDir returns the live templates directory.
+func (m *Manager) TemplatesDir() string {
+	return m.templatesDir
+}
+
+// SetEncryption configures which files are stored encrypted in the sync
+// directory and the passphrase used to encrypt and decrypt them.
+// Patterns are matched against the path relative to the sync directory
+// (e.g. "templates/work/.vscode/mcp.json") and, for template files, against
+// the path inside the template (e.g. ".vscode/mcp.json").
+func (m *Manager) SetEncryption(patterns []string, key string) {
+	m.encrypt = patterns
+	m.key = key
+}
+
+// IsEncryptedPath returns true if relPath (relative to the sync directory)
+// matches an encryption pattern.
+func (m *Manager) IsEncryptedPath(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	candidates := []string{relPath}
+	if parts := strings.SplitN(relPath, "/", 3); len(parts) == 3 && parts[0] == "templates" {
+		candidates = append(candidates, parts[2])
+	}
+	for _, pattern := range m.encrypt {
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+		}
 	}
+	return false
+}
+
+// SetTemplateFilter selects the templates that are synced with glob patterns
+// matched against template names. A template is synced if it matches an
+// include pattern (or include is empty) and no exclude pattern. Templates
+// that are not synced are left alone on both sides.
+func (m *Manager) SetTemplateFilter(include, exclude []string) error {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid template pattern %q: %w", pattern, err)
+		}
+	}
+	m.include, m.exclude = include, exclude
+	return nil
+}
+
+// IsSyncedTemplate returns true if the template called name is synced.
+// The template namespaces of named profiles are never synced by the default
+// profile.
+func (m *Manager) IsSyncedTemplate(name string) bool {
+	if slices.Contains(m.profiles, name) {
+		return false
+	}
+	matchAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(m.include) == 0 || matchAny(m.include)) && !matchAny(m.exclude)
+}
+
+// SetLocalKeys sets the config keys that stay on this machine, as dotted
+// paths such as "editor". They are removed from the synced config file, and
+// their local values are kept when the config file is pulled.
+func (m *Manager) SetLocalKeys(keys []string) {
+	m.localKeys = keys
+}
+
+// LockedFiles returns the encrypted files that could not be decrypted during
+// the last copy from the sync directory because no key was available.
+// Placeholders were written for files that did not exist locally.
+func (m *Manager) LockedFiles() []string {
+	return m.locked
 }
 
 // SyncDirPath returns the path to the sync directory.
 func (m *Manager) SyncDirPath() string {
-	return filepath.Join(m.configDir, SyncDirName)
+	return m.syncDir
 }
 
 // IsInitialized returns true if sync has been initialized.
@@ -63,7 +253,7 @@ func (m *Manager) IsInitialized() bool {
 }
 
 // Initialize sets up the sync directory with the given repository.
-func (m *Manager) Initialize(repoURL, branch string) error {
+func (m *Manager) Initialize(ctx context.Context, repoURL, branch string) error {
 	syncDir := m.SyncDirPath()
 
 	// Create sync directory
@@ -72,7 +262,9 @@ func (m *Manager) Initialize(repoURL, branch string) error {
 	}
 
 	// Try to clone the repository
-	err := m.git.Clone(repoURL, branch)
+	err := m.remote(ctx, func(ctx context.Context) error {
+		return m.git.Clone(ctx, repoURL, branch)
+	})
 	if err != nil {
 		// Only initialize new repo if the remote is empty
 		// For other errors (auth, network, etc.), propagate them
@@ -81,17 +273,17 @@ func (m *Manager) Initialize(repoURL, branch string) error {
 		}
 
 		// If clone fails due to empty repo, initialize a new repo
-		if initErr := m.git.Init(); initErr != nil {
+		if initErr := m.git.Init(ctx); initErr != nil {
 			return fmt.Errorf("init git repo: %w", initErr)
 		}
 
 		// Ensure user config is set for commit
-		if configErr := m.git.EnsureUserConfig(); configErr != nil {
+		if configErr := m.git.EnsureUserConfig(ctx); configErr != nil {
 			return fmt.Errorf("ensure git config: %w", configErr)
 		}
 
 		// Add remote
-		if remoteErr := m.git.RemoteAdd("origin", repoURL); remoteErr != nil {
+		if remoteErr := m.git.RemoteAdd(ctx, "origin", repoURL); remoteErr != nil {
 			return fmt.Errorf("add remote: %w", remoteErr)
 		}
 
@@ -102,62 +294,41 @@ func (m *Manager) Initialize(repoURL, branch string) error {
 			return fmt.Errorf("write readme: %w", writeErr)
 		}
 
-		if addErr := m.git.Add("."); addErr != nil {
+		if addErr := m.git.Add(ctx, "."); addErr != nil {
 			return fmt.Errorf("git add: %w", addErr)
 		}
 
-		if commitErr := m.git.Commit("Initial commit"); commitErr != nil {
+		if commitErr := m.git.Commit(ctx, "Initial commit"); commitErr != nil {
 			return fmt.Errorf("initial commit: %w", commitErr)
 		}
 
 		// Ensure we're on the correct branch
 		// git init may create a different default branch depending on git version/config
-		currentBranch, _ := m.git.GetCurrentBranch()
+		currentBranch, _ := m.git.GetCurrentBranch(ctx)
 		targetBranch := branch
 		if targetBranch == "" {
 			targetBranch = "main"
 		}
 		if currentBranch != targetBranch {
 			// Rename the current branch to the target branch
-			if renameErr := m.git.BranchRename(targetBranch); renameErr != nil {
+			if renameErr := m.git.BranchRename(ctx, targetBranch); renameErr != nil {
 				return fmt.Errorf("rename branch to %s: %w", targetBranch, renameErr)
 			}
 		}
+		return nil
 	}
 
-	return nil
-}
-
-// CopyConfigToSync copies the config file to the sync directory.
-func (m *Manager) CopyConfigToSync() error {
-	srcPath := filepath.Join(m.configDir, "config.yaml")
-	dstPath := filepath.Join(m.SyncDirPath(), "config.yaml")
-	return copyFileIfExists(srcPath, dstPath)
-}
-
-// CopyTemplatesToSync copies the templates directory to the sync directory.
-func (m *Manager) CopyTemplatesToSync() error {
-	srcDir := filepath.Join(m.configDir, "templates")
-	dstDir := filepath.Join(m.SyncDirPath(), "templates")
-	return copyDirIfExists(srcDir, dstDir)
-}
-
-// CopyConfigFromSync copies the config file from the sync directory.
-func (m *Manager) CopyConfigFromSync() error {
-	srcPath := filepath.Join(m.SyncDirPath(), "config.yaml")
-	dstPath := filepath.Join(m.configDir, "config.yaml")
-	return copyFileIfExists(srcPath, dstPath)
-}
+	// Ensure user config is set so later commits and merges succeed
+	if err := m.git.EnsureUserConfig(ctx); err != nil {
+		return fmt.Errorf("ensure git config: %w", err)
+	}
 
-// CopyTemplatesFromSync copies the templates from the sync directory.
-func (m *Manager) CopyTemplatesFromSync() error {
-	srcDir := filepath.Join(m.SyncDirPath(), "templates")
-	dstDir := filepath.Join(m.configDir, "templates")
-	return copyDirIfExists(srcDir, dstDir)
+	m.markPulled()
+	return nil
 }
 
 // GetSyncStatus returns the current sync status.
-func (m *Manager) GetSyncStatus() (*SyncStatus, error) {
+func (m *Manager) GetSyncStatus(ctx context.Context) (*SyncStatus, error) {
 	status := &SyncStatus{}
 
 	if !m.IsInitialized() {
@@ -166,16 +337,23 @@ func (m *Manager) GetSyncStatus() (*SyncStatus, error) {
 	}
 
 	// Get repository info
-	if url, err := m.git.RemoteGetURL("origin"); err == nil {
+	if url, err := m.git.RemoteGetURL(ctx, "origin"); err == nil {
 		status.RepoURL = url
 	}
 
-	if branch, err := m.git.GetCurrentBranch(); err == nil {
+	if branch, err := m.git.GetCurrentBranch(ctx); err == nil {
 		status.Branch = branch
 	}
 
+	if upstream, err := m.git.Upstream(ctx); err == nil {
+		status.Upstream = upstream
+		if ahead, behind, err := m.git.AheadBehind(ctx); err == nil {
+			status.Ahead, status.Behind = ahead, behind
+		}
+	}
+
 	// Check for changes
-	gitStatus, err := m.git.Status()
+	gitStatus, err := m.git.Status(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get git status: %w", err)
 	}
@@ -188,6 +366,10 @@ func (m *Manager) GetSyncStatus() (*SyncStatus, error) {
 		status.Changes = append(status.Changes, gitStatus.Added...)
 		status.Changes = append(status.Changes, gitStatus.Modified...)
 		status.Changes = append(status.Changes, gitStatus.Deleted...)
+		for _, r := range gitStatus.Renamed {
+			status.Changes = append(status.Changes, r.From+" -> "+r.To)
+		}
+		status.Changes = append(status.Changes, gitStatus.Conflicted...)
 		status.Changes = append(status.Changes, gitStatus.Untracked...)
 	}
 
@@ -195,12 +377,29 @@ func (m *Manager) GetSyncStatus() (*SyncStatus, error) {
 }
 
 // StageAndCommit stages all changes and creates a commit.
-func (m *Manager) StageAndCommit(message string) error {
-	if err := m.git.Add("."); err != nil {
+func (m *Manager) StageAndCommit(ctx context.Context, message string) error {
+	if err := m.git.Add(ctx, "."); err != nil {
 		return fmt.Errorf("git add: %w", err)
 	}
 
-	if err := m.git.Commit(message); err != nil {
+	if err := m.git.Commit(ctx, message); err != nil {
+		return fmt.Errorf("git commit: %w", err)
+	}
+
+	return nil
+}
+
+// CommitPaths stages the given paths, relative to the sync directory, and
+// commits them, leaving other changes in the sync directory uncommitted.
+// Deleted paths are staged as deletions.
+func (m *Manager) CommitPaths(ctx context.Context, message string, paths []string) error {
+	for _, p := range paths {
+		if err := m.git.Add(ctx, filepath.FromSlash(p)); err != nil {
+			return fmt.Errorf("git add: %w", err)
+		}
+	}
+
+	if err := m.git.Commit(ctx, message); err != nil {
 		return fmt.Errorf("git commit: %w", err)
 	}
 
@@ -208,121 +407,174 @@ func (m *Manager) StageAndCommit(message string) error {
 }
 
 // Push pushes changes to the remote repository.
-func (m *Manager) Push() error {
-	branch, err := m.git.GetCurrentBranch()
+func (m *Manager) Push(ctx context.Context) error {
+	branch, err := m.git.GetCurrentBranch(ctx)
 	if err != nil {
 		return fmt.Errorf("get current branch: %w", err)
 	}
 
 	// Try normal push first, fall back to push with upstream
-	if err := m.git.Push(); err != nil {
-		if upstreamErr := m.git.PushWithUpstream("origin", branch); upstreamErr != nil {
-			return fmt.Errorf("git push: %w", upstreamErr)
+	return m.remote(ctx, func(ctx context.Context) error {
+		if err := m.git.Push(ctx); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("git push: %w", err)
+			}
+			if upstreamErr := m.git.PushWithUpstream(ctx, "origin", branch); upstreamErr != nil {
+				return fmt.Errorf("git push: %w", upstreamErr)
+			}
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // Pull pulls changes from the remote repository.
-func (m *Manager) Pull() error {
-	return m.git.Pull()
+// Returns ErrUnresolvedConflicts if an earlier merge is still unresolved, and
+// an error wrapping git.ErrMergeConflict if the pull leaves conflicts behind.
+// The time of the pull is recorded on success and on git.ErrNoUpstream.
+func (m *Manager) Pull(ctx context.Context) error {
+	if m.IsMerging() {
+		return ErrUnresolvedConflicts
+	}
+	err := m.remote(ctx, m.git.Pull)
+	// A branch without upstream has nothing to pull, so it is up to date
+	if err == nil || errors.Is(err, git.ErrNoUpstream) {
+		m.markPulled()
+	}
+	return err
 }
 
-// GetGitClient returns the underlying git client.
-func (m *Manager) GetGitClient() *git.Client {
-	return m.git
+// PullPreview is what Pull followed by ApplyPull would do.
+type PullPreview struct {
+	// Upstream is the remote branch pulled from; empty if the branch has
+	// not been pushed yet.
+	Upstream string
+	// Ahead and Behind count the commits that differ from Upstream.
+	Ahead  int
+	Behind int
+	// Plan is the plan of ApplyPull after the pull. It is nil if the pull
+	// merges local and remote commits, as the result is only known once
+	// the merge is done; Items then lists what the remote changed.
+	Plan  *diff.DiffResult
+	Items []string
+	// Locked lists the encrypted files that cannot be decrypted.
+	Locked []string
 }
 
-// copyFileIfExists copies a file if it exists.
-func copyFileIfExists(src, dst string) error {
-	// Check if source exists
-	if _, err := os.Stat(src); os.IsNotExist(err) {
-		return nil
+// PreviewPull computes what Pull and ApplyPull would do, as of the last
+// fetch, without changing anything.
+func (m *Manager) PreviewPull(ctx context.Context) (*PullPreview, error) {
+	if m.IsMerging() {
+		return nil, ErrUnresolvedConflicts
 	}
-
-	// Read source file
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return fmt.Errorf("read source: %w", err)
+	p := &PullPreview{}
+	upstream, err := m.git.Upstream(ctx)
+	if err != nil && !errors.Is(err, git.ErrNoUpstream) {
+		return nil, fmt.Errorf("get upstream: %w", err)
 	}
-
-	// Create destination directory if needed
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return fmt.Errorf("create destination directory: %w", err)
+	if err == nil {
+		p.Upstream = upstream
+		if p.Ahead, p.Behind, err = m.git.AheadBehind(ctx); err != nil {
+			return nil, fmt.Errorf("compare with %s: %w", upstream, err)
+		}
 	}
 
-	// Write destination file
-	if err := os.WriteFile(dst, data, 0644); err != nil {
-		return fmt.Errorf("write destination: %w", err)
+	switch {
+	case p.Behind == 0:
+		// Nothing to pull; only the copy from the sync directory
+		if p.Plan, err = m.PlanPull(); err != nil {
+			return nil, err
+		}
+		p.Locked = m.LockedFiles()
+	case p.Ahead > 0:
+		if p.Items, err = m.RemoteDifferences(ctx); err != nil {
+			return nil, err
+		}
+	default:
+		// A fast-forward: the sync directory becomes the upstream
+		snap, err := m.Snapshot(ctx, upstream)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = snap.Close() }()
+		if p.Plan, err = snap.PlanRestore(""); err != nil {
+			return nil, err
+		}
+		p.Locked = snap.LockedFiles()
 	}
-
-	return nil
+	return p, nil
 }
 
-// copyDirIfExists copies a directory recursively if it exists.
-func copyDirIfExists(src, dst string) error {
-	// Check if source exists
-	srcInfo, err := os.Stat(src)
-	if os.IsNotExist(err) {
-		return nil
-	}
+// LastPull returns when the sync repository was last cloned or pulled, or
+// the zero time if it is not known.
+func (m *Manager) LastPull() time.Time {
+	info, err := os.Stat(m.lastPullPath())
 	if err != nil {
-		return fmt.Errorf("stat source: %w", err)
+		return time.Time{}
 	}
-	if !srcInfo.IsDir() {
-		return fmt.Errorf("source is not a directory: %s", src)
-	}
-
-	// Create destination directory
-	if err := os.MkdirAll(dst, 0755); err != nil {
-		return fmt.Errorf("create destination directory: %w", err)
-	}
-
-	// Walk source directory
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Calculate relative path
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return fmt.Errorf("get relative path: %w", err)
-		}
+	return info.ModTime()
+}
 
-		dstPath := filepath.Join(dst, relPath)
+// markPulled records the current time as the time of the last pull. Failures
+// are ignored: the record only decides whether a later pull can be skipped.
+func (m *Manager) markPulled() {
+	_ = os.WriteFile(m.lastPullPath(), []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0644)
+}
 
-		if info.IsDir() {
-			return os.MkdirAll(dstPath, 0755)
-		}
+// lastPullPath returns the path of the file recording the last pull.
+func (m *Manager) lastPullPath() string {
+	return filepath.Join(m.SyncDirPath(), ".git", lastPullFile)
+}
 
-		return copyFile(path, dstPath)
-	})
+// Fetch updates the remote-tracking branches of the sync repository.
+func (m *Manager) Fetch(ctx context.Context) error {
+	return m.remote(ctx, m.git.Fetch)
 }
 
-// copyFile copies a single file.
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+// RemoteDifferences returns the synced items that differ between the sync
+// repository and its upstream, as of the last fetch: "config.yaml" and
+// "templates/<name>" for each differing template. Returns nil if the branch
+// has no upstream yet.
+func (m *Manager) RemoteDifferences(ctx context.Context) ([]string, error) {
+	upstream, err := m.git.Upstream(ctx)
 	if err != nil {
-		return fmt.Errorf("open source: %w", err)
-	}
-	defer func() { _ = srcFile.Close() }()
-
-	// Create destination directory if needed
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return fmt.Errorf("create destination directory: %w", err)
+		if errors.Is(err, git.ErrNoUpstream) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get upstream: %w", err)
 	}
 
-	dstFile, err := os.Create(dst)
+	paths, err := m.git.DiffNames(ctx, "HEAD", upstream)
 	if err != nil {
-		return fmt.Errorf("create destination: %w", err)
+		return nil, fmt.Errorf("compare with %s: %w", upstream, err)
 	}
-	defer func() { _ = dstFile.Close() }()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("copy content: %w", err)
+	seen := make(map[string]bool)
+	var items []string
+	for _, p := range paths {
+		item := p
+		if parts := strings.SplitN(p, "/", 3); len(parts) == 3 && parts[0] == templatesDirName {
+			if !m.IsSyncedTemplate(parts[1]) {
+				continue
+			}
+			item = parts[0] + "/" + parts[1]
+		} else if p != configFileName || m.profile != "" {
+			continue
+		}
+		if !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
 	}
+	return items, nil
+}
 
-	return nil
+// AheadBehind returns how many commits the sync repository is ahead of and
+// behind its upstream, as of the last fetch.
+func (m *Manager) AheadBehind(ctx context.Context) (ahead, behind int, err error) {
+	return m.git.AheadBehind(ctx)
+}
+
+// GetGitClient returns the underlying git client.
+func (m *Manager) GetGitClient() git.Client {
+	return m.git
 }
@@ -1,11 +1,15 @@
 package sync
 
 import (
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/openjny/dotgh/internal/diff"
+	"github.com/openjny/dotgh/internal/git"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -69,7 +73,7 @@ func TestInitialize(t *testing.T) {
 		configDir := t.TempDir()
 		m := NewManager(configDir)
 
-		err := m.Initialize(bareDir, "main")
+		err := m.Initialize(t.Context(), bareDir, "main")
 		require.NoError(t, err)
 
 		// Verify sync directory was created
@@ -86,12 +90,12 @@ func TestInitialize(t *testing.T) {
 
 		// Verify remote was added
 		client := m.GetGitClient()
-		url, err := client.RemoteGetURL("origin")
+		url, err := client.RemoteGetURL(t.Context(), "origin")
 		require.NoError(t, err)
 		assert.Equal(t, bareDir, url)
 
 		// Verify correct branch
-		branch, err := client.GetCurrentBranch()
+		branch, err := client.GetCurrentBranch(t.Context())
 		require.NoError(t, err)
 		assert.Equal(t, "main", branch)
 	})
@@ -119,7 +123,7 @@ func TestInitialize(t *testing.T) {
 		configDir := t.TempDir()
 		m := NewManager(configDir)
 
-		err := m.Initialize(srcDir, "")
+		err := m.Initialize(t.Context(), srcDir, "")
 		require.NoError(t, err)
 
 		// Verify files were cloned
@@ -160,10 +164,10 @@ func TestInitialize(t *testing.T) {
 		configDir := t.TempDir()
 		m := NewManager(configDir)
 
-		err := m.Initialize(srcDir, "")
+		err := m.Initialize(t.Context(), srcDir, "")
 		require.NoError(t, err)
 
-		branch, err := m.GetGitClient().GetCurrentBranch()
+		branch, err := m.GetGitClient().GetCurrentBranch(t.Context())
 		require.NoError(t, err)
 		assert.Equal(t, "main", branch)
 	})
@@ -195,10 +199,10 @@ func TestInitialize(t *testing.T) {
 		configDir := t.TempDir()
 		m := NewManager(configDir)
 
-		err := m.Initialize(srcDir, "develop")
+		err := m.Initialize(t.Context(), srcDir, "develop")
 		require.NoError(t, err)
 
-		branch, err := m.GetGitClient().GetCurrentBranch()
+		branch, err := m.GetGitClient().GetCurrentBranch(t.Context())
 		require.NoError(t, err)
 		assert.Equal(t, "develop", branch)
 	})
@@ -233,7 +237,7 @@ func TestStageAndCommit(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(syncDir, "config.yaml"), []byte("test"), 0644))
 
 		m := NewManager(tmpDir)
-		err := m.StageAndCommit("test commit")
+		err := m.StageAndCommit(t.Context(), "test commit")
 		require.NoError(t, err)
 
 		// Verify commit was created
@@ -245,12 +249,65 @@ func TestStageAndCommit(t *testing.T) {
 	})
 }
 
+func TestCommitPaths(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	syncDir := filepath.Join(tmpDir, ".sync")
+	writeFiles(t, syncDir, map[string]string{
+		"templates/work/AGENTS.md": "# Work",
+		"templates/old/AGENTS.md":  "# Old",
+	})
+	setupGitRepo(t, syncDir)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = syncDir
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return string(out)
+	}
+	run("add", ".")
+	run("commit", "-m", "initial")
+
+	writeFiles(t, syncDir, map[string]string{
+		"templates/work/AGENTS.md": "# Work v2",
+		"templates/home/AGENTS.md": "# Home",
+	})
+	require.NoError(t, os.RemoveAll(filepath.Join(syncDir, "templates", "old")))
+
+	m := NewManager(tmpDir)
+	require.NoError(t, m.CommitPaths(t.Context(), "update", []string{"templates/work/AGENTS.md", "templates/old/AGENTS.md"}))
+
+	assert.Equal(t, "D\ttemplates/old/AGENTS.md\nM\ttemplates/work/AGENTS.md\n", run("show", "--name-status", "--format=", "HEAD"))
+	assert.Equal(t, "?? templates/home/\n", run("status", "--porcelain"), "other changes are left uncommitted")
+}
+
+func TestPullWithoutUpstream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	bareDir := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "--bare", "--initial-branch=main", bareDir).Run())
+	m := NewManager(t.TempDir())
+	require.NoError(t, m.Initialize(t.Context(), bareDir, "main"))
+	require.True(t, m.LastPull().IsZero())
+
+	// Nothing was pushed yet, so there is nothing to pull and the
+	// repository counts as up to date
+	err := m.Pull(t.Context())
+	require.ErrorIs(t, err, git.ErrNoUpstream)
+	assert.False(t, m.LastPull().IsZero())
+}
+
 func TestGetSyncStatus(t *testing.T) {
 	t.Run("returns not initialized when sync dir does not exist", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		m := NewManager(tmpDir)
 
-		status, err := m.GetSyncStatus()
+		status, err := m.GetSyncStatus(t.Context())
 		require.NoError(t, err)
 		assert.Equal(t, StatusNotInitialized, status.State)
 	})
@@ -285,7 +342,7 @@ func TestGetSyncStatus(t *testing.T) {
 		require.NoError(t, cmd.Run())
 
 		m := NewManager(tmpDir)
-		status, err := m.GetSyncStatus()
+		status, err := m.GetSyncStatus(t.Context())
 		require.NoError(t, err)
 
 		assert.Equal(t, StatusClean, status.State)
@@ -321,7 +378,7 @@ func TestGetSyncStatus(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(syncDir, "new-file.txt"), []byte("new"), 0644))
 
 		m := NewManager(tmpDir)
-		status, err := m.GetSyncStatus()
+		status, err := m.GetSyncStatus(t.Context())
 		require.NoError(t, err)
 
 		assert.Equal(t, StatusDirty, status.State)
@@ -395,6 +452,317 @@ func TestCopyTemplatesToSync(t *testing.T) {
 	})
 }
 
+func TestEncryptedCopy(t *testing.T) {
+	const mcpJSON = `{"servers": {"x": {"env": {"TOKEN": "secret"}}}}`
+
+	setup := func(t *testing.T) (string, string) {
+		tmpDir := t.TempDir()
+		syncDir := filepath.Join(tmpDir, ".sync")
+		require.NoError(t, os.MkdirAll(syncDir, 0755))
+		templateDir := filepath.Join(tmpDir, "templates", "work", ".vscode")
+		require.NoError(t, os.MkdirAll(templateDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "mcp.json"), []byte(mcpJSON), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "templates", "work", "AGENTS.md"), []byte("# Agents"), 0644))
+		return tmpDir, syncDir
+	}
+
+	t.Run("encrypts matching files when copying to sync", func(t *testing.T) {
+		tmpDir, syncDir := setup(t)
+		m := NewManager(tmpDir)
+		m.SetEncryption([]string{".vscode/mcp.json"}, "key")
+		require.NoError(t, m.CopyTemplatesToSync())
+
+		synced, err := os.ReadFile(filepath.Join(syncDir, "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.True(t, IsEncrypted(synced))
+
+		plain, err := os.ReadFile(filepath.Join(syncDir, "templates", "work", "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Agents", string(plain))
+
+		// Copying again without changes leaves the ciphertext untouched
+		require.NoError(t, m.CopyTemplatesToSync())
+		again, err := os.ReadFile(filepath.Join(syncDir, "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.Equal(t, synced, again)
+
+		plan, err := m.PlanPush()
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+	})
+
+	t.Run("refuses to copy plaintext without a key", func(t *testing.T) {
+		tmpDir, _ := setup(t)
+		m := NewManager(tmpDir)
+		m.SetEncryption([]string{"templates/*/.vscode/mcp.json"}, "")
+		err := m.CopyTemplatesToSync()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no sync key")
+	})
+
+	t.Run("decrypts when copying from sync", func(t *testing.T) {
+		srcDir, _ := setup(t)
+		src := NewManager(srcDir)
+		src.SetEncryption([]string{".vscode/mcp.json"}, "key")
+		require.NoError(t, src.CopyTemplatesToSync())
+
+		dstDir := t.TempDir()
+		require.NoError(t, os.Rename(filepath.Join(srcDir, ".sync"), filepath.Join(dstDir, ".sync")))
+
+		dst := NewManager(dstDir)
+		dst.SetEncryption(nil, "key")
+		require.NoError(t, dst.CopyTemplatesFromSync())
+
+		content, err := os.ReadFile(filepath.Join(dstDir, "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.Equal(t, mcpJSON, string(content))
+		assert.Empty(t, dst.LockedFiles())
+	})
+
+	t.Run("writes placeholders without a key", func(t *testing.T) {
+		srcDir, _ := setup(t)
+		src := NewManager(srcDir)
+		src.SetEncryption([]string{".vscode/mcp.json"}, "key")
+		require.NoError(t, src.CopyTemplatesToSync())
+
+		dstDir := t.TempDir()
+		require.NoError(t, os.Rename(filepath.Join(srcDir, ".sync"), filepath.Join(dstDir, ".sync")))
+
+		dst := NewManager(dstDir)
+		require.NoError(t, dst.CopyTemplatesFromSync())
+		assert.Equal(t, []string{"templates/work/.vscode/mcp.json"}, dst.LockedFiles())
+
+		content, err := os.ReadFile(filepath.Join(dstDir, "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.True(t, IsPlaceholder(content))
+
+		// Placeholders are never pushed back over the encrypted file
+		dst.SetEncryption([]string{".vscode/mcp.json"}, "")
+		require.NoError(t, dst.CopyTemplatesToSync())
+		synced, err := os.ReadFile(filepath.Join(dstDir, ".sync", "templates", "work", ".vscode", "mcp.json"))
+		require.NoError(t, err)
+		assert.True(t, IsEncrypted(synced))
+	})
+}
+
+func TestIsEncryptedPath(t *testing.T) {
+	m := NewManager(t.TempDir())
+	m.SetEncryption([]string{".vscode/mcp.json", "templates/private/*"}, "")
+
+	assert.True(t, m.IsEncryptedPath("templates/work/.vscode/mcp.json"))
+	assert.True(t, m.IsEncryptedPath("templates/private/AGENTS.md"))
+	assert.False(t, m.IsEncryptedPath("templates/work/AGENTS.md"))
+	assert.False(t, m.IsEncryptedPath("config.yaml"))
+}
+
+func TestPlanPush(t *testing.T) {
+	t.Run("mirrors additions, modifications and deletions", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		syncDir := filepath.Join(tmpDir, ".sync")
+		writeFiles(t, syncDir, map[string]string{
+			"README.md":                  "# dotgh sync",
+			"templates/same/AGENTS.md":   "# Same",
+			"templates/old/AGENTS.md":    "# Old",
+			"templates/edited/AGENTS.md": "# Before",
+		})
+		writeFiles(t, tmpDir, map[string]string{
+			"config.yaml":                "includes: []\n",
+			"templates/same/AGENTS.md":   "# Same",
+			"templates/new/AGENTS.md":    "# New",
+			"templates/edited/AGENTS.md": "# After",
+		})
+
+		m := NewManager(tmpDir)
+		plan, err := m.PlanPush()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config.yaml", "templates/new/AGENTS.md"}, changePaths(plan.Added))
+		assert.Equal(t, []string{"templates/edited/AGENTS.md"}, changePaths(plan.Modified))
+		assert.Equal(t, []string{"templates/old/AGENTS.md"}, changePaths(plan.Deleted))
+
+		require.NoError(t, m.ApplyPush(plan))
+		_, err = os.Stat(filepath.Join(syncDir, "templates", "old"))
+		assert.True(t, os.IsNotExist(err), "deleted template directory should be pruned")
+		_, err = os.Stat(filepath.Join(syncDir, "README.md"))
+		assert.NoError(t, err, "files outside config and templates are left alone")
+
+		plan, err = m.PlanPush()
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+	})
+
+	t.Run("honors a custom templates directory", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		customDir := t.TempDir()
+		writeFiles(t, customDir, map[string]string{"custom/AGENTS.md": "# Custom"})
+		writeFiles(t, tmpDir, map[string]string{"templates/ignored/AGENTS.md": "# Ignored"})
+
+		m := NewManager(tmpDir)
+		m.SetTemplatesDir(customDir)
+		require.NoError(t, m.CopyTemplatesToSync())
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, ".sync", "templates", "custom", "AGENTS.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Custom", string(content))
+		_, err = os.Stat(filepath.Join(tmpDir, ".sync", "templates", "ignored"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("returns nothing when config dir is empty", func(t *testing.T) {
+		m := NewManager(t.TempDir())
+		plan, err := m.PlanPush()
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+	})
+}
+
+func TestPlanPushTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, filepath.Join(tmpDir, ".sync"), map[string]string{
+		"config.yaml":              "includes: []\n",
+		"templates/work/AGENTS.md": "# Work",
+		"templates/home/AGENTS.md": "# Home",
+	})
+	writeFiles(t, tmpDir, map[string]string{
+		"config.yaml":              "includes:\n  - AGENTS.md\n",
+		"templates/work/AGENTS.md": "# Work v2",
+		"templates/new/AGENTS.md":  "# New",
+	})
+	m := NewManager(tmpDir)
+	require.NoError(t, m.SetTemplateFilter(nil, []string{"new"}))
+
+	// Other templates and the config file are left out
+	plan, err := m.PlanPushTemplate("work")
+	require.NoError(t, err)
+	assert.Empty(t, plan.Added)
+	assert.Equal(t, []string{"templates/work/AGENTS.md"}, changePaths(plan.Modified))
+	assert.Empty(t, plan.Deleted)
+
+	// A template missing locally is deleted
+	plan, err = m.PlanPushTemplate("home")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"templates/home/AGENTS.md"}, changePaths(plan.Deleted))
+
+	// Templates that are not synced have no changes
+	plan, err = m.PlanPushTemplate("new")
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+
+	plan, err = m.PlanPushConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config.yaml"}, changePaths(plan.Modified))
+	assert.Empty(t, plan.Added)
+	assert.Empty(t, plan.Deleted)
+}
+
+func TestPlanPushAs(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, filepath.Join(tmpDir, ".sync"), map[string]string{
+		"templates/work/AGENTS.md": "# Work",
+		"templates/work/old.md":    "old",
+		"templates/home/AGENTS.md": "# Home",
+	})
+	writeFiles(t, tmpDir, map[string]string{
+		"templates/work/AGENTS.md": "# Work",
+		"templates/work/old.md":    "old",
+		"templates/home/AGENTS.md": "# Home",
+	})
+	m := NewManager(tmpDir)
+
+	// The planned state of a template replaces its local copy
+	planned := t.TempDir()
+	writeFiles(t, planned, map[string]string{"AGENTS.md": "# Work v2", "new.md": "new"})
+	plan, err := m.PlanPushAs("work", planned)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"templates/work/new.md"}, changePaths(plan.Added))
+	assert.Equal(t, []string{"templates/work/AGENTS.md"}, changePaths(plan.Modified))
+	assert.Equal(t, []string{"templates/work/old.md"}, changePaths(plan.Deleted))
+
+	// A missing directory deletes the template
+	plan, err = m.PlanPushAs("home", filepath.Join(planned, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, plan.Added)
+	assert.Equal(t, []string{"templates/home/AGENTS.md"}, changePaths(plan.Deleted))
+
+	// Nothing local was changed
+	plan, err = m.PlanPush()
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+}
+
+func TestSetConfigFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "custom.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("editor: vim\n"), 0644))
+	writeFiles(t, tmpDir, map[string]string{"config.yaml": "editor: nano\n"})
+	m := NewManager(tmpDir)
+	m.SetConfigFile(configFile)
+
+	plan, err := m.PlanPush()
+	require.NoError(t, err)
+	require.NoError(t, m.ApplyPush(plan))
+	data, err := os.ReadFile(filepath.Join(m.SyncDirPath(), "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "editor: vim\n", string(data))
+
+	writeFiles(t, m.SyncDirPath(), map[string]string{"config.yaml": "editor: code\n"})
+	plan, err = m.PlanPull()
+	require.NoError(t, err)
+	require.NoError(t, m.ApplyPull(plan))
+	data, err = os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "editor: code\n", string(data))
+
+	// The config file in the config directory is not the one in use
+	data, err = os.ReadFile(filepath.Join(tmpDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "editor: nano\n", string(data))
+}
+
+func TestPlanPull(t *testing.T) {
+	t.Run("deleted templates do not resurrect", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		customDir := t.TempDir()
+		writeFiles(t, filepath.Join(tmpDir, ".sync"), map[string]string{
+			"templates/kept/AGENTS.md": "# Kept",
+		})
+		writeFiles(t, customDir, map[string]string{
+			"kept/AGENTS.md":    "# Kept",
+			"removed/AGENTS.md": "# Removed",
+		})
+
+		m := NewManager(tmpDir)
+		m.SetTemplatesDir(customDir)
+		plan, err := m.PlanPull()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"templates/removed/AGENTS.md"}, changePaths(plan.Deleted))
+
+		require.NoError(t, m.ApplyPull(plan))
+		_, err = os.Stat(filepath.Join(customDir, "removed"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(customDir, "kept", "AGENTS.md"))
+		assert.NoError(t, err)
+	})
+}
+
+// writeFiles creates files under dir from a map of relative path to content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// changePaths returns the paths of the given changes.
+func changePaths(changes []diff.FileChange) []string {
+	paths := []string{}
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
 func TestCopyConfigFromSync(t *testing.T) {
 	t.Run("copies config from sync to config dir", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -473,3 +841,264 @@ func setupGitRepo(t *testing.T, dir string) {
 	cmd.Dir = dir
 	require.NoError(t, cmd.Run())
 }
+
+func TestHasConflictMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "clean file", content: "# Agents\n\nSome text\n", want: false},
+		{name: "full conflict", content: "<<<<<<< HEAD\nlocal\n=======\nremote\n>>>>>>> origin/main\n", want: true},
+		{name: "separator only", content: "a\n=======\nb\n", want: true},
+		{name: "markdown rule is not a marker", content: "Title\n========\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HasConflictMarkers([]byte(tt.content)))
+		})
+	}
+}
+
+func TestRemoteDifferences(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	bareDir := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	cmd.Dir = bareDir
+	require.NoError(t, cmd.Run())
+
+	push := func(m *Manager, files map[string]string) {
+		writeFiles(t, m.SyncDirPath(), files)
+		require.NoError(t, m.StageAndCommit(t.Context(), "update"))
+		require.NoError(t, m.Push(t.Context()))
+	}
+
+	m1 := NewManager(t.TempDir())
+	require.NoError(t, m1.Initialize(t.Context(), bareDir, "main"))
+	push(m1, map[string]string{"config.yaml": "a", "templates/one/AGENTS.md": "a"})
+
+	m2 := NewManager(t.TempDir())
+	require.NoError(t, m2.Initialize(t.Context(), bareDir, "main"))
+
+	items, err := m2.RemoteDifferences(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	push(m1, map[string]string{
+		"config.yaml":               "b",
+		"templates/one/AGENTS.md":   "b",
+		"templates/one/.x/mcp.json": "b",
+		"templates/two/AGENTS.md":   "b",
+		"README.md":                 "b",
+	})
+	require.NoError(t, m2.Fetch(t.Context()))
+
+	status, err := m2.GetSyncStatus(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "origin/main", status.Upstream)
+	assert.Equal(t, 0, status.Ahead)
+	assert.Equal(t, 1, status.Behind)
+
+	items, err = m2.RemoteDifferences(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config.yaml", "templates/one", "templates/two"}, items)
+}
+
+func TestPreviewPull(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	bareDir := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare", "--initial-branch=main")
+	cmd.Dir = bareDir
+	require.NoError(t, cmd.Run())
+
+	push := func(m *Manager, files map[string]string) {
+		writeFiles(t, m.SyncDirPath(), files)
+		require.NoError(t, m.StageAndCommit(t.Context(), "update"))
+		require.NoError(t, m.Push(t.Context()))
+	}
+
+	m1 := NewManager(t.TempDir())
+	require.NoError(t, m1.Initialize(t.Context(), bareDir, "main"))
+	push(m1, map[string]string{"templates/one/AGENTS.md": "a"})
+
+	m2 := NewManager(t.TempDir())
+	require.NoError(t, m2.Initialize(t.Context(), bareDir, "main"))
+
+	// Up to date: the plan copies the sync directory
+	preview, err := m2.PreviewPull(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 0, preview.Behind)
+	assert.Equal(t, []string{"templates/one/AGENTS.md"}, changePaths(preview.Plan.Added))
+	require.NoError(t, m2.ApplyPull(preview.Plan))
+
+	// Behind: the plan is that of the remote version
+	push(m1, map[string]string{"templates/one/AGENTS.md": "b", "templates/two/AGENTS.md": "b"})
+	require.NoError(t, m2.Fetch(t.Context()))
+	preview, err = m2.PreviewPull(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, preview.Behind)
+	assert.Equal(t, []string{"templates/two/AGENTS.md"}, changePaths(preview.Plan.Added))
+	assert.Equal(t, []string{"templates/one/AGENTS.md"}, changePaths(preview.Plan.Modified))
+	content, err := os.ReadFile(filepath.Join(m2.SyncDirPath(), "templates", "one", "AGENTS.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(content), "the sync directory must not change")
+
+	// Diverged: the pull merges, so there is no plan
+	writeFiles(t, m2.SyncDirPath(), map[string]string{"templates/three/AGENTS.md": "c"})
+	require.NoError(t, m2.StageAndCommit(t.Context(), "local"))
+	preview, err = m2.PreviewPull(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, preview.Ahead)
+	assert.Nil(t, preview.Plan)
+	assert.Equal(t, []string{"templates/one", "templates/three", "templates/two"}, preview.Items)
+}
+
+func TestNetworkTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	// A remote that accepts connections but never responds
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+
+	m := NewManager(t.TempDir())
+	require.NoError(t, m.SetGitBackend(git.BackendGoGit))
+	m.SetTimeout(200 * time.Millisecond)
+
+	err = m.Initialize(t.Context(), "http://"+ln.Addr().String()+"/repo.git", "main")
+	assert.ErrorIs(t, err, git.ErrTimeout)
+	assert.ErrorContains(t, err, "no response within 200ms")
+}
+
+func TestSelectiveSync(t *testing.T) {
+	t.Run("template filter leaves other templates alone on both sides", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeFiles(t, tmpDir, map[string]string{
+			"templates/home/AGENTS.md":     "# Home",
+			"templates/work-api/AGENTS.md": "# Work",
+		})
+		writeFiles(t, filepath.Join(tmpDir, ".sync"), map[string]string{
+			"templates/work-web/AGENTS.md": "# Work web",
+		})
+
+		m := NewManager(tmpDir)
+		require.NoError(t, m.SetTemplateFilter(nil, []string{"work-*"}))
+		assert.True(t, m.IsSyncedTemplate("home"))
+		assert.False(t, m.IsSyncedTemplate("work-api"))
+
+		plan, err := m.PlanPush()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"templates/home/AGENTS.md"}, changePaths(plan.Added))
+		assert.Empty(t, plan.Deleted, "excluded templates in the sync repository are kept")
+
+		plan, err = m.PlanPull()
+		require.NoError(t, err)
+		assert.Empty(t, plan.Added, "excluded templates are not pulled")
+		assert.Equal(t, []string{"templates/home/AGENTS.md"}, changePaths(plan.Deleted))
+
+		require.NoError(t, m.SetTemplateFilter([]string{"work-*"}, nil))
+		assert.False(t, m.IsSyncedTemplate("home"))
+		assert.True(t, m.IsSyncedTemplate("work-api"))
+
+		assert.Error(t, m.SetTemplateFilter([]string{"["}, nil))
+	})
+
+	t.Run("local keys are not pushed and survive a pull", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		syncDir := filepath.Join(tmpDir, ".sync")
+		writeFiles(t, tmpDir, map[string]string{
+			"config.yaml": "editor: vim\nincludes:\n  - AGENTS.md\n",
+		})
+
+		m := NewManager(tmpDir)
+		m.SetLocalKeys([]string{"editor"})
+
+		plan, err := m.PlanPush()
+		require.NoError(t, err)
+		require.NoError(t, m.ApplyPush(plan))
+		synced, err := os.ReadFile(filepath.Join(syncDir, "config.yaml"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(synced), "editor")
+		assert.Contains(t, string(synced), "AGENTS.md")
+
+		// Changing only a local key is not a change to sync
+		writeFiles(t, tmpDir, map[string]string{
+			"config.yaml": "editor: nano\nincludes:\n  - AGENTS.md\n",
+		})
+		plan, err = m.PlanPush()
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+
+		// Another machine changes shared keys and its own editor
+		writeFiles(t, syncDir, map[string]string{
+			"config.yaml": "editor: code\nincludes:\n  - CLAUDE.md\n",
+		})
+		plan, err = m.PlanPull()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config.yaml"}, changePaths(plan.Modified))
+		require.NoError(t, m.ApplyPull(plan))
+
+		local, err := os.ReadFile(filepath.Join(tmpDir, "config.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(local), "editor: nano")
+		assert.Contains(t, string(local), "CLAUDE.md")
+
+		plan, err = m.PlanPull()
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+	})
+}
+
+func TestProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"work/.git", "oss/.git", "broken"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ProfilesDirName, dir), 0755))
+	}
+
+	profiles, err := Profiles(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"oss", "work"}, profiles)
+
+	assert.NoError(t, ValidateProfileName("work_2"))
+	for _, name := range []string{"", ".hidden", "a/b", "-x"} {
+		assert.Error(t, ValidateProfileName(name), name)
+	}
+
+	writeFiles(t, tmpDir, map[string]string{
+		"config.yaml":                   "includes: []\n",
+		"templates/home/AGENTS.md":      "# Home",
+		"templates/work/api/AGENTS.md":  "# Work API",
+		"templates/broken/AGENTS.md":    "# Broken",
+		"templates/oss/lib/CLAUDE.md":   "# OSS",
+		"templates/work/.history/x.txt": "local",
+	})
+
+	plan, err := NewManager(tmpDir).PlanPush()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config.yaml", "templates/broken/AGENTS.md", "templates/home/AGENTS.md"}, changePaths(plan.Added))
+
+	work := NewProfileManager(tmpDir, "work")
+	assert.Equal(t, "work", work.Profile())
+	assert.Equal(t, filepath.Join(tmpDir, ProfilesDirName, "work"), work.SyncDirPath())
+	plan, err = work.PlanPush()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"templates/api/AGENTS.md"}, changePaths(plan.Added))
+}